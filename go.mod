@@ -1,6 +1,6 @@
 module github.com/pucke-dev/go-redismigrate
 
-go 1.24.5
+go 1.25
 
 require (
 	github.com/charmbracelet/bubbles v0.18.0
@@ -0,0 +1,85 @@
+// Package archive reads and writes archive files holding the DUMP payloads of
+// keys, independent of the RDB file of an instance.
+//
+// An archive starts with a header, followed by one record per key and an end
+// marker:
+//
+//	"RMARCHIVE"       magic
+//	uint16            format version, big-endian
+//	int64             number of records, big-endian, -1 if unknown
+//	uvarint, JSON     metadata, see [Header]
+//	records           0x01, uvarint length and key, uvarint length and DUMP
+//	                  payload, uvarint expire time in Unix milliseconds or 0
+//	0x00, uint32      end marker and big-endian CRC-32 (IEEE) of the records
+//
+// The number of records is written up front if the output is a regular file,
+// and unknown if the archive was streamed.
+package archive
+
+import (
+	"errors"
+	"net/url"
+	"time"
+)
+
+// Version is the format version written by [Writer].
+const Version = 1
+
+const (
+	magic = "RMARCHIVE"
+
+	recordKey byte = 0x01
+	recordEnd byte = 0x00
+
+	// countOffset is the offset of the number of records in the file.
+	countOffset = len(magic) + 2
+
+	// maxMetadataSize limits the metadata read, guarding against files that
+	// are no archives.
+	maxMetadataSize = 1 << 20
+)
+
+// ErrChecksum is returned when the checksum at the end of an archive doesn't
+// match its records.
+var ErrChecksum = errors.New("archive checksum mismatch")
+
+// ErrTruncated is returned when an archive ends before its end marker, e.g.
+// because the export writing it was interrupted.
+var ErrTruncated = errors.New("archive is incomplete")
+
+// Header describes the keys of an archive.
+type Header struct {
+	// Source is the connection string of the instance the keys were exported
+	// from, with its password redacted.
+	Source string `json:"source"`
+
+	// Pattern is the key pattern of the export.
+	Pattern string `json:"pattern"`
+
+	// Created is the time the export started.
+	Created time.Time `json:"created"`
+
+	// Keys is the number of keys in the archive, or -1 if it's unknown.
+	Keys int64 `json:"-"`
+}
+
+// Record is a key of an archive.
+type Record struct {
+	Key string
+
+	// Payload is the DUMP payload of the key.
+	Payload string
+
+	// ExpireAt is the time the key expires, or zero if it doesn't.
+	ExpireAt time.Time
+}
+
+// RedactSource hides the password of a connection string for
+// [Header.Source].
+func RedactSource(connStr string) string {
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,193 @@
+package archive
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// Reader reads the records of an archive in order.
+type Reader struct {
+	r       *bufio.Reader
+	header  Header
+	version int
+	crc     hash.Hash32
+	done    bool
+
+	// offset is the number of bytes of the archive read so far.
+	offset int64
+}
+
+// NewReader reads the header of an archive from r.
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := &Reader{r: bufio.NewReader(r), crc: crc32.NewIEEE()}
+
+	fixed := make([]byte, countOffset+8)
+	if _, err := io.ReadFull(reader.r, fixed); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, errors.New("not an archive")
+		}
+		return nil, fmt.Errorf("failed to read archive header: %w", err)
+	}
+	if string(fixed[:len(magic)]) != magic {
+		return nil, errors.New("not an archive")
+	}
+
+	reader.version = int(binary.BigEndian.Uint16(fixed[len(magic):]))
+	if reader.version > Version {
+		return nil, fmt.Errorf("archive version %d is not supported, upgrade to read it", reader.version)
+	}
+
+	size, err := binary.ReadUvarint(reader.r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive header: %w", unexpected(err))
+	}
+	if size > maxMetadataSize {
+		return nil, fmt.Errorf("archive header of %d bytes exceeds the limit", size)
+	}
+
+	metadata := make([]byte, size)
+	if _, err := io.ReadFull(reader.r, metadata); err != nil {
+		return nil, fmt.Errorf("failed to read archive header: %w", unexpected(err))
+	}
+	if err := json.Unmarshal(metadata, &reader.header); err != nil {
+		return nil, fmt.Errorf("failed to decode archive header: %w", err)
+	}
+	reader.header.Keys = int64(binary.BigEndian.Uint64(fixed[countOffset:]))
+	reader.offset = int64(len(fixed) + len(binary.AppendUvarint(nil, size)) + len(metadata))
+
+	return reader, nil
+}
+
+// Header returns the header of the archive.
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Version returns the format version of the archive.
+func (r *Reader) Version() int {
+	return r.version
+}
+
+// Next returns the next record of the archive, or [io.EOF] once its end has
+// been read and its checksum verified. [ErrTruncated] is returned if the
+// archive ends before.
+func (r *Reader) Next() (*Record, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+
+	kind, err := r.r.ReadByte()
+	if err != nil {
+		return nil, truncated(err)
+	}
+	r.offset++
+
+	switch kind {
+	case recordEnd:
+		r.done = true
+		sum := make([]byte, 4)
+		if _, err := io.ReadFull(r.r, sum); err != nil {
+			return nil, truncated(err)
+		}
+		r.offset += int64(len(sum))
+		if binary.BigEndian.Uint32(sum) != r.crc.Sum32() {
+			return nil, ErrChecksum
+		}
+		return nil, io.EOF
+
+	case recordKey:
+		r.crc.Write([]byte{kind})
+
+	default:
+		return nil, fmt.Errorf("unknown archive record %#x", kind)
+	}
+
+	key, err := r.readString()
+	if err != nil {
+		return nil, err
+	}
+	payload, err := r.readString()
+	if err != nil {
+		return nil, err
+	}
+	expireAt, err := r.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+
+	record := &Record{Key: key, Payload: payload}
+	if expireAt > 0 {
+		record.ExpireAt = time.UnixMilli(int64(expireAt))
+	}
+	return record, nil
+}
+
+func (r *Reader) readUvarint() (uint64, error) {
+	var buf [binary.MaxVarintLen64]byte
+	n := 0
+	for {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return 0, truncated(err)
+		}
+		if n == len(buf) {
+			return 0, errors.New("invalid length in archive")
+		}
+		buf[n] = b
+		n++
+		if b < 0x80 {
+			break
+		}
+	}
+
+	r.crc.Write(buf[:n])
+	r.offset += int64(n)
+	v, _ := binary.Uvarint(buf[:n])
+	return v, nil
+}
+
+func (r *Reader) readString() (string, error) {
+	n, err := r.readUvarint()
+	if err != nil {
+		return "", err
+	}
+
+	// Read in chunks, so that a corrupt length doesn't allocate more than
+	// the file holds.
+	var buf []byte
+	for n > 0 {
+		chunk := make([]byte, min(n, 1<<20))
+		if _, err := io.ReadFull(r.r, chunk); err != nil {
+			return "", truncated(err)
+		}
+		buf = append(buf, chunk...)
+		n -= uint64(len(chunk))
+	}
+
+	r.crc.Write(buf)
+	r.offset += int64(len(buf))
+	return string(buf), nil
+}
+
+// truncated turns the end of the input within a record into [ErrTruncated].
+func truncated(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrTruncated
+	}
+	return err
+}
+
+// unexpected turns the end of the input within the header into an error.
+func unexpected(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
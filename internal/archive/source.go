@@ -0,0 +1,194 @@
+package archive
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+	"github.com/pucke-dev/go-redismigrate/internal/rdb"
+)
+
+// Source reads the keys of an archive file. It implements
+// [migrate.RedisClient] as the source of a migration, and [migrate.Inspector]
+// for dry runs.
+type Source struct {
+	*migrate.FileKeys[int64]
+
+	f      *os.File
+	size   int64
+	header Header
+}
+
+// Open opens an archive file and reads its header.
+func Open(path string) (*Source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	s := &Source{FileKeys: migrate.NewFileKeys[int64](), f: f, size: info.Size()}
+
+	reader, err := s.reader(0)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	s.header = reader.Header()
+
+	return s, nil
+}
+
+// Header returns the header of the archive.
+func (s *Source) Header() Header {
+	return s.header
+}
+
+// reader returns a reader of the archive starting at offset, which has to be
+// the start of the file or of a record.
+func (s *Source) reader(offset int64) (*Reader, error) {
+	section := io.NewSectionReader(s.f, offset, s.size-offset)
+	if offset == 0 {
+		return NewReader(section)
+	}
+	return &Reader{r: bufio.NewReader(section), crc: crc32.NewIEEE(), offset: offset}, nil
+}
+
+// read returns a reader of the records of the archive matching a scan, which
+// verifies the checksum once the end has been read.
+func (s *Source) read(ctx context.Context, opts migrate.ScanOptions) migrate.FileReader[int64] {
+	return func(yield func(key string, loc, offset, next int64) error) error {
+		reader, err := s.reader(0)
+		if err != nil {
+			return err
+		}
+
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			offset := reader.offset
+			record, err := reader.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read archive: %w", err)
+			}
+
+			if !matches(record, opts) {
+				continue
+			}
+			if err := yield(record.Key, offset, offset, reader.offset); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ScanKeys reads the keys of the archive in order.
+func (s *Source) ScanKeys(ctx context.Context, opts migrate.ScanOptions, batches chan<- migrate.KeyBatch) error {
+	return s.Scan(ctx, opts, batches, s.read(ctx, opts))
+}
+
+// matches reports whether a record matches the pattern and types of a scan.
+func matches(record *Record, opts migrate.ScanOptions) bool {
+	if !migrate.MatchPattern(opts.Pattern, record.Key) {
+		return false
+	}
+	return len(opts.Types) == 0 || slices.Contains(opts.Types, payloadType(record.Payload))
+}
+
+// payloadType returns the data type of a DUMP payload.
+func payloadType(payload string) string {
+	if payload == "" {
+		return ""
+	}
+	return rdb.TypeName(payload[0])
+}
+
+// CountKeys returns the number of keys in the header, unless it's unknown or
+// the keys are filtered, in which case the archive is read to count them.
+func (s *Source) CountKeys(ctx context.Context, opts migrate.ScanOptions) (int64, error) {
+	if s.header.Keys >= 0 && (opts.Pattern == "" || opts.Pattern == "*") && len(opts.Types) == 0 {
+		return s.header.Keys, nil
+	}
+
+	return s.Count(s.read(ctx, opts))
+}
+
+// record reads the record of a key scanned before, if any.
+func (s *Source) record(key string) (*Record, error) {
+	offset, ok := s.Location(key)
+	if !ok {
+		return nil, nil
+	}
+
+	reader, err := s.reader(offset)
+	if err != nil {
+		return nil, err
+	}
+	record, err := reader.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	return record, nil
+}
+
+// DumpKeys returns the payloads of keys scanned before. The TTL of a key is
+// the time left until the expire time recorded by the export, so that the
+// time since then is taken into account.
+func (s *Source) DumpKeys(_ context.Context, keys []string) ([]migrate.KeyData, error) {
+	var data []migrate.KeyData
+	for _, key := range keys {
+		record, err := s.record(key)
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			continue
+		}
+
+		data = append(data, migrate.KeyData{Key: key, Data: record.Payload, TTL: rdb.RemainingTTL(record.ExpireAt)})
+	}
+	return data, nil
+}
+
+// InspectKeys returns the type and TTL of keys scanned before, and the size
+// of their payload as their memory usage.
+func (s *Source) InspectKeys(_ context.Context, keys []string) ([]migrate.KeyInfo, error) {
+	var infos []migrate.KeyInfo
+	for _, key := range keys {
+		record, err := s.record(key)
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			continue
+		}
+
+		infos = append(infos, migrate.KeyInfo{
+			Key:  key,
+			Type: payloadType(record.Payload),
+			Size: int64(len(record.Payload)),
+			TTL:  rdb.RemainingTTL(record.ExpireAt),
+		})
+	}
+	return infos, nil
+}
+
+// Close closes the archive file.
+func (s *Source) Close() error {
+	return s.f.Close()
+}
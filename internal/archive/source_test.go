@@ -0,0 +1,157 @@
+package archive
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+	"github.com/pucke-dev/go-redismigrate/internal/rdb"
+)
+
+// writeArchive writes records to an archive file and returns its path.
+func writeArchive(t *testing.T, records ...Record) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "keys.archive")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+
+	writer, err := NewWriter(f, testHeader())
+	require.NoError(t, err)
+	for _, record := range records {
+		require.NoError(t, writer.Write(record))
+	}
+	require.NoError(t, writer.Close())
+
+	return path
+}
+
+// scanAll collects the batches of a scan.
+func scanAll(t *testing.T, source *Source, opts migrate.ScanOptions) ([]migrate.KeyBatch, error) {
+	t.Helper()
+
+	batches := make(chan migrate.KeyBatch, 100)
+	err := source.ScanKeys(context.Background(), opts, batches)
+	close(batches)
+
+	var all []migrate.KeyBatch
+	for batch := range batches {
+		all = append(all, batch)
+	}
+	return all, err
+}
+
+func testRecords() []Record {
+	return []Record{
+		{Key: "user:1", Payload: string(rdb.TypeString) + "one"},
+		{Key: "user:2", Payload: string(rdb.TypeHashListpack) + "two", ExpireAt: time.Now().Add(time.Hour)},
+		{Key: "order:1", Payload: string(rdb.TypeString) + "three"},
+		{Key: "user:3", Payload: string(rdb.TypeString) + "four", ExpireAt: time.Now().Add(-time.Minute)},
+	}
+}
+
+func TestSource_ScanAndDump(t *testing.T) {
+	source, err := Open(writeArchive(t, testRecords()...))
+	require.NoError(t, err)
+	defer source.Close()
+
+	assert.Equal(t, "user:*", source.Header().Pattern)
+
+	count, err := source.CountKeys(context.Background(), migrate.ScanOptions{Pattern: "*"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), count, "the count of the header is used")
+
+	count, err = source.CountKeys(context.Background(), migrate.ScanOptions{Pattern: "user:*"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	batches, err := scanAll(t, source, migrate.ScanOptions{Pattern: "user:*", BatchSize: 2})
+	require.NoError(t, err)
+	require.Len(t, batches, 2)
+	assert.Equal(t, []string{"user:1", "user:2"}, batches[0].Keys)
+	assert.Equal(t, batches[0].Next, batches[1].Cursor)
+	assert.Equal(t, []string{"user:3"}, batches[1].Keys)
+	assert.Empty(t, batches[1].Next)
+
+	data, err := source.DumpKeys(context.Background(), []string{"user:1", "user:2", "user:3", "order:1"})
+	require.NoError(t, err)
+	require.Len(t, data, 3, "keys not scanned are missing")
+
+	assert.Equal(t, migrate.KeyData{Key: "user:1", Data: string(rdb.TypeString) + "one"}, data[0])
+	assert.Equal(t, "user:2", data[1].Key)
+	assert.InDelta(t, time.Hour, data[1].TTL, float64(time.Minute), "the TTL is reduced by the time since the export")
+	assert.Equal(t, migrate.ExpiredTTL, data[2].TTL, "keys expired since the export")
+
+	infos, err := source.InspectKeys(context.Background(), []string{"user:2"})
+	require.NoError(t, err)
+	if assert.Len(t, infos, 1) {
+		assert.Equal(t, migrate.KeyInfo{Key: "user:2", Type: "hash", Size: 4, TTL: infos[0].TTL}, infos[0])
+		assert.InDelta(t, time.Hour, infos[0].TTL, float64(time.Minute))
+	}
+
+	exists, err := source.ExistingKeys(context.Background(), []string{"user:1", "order:1"})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, exists)
+}
+
+func TestSource_Types(t *testing.T) {
+	source, err := Open(writeArchive(t, testRecords()...))
+	require.NoError(t, err)
+	defer source.Close()
+
+	opts := migrate.ScanOptions{Pattern: "*", BatchSize: 10, Types: []string{"hash"}}
+
+	count, err := source.CountKeys(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	batches, err := scanAll(t, source, opts)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	assert.Equal(t, []string{"user:2"}, batches[0].Keys)
+}
+
+func TestSource_Resume(t *testing.T) {
+	source, err := Open(writeArchive(t, testRecords()...))
+	require.NoError(t, err)
+	defer source.Close()
+
+	first, err := scanAll(t, source, migrate.ScanOptions{Pattern: "*", BatchSize: 1})
+	require.NoError(t, err)
+	require.Len(t, first, 5)
+
+	resumed, err := scanAll(t, source, migrate.ScanOptions{
+		Pattern:   "*",
+		BatchSize: 1,
+		Resume:    map[string]string{"": first[1].Next},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, first[2:], resumed)
+
+	done, err := scanAll(t, source, migrate.ScanOptions{Pattern: "*", BatchSize: 1, Resume: map[string]string{"": ""}})
+	require.NoError(t, err)
+	assert.Empty(t, done)
+}
+
+func TestSource_Incomplete(t *testing.T) {
+	path := writeArchive(t, testRecords()...)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data[:len(data)-5], 0o644))
+
+	source, err := Open(path)
+	require.NoError(t, err)
+	defer source.Close()
+
+	_, err = scanAll(t, source, migrate.ScanOptions{Pattern: "*", BatchSize: 10})
+	assert.ErrorIs(t, err, ErrTruncated)
+
+	_, err = Open(filepath.Join(t.TempDir(), "missing.archive"))
+	assert.ErrorContains(t, err, "failed to open archive")
+}
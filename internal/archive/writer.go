@@ -0,0 +1,206 @@
+package archive
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+// Writer writes an archive. It implements [migrate.RedisClient] as the
+// destination of an export: keys restored are appended to the archive.
+type Writer struct {
+	migrate.WriteOnly
+
+	w   *bufio.Writer
+	crc hash.Hash32
+
+	// start is the offset of the archive in out, or -1 if the number of
+	// records can't be recorded in out.
+	start int64
+
+	mu      sync.Mutex
+	written map[string]struct{}
+	count   int64
+	err     error
+}
+
+// NewWriter writes the header of an archive to out. [Header.Keys] is ignored,
+// the number of records is recorded when the archive is closed if out is a
+// regular file.
+func NewWriter(out io.Writer, header Header) (*Writer, error) {
+	metadata, err := json.Marshal(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode archive header: %w", err)
+	}
+
+	w := &Writer{
+		WriteOnly: migrate.WriteOnly{Format: "archive", Out: out},
+		w:         bufio.NewWriter(out),
+		crc:       crc32.NewIEEE(),
+		start:     -1,
+		written:   make(map[string]struct{}),
+	}
+
+	if f, ok := out.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			if offset, err := f.Seek(0, io.SeekCurrent); err == nil {
+				w.start = offset
+			}
+		}
+	}
+
+	buf := []byte(magic)
+	buf = binary.BigEndian.AppendUint16(buf, Version)
+	// The number of records is unknown until the archive is closed.
+	buf = binary.BigEndian.AppendUint64(buf, math.MaxUint64)
+	buf = binary.AppendUvarint(buf, uint64(len(metadata)))
+	buf = append(buf, metadata...)
+
+	if _, err := w.w.Write(buf); err != nil {
+		return nil, fmt.Errorf("failed to write archive header: %w", err)
+	}
+	return w, nil
+}
+
+// Write appends a record to the archive.
+func (w *Writer) Write(record Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.write(record)
+}
+
+func (w *Writer) write(record Record) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	var expireAt uint64
+	if !record.ExpireAt.IsZero() {
+		expireAt = uint64(max(record.ExpireAt.UnixMilli(), 1))
+	}
+
+	buf := []byte{recordKey}
+	buf = binary.AppendUvarint(buf, uint64(len(record.Key)))
+	buf = append(buf, record.Key...)
+	buf = binary.AppendUvarint(buf, uint64(len(record.Payload)))
+	buf = append(buf, record.Payload...)
+	buf = binary.AppendUvarint(buf, expireAt)
+
+	w.crc.Write(buf)
+	if _, err := w.w.Write(buf); err != nil {
+		// The archive is unusable after a partial write.
+		w.err = fmt.Errorf("failed to write archive: %w", err)
+		return w.err
+	}
+
+	w.written[record.Key] = struct{}{}
+	w.count++
+	return nil
+}
+
+// RestoreKeys appends the keys to the archive. Keys written before are
+// conflicts, which can't be overwritten in an archive.
+func (w *Writer) RestoreKeys(_ context.Context, data []migrate.KeyData, behavior migrate.ConflictBehavior) ([]migrate.RestoreResult, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	results := make([]migrate.RestoreResult, len(data))
+
+	for i, d := range data {
+		results[i].Key = d.Key
+
+		if _, ok := w.written[d.Key]; ok {
+			switch behavior {
+			case migrate.SkipOnConflict:
+				results[i].Outcome = migrate.OutcomeSkipped
+			case migrate.OverwriteOnConflict:
+				results[i].Outcome = migrate.OutcomeFailed
+				results[i].Err = errors.New("key has already been written to the archive, and can't be overwritten")
+			default:
+				results[i].Outcome = migrate.OutcomeConflict
+			}
+			continue
+		}
+
+		if d.Data == "" {
+			results[i].Outcome = migrate.OutcomeFailed
+			results[i].Err = errors.New("only keys read with DUMP can be archived")
+			continue
+		}
+
+		record := Record{Key: d.Key, Payload: d.Data}
+		if d.TTL > 0 {
+			record.ExpireAt = now.Add(d.TTL)
+		}
+
+		if err := w.write(record); err != nil {
+			return nil, err
+		}
+		results[i].Outcome = migrate.OutcomeCreated
+	}
+
+	return results, nil
+}
+
+// Count returns the number of records written so far.
+func (w *Writer) Count() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.count
+}
+
+// Close writes the end of the archive and closes its output, recording the
+// number of records if the output is a regular file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer func() { w.err = w.ErrClosed() }()
+
+	if w.err != nil {
+		return w.CloseOutput(w.err)
+	}
+
+	end := binary.BigEndian.AppendUint32([]byte{recordEnd}, w.crc.Sum32())
+	if _, err := w.w.Write(end); err != nil {
+		return w.CloseOutput(fmt.Errorf("failed to write archive: %w", err))
+	}
+	if err := w.w.Flush(); err != nil {
+		return w.CloseOutput(fmt.Errorf("failed to write archive: %w", err))
+	}
+
+	// The archive stays valid without the number of records, e.g. if the
+	// file has been opened for appending.
+	if w.start >= 0 {
+		count := binary.BigEndian.AppendUint64(nil, uint64(w.count))
+		_, _ = w.Out.(*os.File).WriteAt(count, w.start+int64(countOffset))
+	}
+
+	return w.CloseOutput(nil)
+}
+
+// Abort flushes the records written so far without ending the archive, which
+// marks it as incomplete, and closes its output.
+func (w *Writer) Abort() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer func() { w.err = w.ErrClosed() }()
+
+	var err error
+	if w.err == nil {
+		err = w.w.Flush()
+	}
+	return w.CloseOutput(err)
+}
@@ -0,0 +1,152 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrAccessUnavailable is wrapped by the errors of an [AccessReader] if the
+// server doesn't track what an [AccessFilter] selects keys by, e.g. idle times
+// under an LFU eviction policy, or doesn't allow reading it. It aborts the
+// migration.
+var ErrAccessUnavailable = errors.New("key access statistics unavailable")
+
+// AccessReader is implemented by clients that can report how keys have been
+// accessed without touching them. It's required by [AccessFilter].
+type AccessReader interface {
+	// IdleTimes returns the time since keys have last been accessed, as
+	// tracked under LRU eviction policies. Keys that don't exist are omitted.
+	IdleTimes(ctx context.Context, keys []string) (map[string]time.Duration, error)
+
+	// AccessFrequencies returns the logarithmic access counters of keys, as
+	// tracked under LFU eviction policies. Keys that don't exist are omitted.
+	AccessFrequencies(ctx context.Context, keys []string) (map[string]int64, error)
+}
+
+// AccessFilter selects keys by the time since they have last been accessed,
+// or by their access frequency. Servers track only one of them, depending on
+// their eviction policy, so they can't be combined. Zero bounds are not
+// applied.
+type AccessFilter struct {
+	// IdleMin is the minimum time since a key has last been accessed.
+	IdleMin time.Duration
+
+	// IdleMax is the maximum time since a key has last been accessed.
+	IdleMax time.Duration
+
+	// FreqMin is the minimum access counter of a key.
+	FreqMin int64
+
+	// FreqMax is the maximum access counter of a key.
+	FreqMax int64
+}
+
+func (f AccessFilter) enabled() bool {
+	return f.byIdleTime() || f.byFrequency()
+}
+
+func (f AccessFilter) byIdleTime() bool {
+	return f.IdleMin > 0 || f.IdleMax > 0
+}
+
+func (f AccessFilter) byFrequency() bool {
+	return f.FreqMin > 0 || f.FreqMax > 0
+}
+
+// validate checks that the filter is consistent.
+func (f AccessFilter) validate() error {
+	var errs []error
+
+	if f.IdleMin < 0 || f.IdleMax < 0 {
+		errs = append(errs, errors.New("invalid idle time filter: bounds must not be negative"))
+	}
+	if f.IdleMax > 0 && f.IdleMin > f.IdleMax {
+		errs = append(errs, fmt.Errorf("invalid idle time filter: minimum %s exceeds maximum %s", f.IdleMin, f.IdleMax))
+	}
+	if f.FreqMin < 0 || f.FreqMax < 0 {
+		errs = append(errs, errors.New("invalid access frequency filter: bounds must not be negative"))
+	}
+	if f.FreqMax > 0 && f.FreqMin > f.FreqMax {
+		errs = append(errs, fmt.Errorf("invalid access frequency filter: minimum %d exceeds maximum %d", f.FreqMin, f.FreqMax))
+	}
+	if f.byIdleTime() && f.byFrequency() {
+		errs = append(errs, errors.New("idle time and access frequency filters can't be combined, as servers track only one of them"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// String describes the filter. It is empty if no bounds are set.
+func (f AccessFilter) String() string {
+	var parts []string
+	if f.IdleMin > 0 {
+		parts = append(parts, "idle min "+f.IdleMin.String())
+	}
+	if f.IdleMax > 0 {
+		parts = append(parts, "idle max "+f.IdleMax.String())
+	}
+	if f.FreqMin > 0 {
+		parts = append(parts, "freq min "+strconv.FormatInt(f.FreqMin, 10))
+	}
+	if f.FreqMax > 0 {
+		parts = append(parts, "freq max "+strconv.FormatInt(f.FreqMax, 10))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// within reports whether value lies within the bounds lower and upper, which
+// are not applied if zero.
+func within[T time.Duration | int64](value, lower, upper T) bool {
+	return (lower == 0 || value >= lower) && (upper == 0 || value <= upper)
+}
+
+// filterAccess returns the keys matching [Config.Access], which is read before
+// the keys are dumped, as dumping counts as an access. Keys deleted since the
+// scan are removed as well.
+func (m *Migrator) filterAccess(ctx context.Context, keys []string) ([]string, error) {
+	filter := m.config.Access
+	if !filter.enabled() || len(keys) == 0 {
+		return keys, nil
+	}
+
+	// A dry run reads access statistics like a migration, as doing so
+	// doesn't write anything.
+	source := m.source
+	if dryRun, ok := source.(*dryRunSource); ok {
+		source = dryRun.RedisClient
+	}
+
+	reader, ok := source.(AccessReader)
+	if !ok {
+		return nil, fmt.Errorf("%w: the source doesn't report key access", ErrAccessUnavailable)
+	}
+
+	var matched []string
+	if filter.byIdleTime() {
+		idleTimes, err := reader.IdleTimes(ctx, keys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read idle times: %w", err)
+		}
+		for _, key := range keys {
+			if idle, ok := idleTimes[key]; ok && within(idle, filter.IdleMin, filter.IdleMax) {
+				matched = append(matched, key)
+			}
+		}
+	} else {
+		frequencies, err := reader.AccessFrequencies(ctx, keys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read access frequencies: %w", err)
+		}
+		for _, key := range keys {
+			if freq, ok := frequencies[key]; ok && within(freq, filter.FreqMin, filter.FreqMax) {
+				matched = append(matched, key)
+			}
+		}
+	}
+
+	return matched, nil
+}
@@ -0,0 +1,125 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/stats"
+)
+
+// accessClient reports fixed idle times and frequencies. Under an LFU policy
+// it doesn't track idle times, and otherwise no frequencies, like Redis.
+type accessClient struct {
+	*memoryClient
+	idle map[string]time.Duration
+	freq map[string]int64
+	lfu  bool
+}
+
+func (c *accessClient) IdleTimes(_ context.Context, keys []string) (map[string]time.Duration, error) {
+	if c.lfu {
+		return nil, fmt.Errorf("%w: LFU", ErrAccessUnavailable)
+	}
+
+	idle := make(map[string]time.Duration)
+	for _, key := range keys {
+		if _, ok := c.keys[key]; ok {
+			idle[key] = c.idle[key]
+		}
+	}
+	return idle, nil
+}
+
+func (c *accessClient) AccessFrequencies(_ context.Context, keys []string) (map[string]int64, error) {
+	if !c.lfu {
+		return nil, fmt.Errorf("%w: not LFU", ErrAccessUnavailable)
+	}
+
+	freq := make(map[string]int64)
+	for _, key := range keys {
+		if _, ok := c.keys[key]; ok {
+			freq[key] = c.freq[key]
+		}
+	}
+	return freq, nil
+}
+
+func TestMigrate_IdleFilter(t *testing.T) {
+	source := &accessClient{
+		memoryClient: newMemoryClient("fresh", "stale:1", "stale:2", "ancient"),
+		idle: map[string]time.Duration{
+			"fresh":   time.Minute,
+			"stale:1": 40 * 24 * time.Hour,
+			"stale:2": 31 * 24 * time.Hour,
+			"ancient": 400 * 24 * time.Hour,
+		},
+	}
+	dest := newMemoryClient()
+	metrics := stats.NewMetrics()
+
+	config := testConfig(MoveMode, ErrorOnConflict)
+	config.Access = AccessFilter{IdleMin: 30 * 24 * time.Hour, IdleMax: 365 * 24 * time.Hour}
+
+	err := NewMigrator(source, dest, config, metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stale:1", "stale:2"}, dest.sortedKeys("*"))
+	assert.Equal(t, []string{"ancient", "fresh"}, source.sortedKeys("*"))
+	assert.Equal(t, int64(2), metrics.GetTotalKeys())
+	assert.Equal(t, 1.0, metrics.GetProgress())
+}
+
+func TestMigrate_FrequencyFilter(t *testing.T) {
+	source := &accessClient{
+		memoryClient: newMemoryClient("hot", "cold"),
+		freq:         map[string]int64{"hot": 200, "cold": 1},
+		lfu:          true,
+	}
+	dest := newMemoryClient()
+
+	config := testConfig(CopyMode, ErrorOnConflict)
+	config.Access = AccessFilter{FreqMax: 5}
+
+	err := NewMigrator(source, dest, config, stats.NewMetrics()).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cold"}, dest.sortedKeys("*"))
+}
+
+func TestMigrate_AccessUnavailable(t *testing.T) {
+	tests := []struct {
+		name   string
+		source RedisClient
+	}{
+		{"untracked", &accessClient{memoryClient: newMemoryClient(numberedKeys("key", 30)...), lfu: true}},
+		{"unsupported", newMemoryClient(numberedKeys("key", 30)...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := newMemoryClient()
+
+			config := testConfig(CopyMode, ErrorOnConflict)
+			config.Access = AccessFilter{IdleMin: time.Hour}
+
+			err := NewMigrator(tt.source, dest, config, stats.NewMetrics()).Migrate(context.Background())
+
+			assert.ErrorIs(t, err, ErrAccessUnavailable)
+			assert.Empty(t, dest.sortedKeys("*"))
+		})
+	}
+}
+
+func TestAccessFilter_Validate(t *testing.T) {
+	assert.NoError(t, AccessFilter{IdleMin: time.Hour, IdleMax: 24 * time.Hour}.validate())
+	assert.NoError(t, AccessFilter{FreqMin: 1, FreqMax: 10}.validate())
+
+	assert.ErrorContains(t, AccessFilter{IdleMin: 24 * time.Hour, IdleMax: time.Hour}.validate(), "exceeds maximum")
+	assert.ErrorContains(t, AccessFilter{FreqMin: -1}.validate(), "must not be negative")
+	assert.ErrorContains(t, AccessFilter{IdleMin: time.Hour, FreqMax: 5}.validate(), "can't be combined")
+}
@@ -0,0 +1,216 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// bigKeyChunkSize is the number of elements read and written at once when
+// copying a big key in chunks.
+const bigKeyChunkSize = 1000
+
+// bigKeyRestarts is the number of times the copy of a big key is restarted
+// after a chunk may have been written partially.
+const bigKeyRestarts = 3
+
+// ErrPartialChunk is wrapped by the error of [ChunkWriter.WriteChunk] when the
+// chunk may have been written partially, e.g. because the connection was lost
+// during a failover. Writing it again could duplicate elements, so the copy of
+// the key is restarted from a discarded temporary key instead.
+var ErrPartialChunk = errors.New("chunk may have been written partially")
+
+// ChunkReader is implemented by clients that can read values incrementally.
+// Together with [ChunkWriter] and [Inspector] it's used to copy keys whose
+// MEMORY USAGE exceeds [Config.BigKeyThreshold] without a DUMP payload of the
+// whole value, which would block source and destination while it is
+// serialized and could exceed the maximum bulk length of the destination.
+type ChunkReader interface {
+	// ReadChunks reads the value of key, whose type is typ, in chunks of
+	// about count elements and passes them to fn in order, then returns the
+	// TTL of the key like [KeyData.TTL]. Empty chunks are not passed to fn,
+	// so fn is not called at all for a key that doesn't exist. Chunks may
+	// overlap for types read with SCAN, so writing them must be idempotent.
+	ReadChunks(ctx context.Context, key, typ string, count int, fn func(chunk *Value) error) (time.Duration, error)
+}
+
+// ChunkWriter is implemented by clients that can write values incrementally.
+// Chunks are written to a temporary key, which is moved into place once the
+// whole value has been written, so that a key never exists partially written.
+type ChunkWriter interface {
+	// WriteChunk appends chunk to the temporary key of key. If the chunk may
+	// have been written partially, the error wraps [ErrPartialChunk].
+	WriteChunk(ctx context.Context, key string, chunk *Value) error
+
+	// CommitChunks moves the temporary key of key into place with conflict
+	// handling, and sets its TTL.
+	CommitChunks(ctx context.Context, key string, ttl time.Duration, behavior ConflictBehavior) (RestoreResult, error)
+
+	// DiscardChunks deletes the temporary key of key.
+	DiscardChunks(ctx context.Context, key string) error
+}
+
+// splitBigKeys separates the keys of a batch exceeding the big key threshold
+// from the others, if the source and all destinations support copying in
+// chunks.
+func (m *Migrator) splitBigKeys(ctx context.Context, keys []string) (small []string, big []KeyInfo, err error) {
+	inspector, ok := m.source.(Inspector)
+	if m.config.BigKeyThreshold == 0 || !ok {
+		return keys, nil, nil
+	}
+	if _, ok := m.source.(ChunkReader); !ok {
+		return keys, nil, nil
+	}
+	for _, dest := range m.dests {
+		if _, ok := dest.Client.(ChunkWriter); !ok {
+			return keys, nil, nil
+		}
+	}
+
+	infos, err := inspector.InspectKeys(ctx, keys)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to inspect keys: %w", err)
+	}
+
+	sizes := make(map[string]KeyInfo, len(infos))
+	for _, info := range infos {
+		sizes[info.Key] = info
+	}
+
+	for _, key := range keys {
+		info, ok := sizes[key]
+		if ok && info.Size > m.config.BigKeyThreshold && DataTypes[info.Type] {
+			big = append(big, info)
+		} else {
+			small = append(small, key)
+		}
+	}
+
+	return small, big, nil
+}
+
+// bigKey is a big key along with its name in the destinations.
+type bigKey struct {
+	KeyInfo
+	name string
+}
+
+// rewriteBigKeys returns big keys with their rewritten names, recorded in
+// renamed, along with the keys that failed to be rewritten. Names are
+// resolved once per batch, before the keys are copied to any destination.
+func (m *Migrator) rewriteBigKeys(keys []KeyInfo, renamed renames) ([]bigKey, []RestoreResult) {
+	sourceNames := make([]string, len(keys))
+	for i, info := range keys {
+		sourceNames[i] = info.Key
+	}
+
+	names, failed := m.rewriteKeys(sourceNames, renamed)
+
+	var big []bigKey
+	for i, info := range keys {
+		if names[i] != "" {
+			big = append(big, bigKey{KeyInfo: info, name: names[i]})
+		}
+	}
+	return big, failed
+}
+
+// copyBigKeys copies big keys one by one in chunks to dest. Keys deleted from
+// the source since they were inspected are reported as [OutcomeExpired], like
+// small keys missing from their dump.
+func (m *Migrator) copyBigKeys(ctx context.Context, dest Destination, keys []bigKey) []RestoreResult {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.name
+	}
+
+	// Keys that would be skipped or conflict are not worth streaming. The
+	// rename at the end catches keys created in the meantime, so if checking
+	// fails, all keys are streamed instead.
+	var exists []bool
+	if m.config.Conflict != OverwriteOnConflict {
+		exists, _ = existingKeys(ctx, dest.Client, names)
+	}
+
+	var results []RestoreResult
+	for i, key := range keys {
+		switch {
+		case exists != nil && exists[i] && m.config.Conflict == SkipOnConflict:
+			results = append(results, RestoreResult{Key: key.name, Outcome: OutcomeSkipped})
+		case exists != nil && exists[i]:
+			results = append(results, RestoreResult{Key: key.name, Outcome: OutcomeConflict})
+		default:
+			results = append(results, m.copyBigKey(ctx, dest, key.KeyInfo, key.name))
+		}
+
+		dest.Metrics.AddBigKeys(1)
+	}
+
+	return results
+}
+
+// copyBigKey copies a single key in chunks to the key name of dest.
+func (m *Migrator) copyBigKey(ctx context.Context, dest Destination, info KeyInfo, name string) RestoreResult {
+	reader := m.source.(ChunkReader)
+	writer := dest.Client.(ChunkWriter)
+
+	fail := func(err error) RestoreResult {
+		// The temporary key is removed on a best-effort basis, a leftover one
+		// is replaced by the next attempt.
+		_ = writer.DiscardChunks(context.WithoutCancel(ctx), name)
+		return RestoreResult{
+			Key:     name,
+			Outcome: OutcomeFailed,
+			Err:     fmt.Errorf("failed to copy big key: %w", err),
+		}
+	}
+
+	// A chunk written partially restarts the copy from the first chunk.
+	var chunks int
+	var ttl time.Duration
+	for restarts := 0; ; restarts++ {
+		// A temporary key left behind by an earlier attempt would be merged
+		// with the value.
+		if err := writer.DiscardChunks(ctx, name); err != nil {
+			return fail(err)
+		}
+
+		var err error
+		chunks = 0
+		ttl, err = reader.ReadChunks(ctx, info.Key, info.Type, bigKeyChunkSize, func(chunk *Value) error {
+			chunks++
+			return writer.WriteChunk(ctx, name, chunk)
+		})
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrPartialChunk) || restarts == bigKeyRestarts || ctx.Err() != nil {
+			return fail(err)
+		}
+	}
+
+	// The key has been deleted since it was inspected.
+	if chunks == 0 {
+		return RestoreResult{Key: name, Outcome: OutcomeExpired}
+	}
+
+	// The TTL is read after the last chunk, so there is no time to account
+	// for. Keys dropped by the TTL policy are only detected at this point.
+	data, unrestored := m.applyTTLs([]KeyData{{Key: name, TTL: ttl}}, 0)
+	if len(unrestored) > 0 {
+		_ = writer.DiscardChunks(context.WithoutCancel(ctx), name)
+		return unrestored[0]
+	}
+
+	result, err := writer.CommitChunks(ctx, name, data[0].TTL, m.config.Conflict)
+	if err != nil {
+		return fail(err)
+	}
+
+	return result
+}
@@ -0,0 +1,217 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/stats"
+)
+
+// chunkClient wraps a memoryClient with chunked reads and writes of string
+// values, splitting them into chunks of count bytes.
+type chunkClient struct {
+	*memoryClient
+	temp    map[string]string
+	dumped  []string
+	written int
+}
+
+func newChunkClient(keys ...string) *chunkClient {
+	return &chunkClient{memoryClient: newMemoryClient(keys...), temp: make(map[string]string)}
+}
+
+func (c *chunkClient) DumpKeys(ctx context.Context, keys []string) ([]KeyData, error) {
+	c.mu.Lock()
+	c.dumped = append(c.dumped, keys...)
+	c.mu.Unlock()
+	return c.memoryClient.DumpKeys(ctx, keys)
+}
+
+func (c *chunkClient) ReadChunks(_ context.Context, key, _ string, count int, fn func(chunk *Value) error) (time.Duration, error) {
+	c.mu.Lock()
+	data, ok := c.keys[key]
+	c.mu.Unlock()
+
+	for i := 0; ok && i < len(data.Data); i += count {
+		if err := fn(&Value{Type: "string", String: data.Data[i:min(i+count, len(data.Data))]}); err != nil {
+			return 0, err
+		}
+	}
+	return data.TTL, nil
+}
+
+func (c *chunkClient) WriteChunk(_ context.Context, key string, chunk *Value) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.temp[key] += chunk.String
+	c.written++
+	return nil
+}
+
+func (c *chunkClient) CommitChunks(ctx context.Context, key string, ttl time.Duration, behavior ConflictBehavior) (RestoreResult, error) {
+	c.mu.Lock()
+	data := KeyData{Key: key, Data: c.temp[key], TTL: ttl}
+	delete(c.temp, key)
+	c.mu.Unlock()
+
+	results, err := c.memoryClient.RestoreKeys(ctx, []KeyData{data}, behavior)
+	if err != nil {
+		return RestoreResult{}, err
+	}
+	return results[0], nil
+}
+
+func (c *chunkClient) DiscardChunks(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.temp, key)
+	return nil
+}
+
+func TestMigrate_BigKeys(t *testing.T) {
+	source := newChunkClient(numberedKeys("key", 20)...)
+	big := strings.Repeat("x", 2500)
+	source.keys["big:1"] = KeyData{Key: "big:1", Data: big, TTL: time.Hour}
+	source.keys["big:2"] = KeyData{Key: "big:2", Data: big}
+
+	dest := newChunkClient("big:2")
+	dest.temp["big:1"] = "left over by an earlier attempt"
+	metrics := stats.NewMetrics()
+
+	config := testConfig(MoveMode, SkipOnConflict)
+	config.BigKeyThreshold = 1000
+
+	err := NewMigrator(source, dest, config, metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, KeyData{Key: "big:1", Data: big, TTL: time.Hour}, dest.keys["big:1"])
+	assert.Equal(t, 3, dest.written)
+	assert.Empty(t, dest.temp)
+	assert.NotContains(t, source.dumped, "big:1")
+	assert.NotContains(t, source.dumped, "big:2")
+
+	assert.Equal(t, int64(2), metrics.GetBigKeys())
+	assert.Equal(t, int64(21), metrics.GetSuccessfulKeys())
+	assert.Equal(t, int64(1), metrics.GetSkippedKeys())
+
+	// The skipped big key has not been streamed, and stays in the source.
+	assert.Equal(t, []string{"big:2"}, source.sortedKeys("*"))
+}
+
+// vanishingChunkClient wraps a chunkClient and deletes keys right after they
+// have been inspected, before their chunks are read.
+type vanishingChunkClient struct {
+	*chunkClient
+	vanish []string
+}
+
+func (c *vanishingChunkClient) InspectKeys(ctx context.Context, keys []string) ([]KeyInfo, error) {
+	infos, err := c.chunkClient.InspectKeys(ctx, keys)
+	_ = c.DeleteKeys(ctx, c.vanish)
+	return infos, err
+}
+
+func TestMigrate_BigKeyDeletedBeforeRead(t *testing.T) {
+	source := &vanishingChunkClient{chunkClient: newChunkClient("key:1"), vanish: []string{"big:1"}}
+	source.keys["big:1"] = KeyData{Key: "big:1", Data: strings.Repeat("x", 2500)}
+	dest := newChunkClient()
+	metrics := stats.NewMetrics()
+
+	config := testConfig(CopyMode, ErrorOnConflict)
+	config.BigKeyThreshold = 1000
+
+	err := NewMigrator(source, dest, config, metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"key:1"}, dest.sortedKeys("*"))
+	assert.Equal(t, int64(1), metrics.GetExpiredKeys())
+	assert.Equal(t, int64(1), metrics.GetSuccessfulKeys())
+	assert.Equal(t, 1.0, metrics.GetProgress())
+}
+
+func TestMigrate_BigKeysDisabled(t *testing.T) {
+	source := newChunkClient()
+	source.keys["big"] = KeyData{Key: "big", Data: strings.Repeat("x", 2500)}
+	dest := newChunkClient()
+	metrics := stats.NewMetrics()
+
+	err := NewMigrator(source, dest, testConfig(CopyMode, ErrorOnConflict), metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"big"}, source.dumped)
+	assert.Zero(t, dest.written)
+	assert.Zero(t, metrics.GetBigKeys())
+}
+
+// partialChunkClient is a chunkClient whose writes of the chunks listed in
+// failures are applied, but fail as if the connection was lost before the
+// reply.
+type partialChunkClient struct {
+	*chunkClient
+	failures map[int]bool
+	writes   int
+}
+
+func (c *partialChunkClient) WriteChunk(ctx context.Context, key string, chunk *Value) error {
+	if err := c.chunkClient.WriteChunk(ctx, key, chunk); err != nil {
+		return err
+	}
+
+	c.writes++
+	if c.failures[c.writes] {
+		return fmt.Errorf("%w: EOF", ErrPartialChunk)
+	}
+	return nil
+}
+
+func TestMigrate_BigKeyPartialChunk(t *testing.T) {
+	big := strings.Repeat("a", 1000) + strings.Repeat("b", 1000) + strings.Repeat("c", 500)
+	source := newChunkClient()
+	source.keys["big"] = KeyData{Key: "big", Data: big}
+
+	// The second chunk fails on the first attempt, the copy is restarted.
+	dest := &partialChunkClient{chunkClient: newChunkClient(), failures: map[int]bool{2: true}}
+	metrics := stats.NewMetrics()
+
+	config := testConfig(CopyMode, ErrorOnConflict)
+	config.BigKeyThreshold = 1000
+
+	err := NewMigrator(source, dest, config, metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, big, dest.keys["big"].Data, "chunks must not be duplicated")
+	assert.Equal(t, 5, dest.written)
+	assert.Empty(t, dest.temp)
+	assert.Equal(t, int64(1), metrics.GetSuccessfulKeys())
+}
+
+func TestMigrate_BigKeyPartialChunkGivesUp(t *testing.T) {
+	source := newChunkClient()
+	source.keys["big"] = KeyData{Key: "big", Data: strings.Repeat("x", 2500)}
+
+	failures := make(map[int]bool)
+	for i := 1; i <= bigKeyRestarts+1; i++ {
+		failures[i] = true
+	}
+	dest := &partialChunkClient{chunkClient: newChunkClient(), failures: failures}
+	metrics := stats.NewMetrics()
+
+	config := testConfig(CopyMode, ErrorOnConflict)
+	config.BigKeyThreshold = 1000
+
+	err := NewMigrator(source, dest, config, metrics).Migrate(context.Background())
+
+	assert.ErrorIs(t, err, ErrPartialChunk)
+	assert.Equal(t, bigKeyRestarts+1, dest.writes)
+	assert.Empty(t, dest.keys)
+	assert.Empty(t, dest.temp)
+	assert.Equal(t, int64(1), metrics.GetFailedKeys())
+}
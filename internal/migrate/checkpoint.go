@@ -0,0 +1,411 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pucke-dev/go-redismigrate/internal/stats"
+)
+
+const (
+	// checkpointVersion is the version of the checkpoint file format.
+	checkpointVersion = 1
+
+	// checkpointInterval is how often the checkpoint file is written while a
+	// migration is running.
+	checkpointInterval = time.Second
+)
+
+// Checkpoint is the persisted progress of a migration, written to
+// [Config.CheckpointFile] while the migration runs and used to resume it with
+// [Migrator.Resume].
+type Checkpoint struct {
+	Version int `json:"version"`
+
+	// Source and Dest are the connection strings of the run with passwords
+	// redacted. They are informational only, the comparison on resume uses
+	// [Checkpoint.Fingerprint].
+	Source string `json:"source"`
+	Dest   string `json:"dest"`
+
+	Pattern  string   `json:"pattern"`
+	Filter   string   `json:"filter,omitempty"`
+	Rewrites []string `json:"rewrites,omitempty"`
+	Mode     string   `json:"mode"`
+
+	Fingerprint Fingerprint `json:"fingerprint"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Complete is set once every shard has been scanned and every batch has
+	// been processed.
+	Complete bool `json:"complete"`
+
+	// Shards maps every shard of the source to its scan progress. See
+	// [KeyBatch.Shard] for details.
+	Shards map[string]ShardProgress `json:"shards"`
+
+	// Metrics are the counters of all batches recorded as completed.
+	Metrics stats.Snapshot `json:"metrics"`
+}
+
+// Fingerprint identifies the configuration a checkpoint was recorded with.
+// Every field is a SHA-256 hash, so that credentials in connection strings
+// are not stored in plain text.
+type Fingerprint struct {
+	Source  string `json:"source"`
+	Dest    string `json:"dest"`
+	Pattern string `json:"pattern"`
+	Mode    string `json:"mode"`
+
+	// Filter and Rewrites are empty if no filters or rewrites are configured,
+	// so that checkpoints written before they existed still match.
+	Filter   string `json:"filter,omitempty"`
+	Rewrites string `json:"rewrites,omitempty"`
+}
+
+// ShardProgress is the scan progress of a single shard.
+type ShardProgress struct {
+	// Cursor is the SCAN cursor to continue from. Every batch read before it
+	// has been completed.
+	Cursor string `json:"cursor"`
+
+	// Done is set once the shard has been scanned and processed completely.
+	Done bool `json:"done,omitempty"`
+
+	// CompletedKeys lists the keys of batches read after Cursor that have
+	// already been completed. The scan continues from Cursor and skips them
+	// in whichever batch they are returned again, as the batches following a
+	// cursor differ once the hash table has been resized.
+	CompletedKeys []string `json:"completed_keys,omitempty"`
+}
+
+func newFingerprint(config Config) Fingerprint {
+	fingerprint := Fingerprint{
+		Source:  hash(config.SourceURL),
+		Dest:    hash(config.DestURL),
+		Pattern: hash(config.Pattern),
+		Mode:    hash(config.Mode.String()),
+	}
+
+	if filters := describeFilters(config); filters != "" {
+		fingerprint.Filter = hash(filters)
+	}
+
+	if len(config.Rewrites) > 0 {
+		fingerprint.Rewrites = hash(strings.Join(rewriteSpecs(config.Rewrites), "\n"))
+	}
+
+	return fingerprint
+}
+
+func rewriteSpecs(rewrites []KeyRewrite) []string {
+	var specs []string
+	for _, rewrite := range rewrites {
+		specs = append(specs, rewrite.String())
+	}
+	return specs
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// redact hides the password of a connection string.
+func redact(connStr string) string {
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
+
+// LoadCheckpoint reads a checkpoint file written by a previous migration.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+
+	if checkpoint.Version != checkpointVersion {
+		return nil, fmt.Errorf("unsupported checkpoint version %d (want %d)", checkpoint.Version, checkpointVersion)
+	}
+
+	return &checkpoint, nil
+}
+
+// Matches checks that the checkpoint has been recorded for the same source,
+// destination, pattern, filters, key rewrites and mode as config.
+func (c *Checkpoint) Matches(config Config) error {
+	want := newFingerprint(config)
+
+	var errs []error
+	if c.Fingerprint.Source != want.Source {
+		errs = append(errs, fmt.Errorf("source differs from the recorded %s", c.Source))
+	}
+	if c.Fingerprint.Dest != want.Dest {
+		errs = append(errs, fmt.Errorf("destination differs from the recorded %s", c.Dest))
+	}
+	if c.Fingerprint.Pattern != want.Pattern {
+		errs = append(errs, fmt.Errorf("pattern differs from the recorded %q", c.Pattern))
+	}
+	if c.Fingerprint.Mode != want.Mode {
+		errs = append(errs, fmt.Errorf("mode differs from the recorded %s", c.Mode))
+	}
+	if c.Fingerprint.Filter != want.Filter {
+		errs = append(errs, fmt.Errorf("filters differ from the recorded %q", c.Filter))
+	}
+	if c.Fingerprint.Rewrites != want.Rewrites {
+		errs = append(errs, fmt.Errorf("key rewrites differ from the recorded %q", c.Rewrites))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("checkpoint does not match the migration: %w", err)
+	}
+
+	return nil
+}
+
+// resumeCursors returns the [ScanOptions.Resume] map continuing the scan of
+// the checkpoint.
+func (c *Checkpoint) resumeCursors() map[string]string {
+	resume := make(map[string]string, len(c.Shards))
+	for shard, progress := range c.Shards {
+		if progress.Done {
+			resume[shard] = ""
+		} else {
+			resume[shard] = progress.Cursor
+		}
+	}
+	return resume
+}
+
+// save writes the checkpoint atomically, so that an interrupted write never
+// leaves a truncated file behind.
+func (c *Checkpoint) save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// scheduledBatch is a batch handed to the workers, tracked by the checkpointer
+// until it has been completed.
+type scheduledBatch struct {
+	KeyBatch
+	completed bool
+}
+
+// shardState tracks the batches of a shard that are in flight.
+type shardState struct {
+	// pending holds the batches in scan order, starting with the oldest one
+	// that has not been completed yet.
+	pending []*scheduledBatch
+
+	// cursor is the cursor to continue from once pending is empty.
+	cursor string
+
+	// done is set once the last batch of the shard has been completed.
+	done bool
+
+	// skip holds the keys of batches completed ahead of the cursor by a
+	// previous run.
+	skip map[string]bool
+}
+
+// checkpointer tracks which batches have been completed, and derives the
+// scan position a migration can safely be resumed from.
+type checkpointer struct {
+	mu     sync.Mutex
+	shards map[string]*shardState
+
+	// excluded counts the outcomes of batches that were processed but not
+	// completed, which are processed again on resume.
+	excluded stats.Snapshot
+}
+
+func newCheckpointer(resume *Checkpoint) *checkpointer {
+	c := &checkpointer{shards: make(map[string]*shardState)}
+
+	if resume == nil {
+		return c
+	}
+
+	for shard, progress := range resume.Shards {
+		state := &shardState{cursor: progress.Cursor, done: progress.Done, skip: make(map[string]bool)}
+		for _, key := range progress.CompletedKeys {
+			state.skip[key] = true
+		}
+		c.shards[shard] = state
+	}
+
+	return c
+}
+
+func (c *checkpointer) shard(name string) *shardState {
+	state, ok := c.shards[name]
+	if !ok {
+		state = &shardState{cursor: "0", skip: make(map[string]bool)}
+		c.shards[name] = state
+	}
+	return state
+}
+
+// schedule registers a batch in scan order, without the keys completed by a
+// previous run. It returns nil if no key of the batch has to be processed.
+func (c *checkpointer) schedule(batch KeyBatch) *scheduledBatch {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.shard(batch.Shard)
+	if len(state.skip) > 0 {
+		batch.Keys = slices.DeleteFunc(slices.Clone(batch.Keys), func(key string) bool {
+			return state.skip[key]
+		})
+	}
+
+	scheduled := &scheduledBatch{KeyBatch: batch}
+	state.pending = append(state.pending, scheduled)
+
+	if len(batch.Keys) > 0 {
+		return scheduled
+	}
+
+	c.completeLocked(scheduled)
+
+	return nil
+}
+
+// complete records a batch as completed.
+func (c *checkpointer) complete(batch *scheduledBatch) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.completeLocked(batch)
+}
+
+func (c *checkpointer) completeLocked(batch *scheduledBatch) {
+	batch.completed = true
+
+	state := c.shard(batch.Shard)
+	for len(state.pending) > 0 && state.pending[0].completed {
+		state.cursor = state.pending[0].Next
+		state.done = state.cursor == ""
+		state.pending = state.pending[1:]
+	}
+}
+
+// exclude records the outcomes of a batch that has been processed without
+// being completed.
+func (c *checkpointer) exclude(counts outcomeCounts) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.excluded.Processed += counts.total()
+	c.excluded.Success += counts[OutcomeCreated]
+	c.excluded.Overwritten += counts[OutcomeOverwritten]
+	c.excluded.Skipped += counts[OutcomeSkipped]
+	c.excluded.Conflicted += counts[OutcomeConflict]
+	c.excluded.Failed += counts[OutcomeFailed]
+	c.excluded.Expired += counts[OutcomeExpired]
+	c.excluded.Dropped += counts[OutcomeDropped]
+}
+
+// checkpoint returns the progress of the migration. Batches must not be
+// processed while it is called, so that metrics and progress are consistent.
+func (c *checkpointer) checkpoint(config Config, metrics stats.Snapshot) *Checkpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	metrics.Processed -= c.excluded.Processed
+	metrics.Success -= c.excluded.Success
+	metrics.Overwritten -= c.excluded.Overwritten
+	metrics.Skipped -= c.excluded.Skipped
+	metrics.Conflicted -= c.excluded.Conflicted
+	metrics.Failed -= c.excluded.Failed
+	metrics.Expired -= c.excluded.Expired
+	metrics.Dropped -= c.excluded.Dropped
+
+	checkpoint := &Checkpoint{
+		Version:     checkpointVersion,
+		Source:      redact(config.SourceURL),
+		Dest:        redact(config.DestURL),
+		Pattern:     config.Pattern,
+		Filter:      describeFilters(config),
+		Rewrites:    rewriteSpecs(config.Rewrites),
+		Mode:        config.Mode.String(),
+		Fingerprint: newFingerprint(config),
+		UpdatedAt:   time.Now(),
+		Complete:    len(c.shards) > 0,
+		Shards:      make(map[string]ShardProgress, len(c.shards)),
+		Metrics:     metrics,
+	}
+
+	for name, state := range c.shards {
+		progress := ShardProgress{Cursor: state.cursor, Done: state.done}
+
+		if len(state.pending) > 0 {
+			progress.Cursor = state.pending[0].Cursor
+			progress.Done = false
+
+			for _, batch := range state.pending[1:] {
+				if batch.completed {
+					progress.CompletedKeys = append(progress.CompletedKeys, batch.Keys...)
+				}
+			}
+		}
+
+		// Keys completed by a previous run stay completed until the shard is
+		// done, as the batch they are scanned again in may not be completed
+		// yet.
+		if !progress.Done {
+			for key := range state.skip {
+				progress.CompletedKeys = append(progress.CompletedKeys, key)
+			}
+		}
+		slices.Sort(progress.CompletedKeys)
+		progress.CompletedKeys = slices.Compact(progress.CompletedKeys)
+
+		checkpoint.Shards[name] = progress
+		checkpoint.Complete = checkpoint.Complete && progress.Done
+	}
+
+	return checkpoint
+}
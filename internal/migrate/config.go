@@ -3,6 +3,11 @@ package migrate
 import (
 	"errors"
 	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Mode defines the migration mode.
@@ -34,9 +39,44 @@ type Config struct {
 	// DestURL is the Redis connection string for the destination database.
 	DestURL string
 
+	// SourceTLS configures TLS for the source connection. See [TLSOptions] for details.
+	SourceTLS TLSOptions
+
+	// DestTLS configures TLS for the destination connection. See [TLSOptions] for details.
+	DestTLS TLSOptions
+
 	// Pattern is the Redis key pattern to match for migration.
 	Pattern string
 
+	// Include lists glob patterns of which a key must match at least one, in
+	// addition to Pattern. Unlike Pattern, they are matched client-side.
+	Include []string
+
+	// Exclude lists glob patterns of keys that are not migrated, matched
+	// client-side.
+	Exclude []string
+
+	// Regex is a regular expression keys must match, matched client-side.
+	// Keys are not filtered by a regular expression if nil.
+	Regex *regexp.Regexp
+
+	// Types restricts the migration to keys of these types. Keys of all types
+	// are migrated if empty. See [ScanOptions.Types] for details.
+	Types []string
+
+	// Access restricts the migration to keys by their last access or access
+	// frequency. See [AccessFilter] for details.
+	Access AccessFilter
+
+	// Rewrites rename keys in the destination. The first rewrite matching a
+	// key applies, keys matched by none keep their name. See [KeyRewrite] for
+	// details.
+	Rewrites []KeyRewrite
+
+	// TTL adjusts the TTLs of keys in the destination. TTLs are copied as is
+	// by default. See [TTLPolicy] for details.
+	TTL TTLPolicy
+
 	// Mode specifies the migration mode. See [Mode] for details.
 	Mode Mode
 
@@ -51,6 +91,36 @@ type Config struct {
 
 	// Verbose enables detailed logging during migration.
 	Verbose bool
+
+	// CheckpointFile is the path the progress of the migration is written to,
+	// so that it can be resumed later. Checkpointing is disabled if empty.
+	CheckpointFile string
+
+	// DryRun inspects the keys that would be migrated and checks them for
+	// conflicts, without writing or deleting anything. See [Plan] for details.
+	DryRun bool
+
+	// Follow keeps replicating changes of the source once the migration has
+	// completed. See [Migrator.Follow] for details.
+	Follow bool
+
+	// Replicate follows the source as a replica instead of with keyspace
+	// notifications, which can miss changes while reconnecting. It requires
+	// Follow. See [Replicator] for details.
+	Replicate bool
+
+	// Verify compares source and destination once a copy has completed. See
+	// [Verifier] for details.
+	Verify bool
+
+	// VerifyTTLTolerance is the maximum difference between the TTLs of a key
+	// in source and destination for it to be considered identical.
+	VerifyTTLTolerance time.Duration
+
+	// BigKeyThreshold is the MEMORY USAGE in bytes above which a key is copied
+	// in chunks instead of with DUMP and RESTORE. Big keys are copied as is if
+	// zero. See [ChunkReader] for details.
+	BigKeyThreshold int64
 }
 
 // Validate checks if the migration configuration is valid.
@@ -65,6 +135,14 @@ func (c *Config) Validate() error {
 		errs = append(errs, errors.New("no destination URL provided"))
 	}
 
+	if _, err := c.SourceTLS.Config(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid source TLS configuration: %w", err))
+	}
+
+	if _, err := c.DestTLS.Config(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid destination TLS configuration: %w", err))
+	}
+
 	if c.Mode != CopyMode && c.Mode != MoveMode {
 		errs = append(errs, fmt.Errorf("invalid mode: %d (must be CopyMode or MoveMode)", c.Mode))
 	}
@@ -73,6 +151,71 @@ func (c *Config) Validate() error {
 		errs = append(errs, fmt.Errorf("invalid conflict behavior: %d (must be ErrorOnConflict, SkipOnConflict, or OverwriteOnConflict)", c.Conflict))
 	}
 
+	if c.Verify && c.Mode == MoveMode {
+		errs = append(errs, errors.New("verification is not supported in move mode, which empties the source"))
+	}
+
+	if c.Verify && c.DryRun {
+		errs = append(errs, errors.New("a dry run cannot be verified"))
+	}
+
+	if c.VerifyTTLTolerance < 0 {
+		errs = append(errs, fmt.Errorf("invalid TTL tolerance: %s (must not be negative)", c.VerifyTTLTolerance))
+	}
+
+	for _, typ := range c.Types {
+		if !DataTypes[typ] {
+			errs = append(errs, fmt.Errorf("invalid type: %s (must be 'string', 'hash', 'list', 'set', 'zset' or 'stream')", typ))
+		}
+	}
+
+	if err := c.Access.validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.Verify && c.Access.enabled() {
+		errs = append(errs, errors.New("verification is not supported with an idle time or access frequency filter, as copying counts as an access"))
+	}
+
+	if err := c.TTL.validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.BigKeyThreshold < 0 {
+		errs = append(errs, fmt.Errorf("invalid big key threshold: %d (must not be negative)", c.BigKeyThreshold))
+	}
+
+	if c.DryRun && c.CheckpointFile != "" {
+		errs = append(errs, errors.New("a dry run cannot be checkpointed or resumed"))
+	}
+
+	if c.Replicate {
+		if !c.Follow {
+			errs = append(errs, errors.New("replication requires following changes"))
+		}
+		if c.CheckpointFile != "" {
+			errs = append(errs, errors.New("replication cannot be checkpointed or resumed, as it starts from a new snapshot"))
+		}
+		if c.TTL != (TTLPolicy{}) {
+			errs = append(errs, errors.New("a TTL policy is not supported with replication, as it can't be applied to replicated commands"))
+		}
+	}
+
+	if c.Follow {
+		if c.Mode == MoveMode {
+			errs = append(errs, errors.New("following changes is not supported in move mode, which deletes the keys it copies"))
+		}
+		if c.DryRun {
+			errs = append(errs, errors.New("a dry run cannot follow changes"))
+		}
+		if c.Verify {
+			errs = append(errs, errors.New("verification is not supported while following changes, as the source keeps changing"))
+		}
+		if len(c.Types) > 0 || c.Access.enabled() {
+			errs = append(errs, errors.New("following changes is not supported with a type, idle time or access frequency filter, as changed keys are only matched by name"))
+		}
+	}
+
 	return errors.Join(errs...)
 }
 
@@ -128,3 +271,55 @@ func ParseConflictBehavior(s string) (ConflictBehavior, error) {
 	}
 }
 
+// ParseDuration parses a duration like [time.ParseDuration], optionally
+// preceded by a number of days, e.g. "30d", "1d12h" or "90m".
+func ParseDuration(s string) (time.Duration, error) {
+	rest := strings.TrimSpace(s)
+
+	if rest == "" {
+		return 0, fmt.Errorf("invalid duration: %s (must be e.g. '30d', '12h' or '90m')", s)
+	}
+
+	var days int64
+	if number, after, ok := strings.Cut(rest, "d"); ok {
+		n, err := strconv.ParseInt(number, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s (must be e.g. '30d', '12h' or '90m')", s)
+		}
+		days, rest = n, after
+	}
+
+	var d time.Duration
+	if rest != "" {
+		var err error
+		if d, err = time.ParseDuration(rest); err != nil {
+			return 0, fmt.Errorf("invalid duration: %s (must be e.g. '30d', '12h' or '90m')", s)
+		}
+	}
+
+	d += time.Duration(days) * 24 * time.Hour
+	if d < 0 || days < 0 {
+		return 0, fmt.Errorf("invalid duration: %s (must not be negative)", s)
+	}
+	return d, nil
+}
+
+// ParseSize parses a size in bytes, optionally with one of the binary units
+// KB, MB or GB, e.g. "512KB" or "64MB".
+func ParseSize(s string) (int64, error) {
+	number, multiplier := strings.ToUpper(strings.TrimSpace(s)), int64(1)
+	for suffix, m := range map[string]int64{"KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30} {
+		if strings.HasSuffix(number, suffix) {
+			number, multiplier = strings.TrimSpace(strings.TrimSuffix(number, suffix)), m
+			break
+		}
+	}
+	number = strings.TrimSuffix(number, "B")
+
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || size < 0 || size > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("invalid size: %s (must be a number of bytes, optionally followed by KB, MB or GB)", s)
+	}
+	return size * multiplier, nil
+}
+
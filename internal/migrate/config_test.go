@@ -0,0 +1,82 @@
+package migrate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"1048576", 1 << 20, false},
+		{"512KB", 512 << 10, false},
+		{"64MB", 64 << 20, false},
+		{"64mb", 64 << 20, false},
+		{"2 GB", 2 << 30, false},
+		{"100B", 100, false},
+		{"", 0, true},
+		{"-1MB", 0, true},
+		{"1TB", 0, true},
+		{"8589934592GB", 0, true},
+		{"8589934591GB", 8589934591 << 30, false},
+		{"lots", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSize(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfig_ValidateTypes(t *testing.T) {
+	config := testConfig(CopyMode, ErrorOnConflict)
+	config.Types = []string{"hash", "stream"}
+	assert.NoError(t, config.Validate())
+
+	config.Types = []string{"hash", "json"}
+	assert.EqualError(t, config.Validate(), "invalid type: json (must be 'string', 'hash', 'list', 'set', 'zset' or 'stream')")
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"90m", 90 * time.Minute, false},
+		{"12h", 12 * time.Hour, false},
+		{"30d", 30 * 24 * time.Hour, false},
+		{"1d12h", 36 * time.Hour, false},
+		{" 7d ", 7 * 24 * time.Hour, false},
+		{"", 0, true},
+		{"d", 0, true},
+		{"-1d", 0, true},
+		{"-5m", 0, true},
+		{"30 days", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDuration(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
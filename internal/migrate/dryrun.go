@@ -0,0 +1,230 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxPlanSamples is the number of keys listed per category of a [Plan].
+const maxPlanSamples = 10
+
+// Inspector is implemented by clients supporting dry runs, see [Config.DryRun].
+type Inspector interface {
+	// InspectKeys returns the type, memory usage and TTL of multiple keys.
+	// Keys that don't exist are omitted.
+	InspectKeys(ctx context.Context, keys []string) ([]KeyInfo, error)
+
+	// ExistingKeys reports for every key whether it exists, in input order.
+	ExistingKeys(ctx context.Context, keys []string) ([]bool, error)
+}
+
+// KeyInfo describes a key without its value.
+type KeyInfo struct {
+	Key  string
+	Type string
+
+	// Size is the memory usage of the key in bytes.
+	Size int64
+
+	// TTL is the remaining time to live like [KeyData.TTL], so that dry runs
+	// apply [Config.TTL] like a migration.
+	TTL time.Duration
+}
+
+// Plan is the result of a dry run: what a migration with the same
+// configuration would do.
+type Plan struct {
+	// Keys is the number of keys that would be migrated.
+	Keys int64
+
+	// Bytes is the memory usage of those keys in the source.
+	Bytes int64
+
+	// Types counts the keys per data type.
+	Types map[string]int64
+
+	// Conflicts is the number of keys that already exist in the destination,
+	// and are handled according to [Config.Conflict].
+	Conflicts int64
+
+	// ConflictSamples lists the first conflicting keys in lexical order.
+	ConflictSamples []string
+
+	// Deletes is the number of keys that would be deleted from the source in
+	// [MoveMode].
+	Deletes int64
+
+	// DeleteSamples lists the first deleted keys in lexical order.
+	DeleteSamples []string
+}
+
+// planRecorder collects a [Plan] from concurrent workers.
+type planRecorder struct {
+	mu   sync.Mutex
+	plan Plan
+
+	// inspected holds the keys read by [dryRunSource] until their batch
+	// knows which of them would be migrated, see addKeys.
+	inspected map[string]KeyInfo
+}
+
+func newPlanRecorder() *planRecorder {
+	return &planRecorder{plan: Plan{Types: make(map[string]int64)}, inspected: make(map[string]KeyInfo)}
+}
+
+func (r *planRecorder) inspect(infos []KeyInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, info := range infos {
+		r.inspected[info.Key] = info
+	}
+}
+
+// addKeys records the keys of data, which are named as in the destination,
+// as keys that would be migrated. The other inspected keys of the batch, like
+// keys left out by the TTL policy, are forgotten.
+func (r *planRecorder) addKeys(keys []string, data []KeyData, renamed renames) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range data {
+		info, ok := r.inspected[renamed.source(d.Key)]
+		if !ok {
+			continue
+		}
+		r.plan.Keys++
+		r.plan.Bytes += info.Size
+		r.plan.Types[info.Type]++
+	}
+
+	for _, key := range keys {
+		delete(r.inspected, key)
+	}
+}
+
+func (r *planRecorder) addConflict(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.plan.Conflicts++
+	r.plan.ConflictSamples = addSample(r.plan.ConflictSamples, key)
+}
+
+func (r *planRecorder) addDeletes(keys []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.plan.Deletes += int64(len(keys))
+	for _, key := range keys {
+		r.plan.DeleteSamples = addSample(r.plan.DeleteSamples, key)
+	}
+}
+
+// addSample inserts key into the sorted samples, keeping at most
+// maxPlanSamples of them.
+func addSample(samples []string, key string) []string {
+	i, _ := slices.BinarySearch(samples, key)
+	if i >= maxPlanSamples {
+		return samples
+	}
+
+	samples = slices.Insert(samples, i, key)
+	if len(samples) > maxPlanSamples {
+		samples = samples[:maxPlanSamples]
+	}
+	return samples
+}
+
+func (r *planRecorder) snapshot() Plan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	plan := r.plan
+	plan.Types = maps.Clone(r.plan.Types)
+	plan.ConflictSamples = slices.Clone(r.plan.ConflictSamples)
+	plan.DeleteSamples = slices.Clone(r.plan.DeleteSamples)
+	return plan
+}
+
+// dryRunSource inspects keys instead of dumping them, and records deletes
+// instead of executing them.
+type dryRunSource struct {
+	RedisClient
+	plan *planRecorder
+}
+
+func (s *dryRunSource) DumpKeys(ctx context.Context, keys []string) ([]KeyData, error) {
+	infos, err := s.RedisClient.(Inspector).InspectKeys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	s.plan.inspect(infos)
+
+	data := make([]KeyData, len(infos))
+	for i, info := range infos {
+		data[i] = KeyData{Key: info.Key, TTL: info.TTL}
+	}
+	return data, nil
+}
+
+func (s *dryRunSource) DeleteKeys(_ context.Context, keys []string) error {
+	s.plan.addDeletes(keys)
+	return nil
+}
+
+// dryRunDest classifies keys like a restore would, without writing them.
+type dryRunDest struct {
+	RedisClient
+	plan *planRecorder
+}
+
+func (d *dryRunDest) RestoreKeys(ctx context.Context, data []KeyData, behavior ConflictBehavior) ([]RestoreResult, error) {
+	keys := make([]string, len(data))
+	for i, info := range data {
+		keys[i] = info.Key
+	}
+
+	exists, err := d.RedisClient.(Inspector).ExistingKeys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]RestoreResult, len(data))
+	for i, key := range keys {
+		results[i] = RestoreResult{Key: key, Outcome: OutcomeCreated}
+		if !exists[i] {
+			continue
+		}
+
+		d.plan.addConflict(key)
+
+		switch behavior {
+		case ErrorOnConflict:
+			results[i].Outcome = OutcomeConflict
+		case SkipOnConflict:
+			results[i].Outcome = OutcomeSkipped
+		case OverwriteOnConflict:
+			results[i].Outcome = OutcomeOverwritten
+		}
+	}
+
+	return results, nil
+}
+
+// checkDryRun verifies that both clients support dry runs.
+func checkDryRun(source, dest RedisClient) error {
+	var errs []error
+	if _, ok := source.(Inspector); !ok {
+		errs = append(errs, errors.New("source does not support dry runs"))
+	}
+	if _, ok := dest.(Inspector); !ok {
+		errs = append(errs, errors.New("destination does not support dry runs"))
+	}
+	return errors.Join(errs...)
+}
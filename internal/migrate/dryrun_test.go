@@ -0,0 +1,127 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/stats"
+)
+
+func TestMigrate_DryRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        Mode
+		conflict    ConflictBehavior
+		wantDeletes int64
+	}{
+		{"copy error", CopyMode, ErrorOnConflict, 0},
+		{"move error", MoveMode, ErrorOnConflict, 40},
+		{"move skip", MoveMode, SkipOnConflict, 40},
+		{"move overwrite", MoveMode, OverwriteOnConflict, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := numberedKeys("key", 50)
+			source := newMemoryClient(keys...)
+			dest := newMemoryClient(keys[:10]...)
+			metrics := stats.NewMetrics()
+
+			config := testConfig(tt.mode, tt.conflict)
+			config.DryRun = true
+			migrator := NewMigrator(source, dest, config, metrics)
+
+			// Conflicts don't abort a dry run, so that all of them are listed.
+			require.NoError(t, migrator.Migrate(context.Background()))
+
+			assert.Equal(t, keys, source.sortedKeys("*"), "dry run must not delete")
+			assert.Equal(t, keys[:10], dest.sortedKeys("*"), "dry run must not write")
+
+			plan := migrator.Plan()
+			assert.Equal(t, int64(50), plan.Keys)
+			assert.Equal(t, int64(50*len("payload:key:0000")), plan.Bytes)
+			assert.Equal(t, map[string]int64{"string": 50}, plan.Types)
+			assert.Equal(t, int64(10), plan.Conflicts)
+			assert.Equal(t, keys[:10], plan.ConflictSamples)
+			assert.Equal(t, tt.wantDeletes, plan.Deletes)
+			assert.Len(t, plan.DeleteSamples, int(min(tt.wantDeletes, maxPlanSamples)))
+			assert.Equal(t, int64(50), metrics.GetProcessedKeys())
+		})
+	}
+}
+
+func TestMigrate_DryRunMatchesMigration(t *testing.T) {
+	for _, conflict := range []ConflictBehavior{SkipOnConflict, OverwriteOnConflict} {
+		t.Run(conflict.String(), func(t *testing.T) {
+			keys := numberedKeys("key", 50)
+			config := testConfig(MoveMode, conflict)
+
+			dryRunMetrics := stats.NewMetrics()
+			config.DryRun = true
+			migrator := NewMigrator(newMemoryClient(keys...), newMemoryClient(keys[:10]...), config, dryRunMetrics)
+			require.NoError(t, migrator.Migrate(context.Background()))
+
+			metrics := stats.NewMetrics()
+			config.DryRun = false
+			source := newMemoryClient(keys...)
+			require.NoError(t, NewMigrator(source, newMemoryClient(keys[:10]...), config, metrics).Migrate(context.Background()))
+
+			assert.Equal(t, metrics.GetSuccessfulKeys(), dryRunMetrics.GetSuccessfulKeys())
+			assert.Equal(t, metrics.GetSkippedKeys(), dryRunMetrics.GetSkippedKeys())
+			assert.Equal(t, metrics.GetOverwrittenKeys(), dryRunMetrics.GetOverwrittenKeys())
+			assert.Equal(t, int64(len(keys)-len(source.sortedKeys("*"))), migrator.Plan().Deletes)
+		})
+	}
+}
+
+func TestMigrate_DryRunTTLPolicy(t *testing.T) {
+	source := newMemoryClient("persistent")
+	source.keys["expiring"] = KeyData{Key: "expiring", Data: "payload:expiring", TTL: time.Hour}
+	source.keys["soon"] = KeyData{Key: "soon", Data: "payload:soon", TTL: 10 * time.Second}
+	source.keys["expired"] = KeyData{Key: "expired", Data: "payload:expired", TTL: ExpiredTTL}
+	metrics := stats.NewMetrics()
+
+	config := testConfig(MoveMode, ErrorOnConflict)
+	config.TTL = TTLPolicy{MinRemaining: time.Minute}
+	config.DryRun = true
+	migrator := NewMigrator(source, newMemoryClient(), config, metrics)
+
+	require.NoError(t, migrator.Migrate(context.Background()))
+
+	assert.Equal(t, int64(2), metrics.GetSuccessfulKeys())
+	assert.Equal(t, int64(1), metrics.GetDroppedKeys())
+	assert.Equal(t, int64(1), metrics.GetExpiredKeys())
+
+	// Keys dropped by the policy would neither be migrated nor deleted from
+	// the source.
+	plan := migrator.Plan()
+	assert.Equal(t, int64(2), plan.Keys)
+	assert.Equal(t, int64(len("payload:expiring")+len("payload:persistent")), plan.Bytes)
+	assert.Equal(t, map[string]int64{"string": 2}, plan.Types)
+	assert.Equal(t, []string{"expiring", "persistent"}, plan.DeleteSamples)
+}
+
+func TestMigrate_DryRunUnsupported(t *testing.T) {
+	// Embedding the interface hides the Inspector methods of the client.
+	source := struct{ RedisClient }{newMemoryClient("key")}
+
+	config := testConfig(CopyMode, ErrorOnConflict)
+	config.DryRun = true
+
+	err := NewMigrator(source, newMemoryClient(), config, stats.NewMetrics()).Migrate(context.Background())
+	assert.EqualError(t, err, "source does not support dry runs")
+}
+
+func TestAddSample(t *testing.T) {
+	var samples []string
+	for _, key := range numberedKeys("key", 20) {
+		samples = addSample(samples, key)
+	}
+	samples = addSample(samples, "a")
+
+	assert.Equal(t, append([]string{"a"}, numberedKeys("key", maxPlanSamples-1)...), samples)
+}
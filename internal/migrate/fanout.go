@@ -0,0 +1,199 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/pucke-dev/go-redismigrate/internal/stats"
+)
+
+// errBatchFailed marks the results of keys that failed along with their
+// whole batch, whose error is reported once for the batch.
+var errBatchFailed = errors.New("batch failed")
+
+// Destination is one of the destinations of a fan-out migration, see
+// [NewFanOutMigrator].
+type Destination struct {
+	// Name identifies the destination in errors, e.g. its URL.
+	Name string
+
+	Client RedisClient
+
+	// Metrics tracks the progress and outcomes of the keys restored to the
+	// destination.
+	Metrics *stats.Metrics
+}
+
+// NewFanOutMigrator creates a migrator restoring every key to several
+// destinations. Each batch is dumped once and restored to all destinations
+// concurrently.
+//
+// The metrics of the migration count every key once, by its worst outcome
+// across destinations, while the metrics of a destination count its own
+// outcomes. In [MoveMode], a key is only deleted from the source once every
+// destination restored it.
+//
+// Dry runs, following changes, verification and checkpoints are limited to a
+// single destination, see [Config.ValidateFanOut]. With a single destination, it's the same as [NewMigrator],
+// and the metrics of the destination are not used.
+func NewFanOutMigrator(source RedisClient, dests []Destination, config Config, metrics *stats.Metrics) *Migrator {
+	m := NewMigrator(source, dests[0].Client, config, metrics)
+	if len(dests) > 1 {
+		m.dests = dests
+	}
+	return m
+}
+
+// errFanOutCheckpoint is returned for checkpoints of a migration to several
+// destinations.
+var errFanOutCheckpoint = errors.New("checkpoints support a single destination")
+
+// ValidateFanOut checks that the configuration of a migration to several
+// destinations doesn't use features limited to a single destination.
+func (c *Config) ValidateFanOut() error {
+	errs := []error{}
+
+	if c.DryRun {
+		errs = append(errs, errors.New("dry runs support a single destination"))
+	}
+	if c.Follow {
+		errs = append(errs, errors.New("following changes supports a single destination"))
+	}
+	if c.Verify {
+		errs = append(errs, errors.New("verification supports a single destination"))
+	}
+	if c.CheckpointFile != "" {
+		errs = append(errs, errFanOutCheckpoint)
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkFanOut returns an error if the migration uses features that are
+// limited to a single destination, see [Config.ValidateFanOut].
+func (m *Migrator) checkFanOut() error {
+	if len(m.dests) < 2 {
+		return nil
+	}
+	if m.resume != nil && m.config.CheckpointFile == "" {
+		return errFanOutCheckpoint
+	}
+	return m.config.ValidateFanOut()
+}
+
+// eachMetrics calls fn with the metrics of the migration and those of every
+// destination tracked separately.
+func (m *Migrator) eachMetrics(fn func(metrics *stats.Metrics)) {
+	fn(m.metrics)
+	for _, dest := range m.dests {
+		if dest.Metrics != m.metrics {
+			fn(dest.Metrics)
+		}
+	}
+}
+
+// restoreAll restores a batch to every destination concurrently, and returns
+// the results merged across destinations. shared are the results of keys
+// that were not restored anywhere, like keys whose rewrite failed.
+func (m *Migrator) restoreAll(ctx context.Context, data []KeyData, bigKeys []bigKey, renamed renames, shared []RestoreResult, errorsChan chan<- error) []RestoreResult {
+	results := make([][]RestoreResult, len(m.dests))
+
+	var wg sync.WaitGroup
+	for i, dest := range m.dests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = m.restoreTo(ctx, dest, data, bigKeys, renamed, errorsChan)
+		}()
+	}
+	wg.Wait()
+
+	if len(m.dests) == 1 {
+		return append(results[0], shared...)
+	}
+
+	// Keys copied natively or in chunks are counted by every destination
+	// copying them that way, and once by the migration.
+	native := make(map[string]bool)
+	for i, dest := range m.dests {
+		var counts outcomeCounts
+		for _, result := range append(results[i], shared...) {
+			counts[result.Outcome]++
+			if result.native {
+				native[result.Key] = true
+			}
+		}
+		if dest.Metrics != m.metrics {
+			updateMetrics(dest.Metrics, counts)
+		}
+	}
+	m.metrics.AddNative(int64(len(native)))
+	m.metrics.AddBigKeys(int64(len(bigKeys)))
+
+	return append(m.mergeResults(results), shared...)
+}
+
+// restoreTo restores a batch to a single destination. If the batch can't be
+// restored as a whole, its keys fail and the error is reported once on
+// errorsChan. Big keys are copied regardless, one by one.
+func (m *Migrator) restoreTo(ctx context.Context, dest Destination, data []KeyData, bigKeys []bigKey, renamed renames, errorsChan chan<- error) []RestoreResult {
+	results, err := dest.Client.RestoreKeys(ctx, data, m.config.Conflict)
+	if err != nil {
+		if len(m.dests) > 1 {
+			err = fmt.Errorf("%s: %w", dest.Name, err)
+		}
+		errorsChan <- fmt.Errorf("failed to restore keys: %w", err)
+
+		results = make([]RestoreResult, len(data))
+		for i, d := range data {
+			results[i] = RestoreResult{Key: d.Key, Outcome: OutcomeFailed, Err: fmt.Errorf("%w: %w", errBatchFailed, err)}
+		}
+	} else {
+		results = m.restoreNative(ctx, dest, results, renamed, m.config.Conflict)
+	}
+
+	return append(results, m.copyBigKeys(ctx, dest, bigKeys)...)
+}
+
+// outcomeRanks orders outcomes from the best to the worst, the merged outcome
+// of a key being its worst across destinations.
+var outcomeRanks = map[Outcome]int{
+	OutcomeCreated:     0,
+	OutcomeOverwritten: 1,
+	OutcomeSkipped:     2,
+	OutcomeDropped:     3,
+	OutcomeExpired:     4,
+	OutcomeConflict:    5,
+	OutcomeFailed:      6,
+}
+
+// mergeResults merges the results of every destination into a single result
+// per key, in the order of the first destination. A key is only created or
+// overwritten if it has been restored to every destination. Errors are
+// prefixed with the name of the destination they occurred at.
+func (m *Migrator) mergeResults(results [][]RestoreResult) []RestoreResult {
+	indexes := make(map[string]int)
+	var merged []RestoreResult
+
+	for i, destResults := range results {
+		for _, result := range destResults {
+			if result.Err != nil && !errors.Is(result.Err, errBatchFailed) {
+				result.Err = fmt.Errorf("%s: %w", m.dests[i].Name, result.Err)
+			}
+
+			j, ok := indexes[result.Key]
+			if !ok {
+				indexes[result.Key] = len(merged)
+				merged = append(merged, result)
+				continue
+			}
+			if outcomeRanks[result.Outcome] > outcomeRanks[merged[j].Outcome] {
+				merged[j] = result
+			}
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,179 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/stats"
+)
+
+// countingClient wraps a memoryClient and counts the keys dumped.
+type countingClient struct {
+	*memoryClient
+	dumped atomic.Int64
+}
+
+func (c *countingClient) DumpKeys(ctx context.Context, keys []string) ([]KeyData, error) {
+	c.dumped.Add(int64(len(keys)))
+	return c.memoryClient.DumpKeys(ctx, keys)
+}
+
+// failingClient is a destination that can't restore any batch.
+type failingClient struct {
+	*memoryClient
+}
+
+func (c *failingClient) RestoreKeys(context.Context, []KeyData, ConflictBehavior) ([]RestoreResult, error) {
+	return nil, errors.New("connection refused")
+}
+
+func destinations(clients ...RedisClient) []Destination {
+	dests := make([]Destination, len(clients))
+	for i, client := range clients {
+		dests[i] = Destination{Name: string(rune('a' + i)), Client: client, Metrics: stats.NewMetrics()}
+	}
+	return dests
+}
+
+func TestFanOut_DumpsOnce(t *testing.T) {
+	keys := numberedKeys("key", 95)
+	source := &countingClient{memoryClient: newMemoryClient(keys...)}
+	first, second := newMemoryClient(), newMemoryClient()
+	dests := destinations(first, second)
+	metrics := stats.NewMetrics()
+
+	err := NewFanOutMigrator(source, dests, testConfig(CopyMode, ErrorOnConflict), metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(95), source.dumped.Load())
+	assert.Equal(t, keys, first.sortedKeys("*"))
+	assert.Equal(t, keys, second.sortedKeys("*"))
+
+	assert.Equal(t, int64(95), metrics.GetProcessedKeys())
+	assert.Equal(t, int64(95), metrics.GetSuccessfulKeys())
+	for _, dest := range dests {
+		assert.Equal(t, int64(95), dest.Metrics.GetTotalKeys())
+		assert.Equal(t, int64(95), dest.Metrics.GetSuccessfulKeys())
+	}
+}
+
+func TestFanOut_MoveWaitsForEveryDestination(t *testing.T) {
+	keys := numberedKeys("key", 50)
+	source := newMemoryClient(keys...)
+	first, second := newMemoryClient(), newMemoryClient(keys[:10]...)
+	dests := destinations(first, second)
+	metrics := stats.NewMetrics()
+
+	err := NewFanOutMigrator(source, dests, testConfig(MoveMode, SkipOnConflict), metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, keys, first.sortedKeys("*"))
+	assert.Equal(t, keys, second.sortedKeys("*"))
+
+	// Keys skipped by one destination stay in the source.
+	assert.Equal(t, keys[:10], source.sortedKeys("*"))
+
+	assert.Equal(t, int64(40), metrics.GetSuccessfulKeys())
+	assert.Equal(t, int64(10), metrics.GetSkippedKeys())
+	assert.Equal(t, int64(50), dests[0].Metrics.GetSuccessfulKeys())
+	assert.Equal(t, int64(40), dests[1].Metrics.GetSuccessfulKeys())
+	assert.Equal(t, int64(10), dests[1].Metrics.GetSkippedKeys())
+}
+
+func TestFanOut_FailingDestination(t *testing.T) {
+	keys := numberedKeys("key", 20)
+	source := newMemoryClient(keys...)
+	first := newMemoryClient()
+	dests := destinations(first, &failingClient{memoryClient: newMemoryClient()})
+	metrics := stats.NewMetrics()
+
+	err := NewFanOutMigrator(source, dests, testConfig(MoveMode, ErrorOnConflict), metrics).Migrate(context.Background())
+
+	require.ErrorContains(t, err, "failed to restore keys: b: connection refused")
+	assert.Equal(t, keys, first.sortedKeys("*"))
+	assert.Equal(t, keys, source.sortedKeys("*"), "keys missing from a destination must not be moved")
+
+	assert.Equal(t, int64(20), metrics.GetFailedKeys())
+	assert.Equal(t, int64(20), dests[0].Metrics.GetSuccessfulKeys())
+	assert.Equal(t, int64(20), dests[1].Metrics.GetFailedKeys())
+}
+
+func TestFanOut_SingleDestinationFeatures(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(*Config)
+		want   string
+	}{
+		{"dry run", func(c *Config) { c.DryRun = true }, "dry runs support a single destination"},
+		{"follow", func(c *Config) { c.Follow = true }, "following changes supports a single destination"},
+		{"verify", func(c *Config) { c.Verify = true }, "verification supports a single destination"},
+		{"checkpoint", func(c *Config) { c.CheckpointFile = t.TempDir() + "/checkpoint.json" }, "checkpoints support a single destination"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig(CopyMode, ErrorOnConflict)
+			tt.config(&config)
+			dests := destinations(newMemoryClient(), newMemoryClient())
+
+			m := NewFanOutMigrator(newMemoryClient("key"), dests, config, stats.NewMetrics())
+
+			assert.EqualError(t, m.Migrate(context.Background()), tt.want)
+			assert.EqualError(t, m.Follow(context.Background()), tt.want)
+		})
+	}
+}
+
+func TestFanOut_BigKeysRewrite(t *testing.T) {
+	source := newChunkClient("key:1")
+	big := strings.Repeat("x", 2500)
+	source.keys["big:1"] = KeyData{Key: "big:1", Data: big}
+	first, second := newChunkClient(), newChunkClient()
+	dests := destinations(first, second)
+	metrics := stats.NewMetrics()
+
+	config := testConfig(MoveMode, ErrorOnConflict)
+	config.BigKeyThreshold = 1000
+	config.Rewrites = []KeyRewrite{NewPrefixRewrite("big:", "new:")}
+
+	err := NewFanOutMigrator(source, dests, config, metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	for _, dest := range []*chunkClient{first, second} {
+		assert.Equal(t, []string{"key:1", "new:1"}, dest.sortedKeys("*"))
+		assert.Equal(t, big, dest.keys["new:1"].Data)
+	}
+	assert.Empty(t, source.sortedKeys("*"))
+
+	assert.Equal(t, int64(2), metrics.GetSuccessfulKeys())
+	assert.Equal(t, int64(0), metrics.GetFailedKeys())
+	assert.Equal(t, int64(1), metrics.GetBigKeys())
+	for _, dest := range dests {
+		assert.Equal(t, int64(2), dest.Metrics.GetSuccessfulKeys())
+		assert.Equal(t, int64(1), dest.Metrics.GetBigKeys())
+	}
+}
+
+func TestFanOut_NativeMetrics(t *testing.T) {
+	keys := numberedKeys("key", 20)
+	source := &nativeClient{memoryClient: newMemoryClient(keys...)}
+	dests := destinations(
+		&nativeClient{memoryClient: newMemoryClient(), incompatible: map[string]bool{"key:0001": true, "key:0002": true}},
+		&nativeClient{memoryClient: newMemoryClient(), incompatible: map[string]bool{"key:0002": true, "key:0003": true}},
+	)
+	metrics := stats.NewMetrics()
+
+	err := NewFanOutMigrator(source, dests, testConfig(CopyMode, ErrorOnConflict), metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), metrics.GetSuccessfulKeys())
+	assert.Equal(t, int64(3), metrics.GetNativeKeys(), "keys copied natively to any destination are counted once")
+	assert.Equal(t, int64(2), dests[0].Metrics.GetNativeKeys())
+	assert.Equal(t, int64(2), dests[1].Metrics.GetNativeKeys())
+}
@@ -0,0 +1,147 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// ErrReadOnly is returned by the methods of [FileKeys] writing keys.
+var ErrReadOnly = errors.New("keys of a file can't be changed")
+
+// FileKeys scans the keys of files for sources reading them instead of a
+// server, like archives. A file is scanned as a single shard, using the
+// offset of a batch in the file as its cursor. Where each key was found is
+// kept, so that its value can be read again when it's dumped.
+//
+// Sources embed FileKeys for the methods of [RedisClient] and [Inspector] that
+// don't read values. Writing keys fails with [ErrReadOnly].
+//
+// A key found several times in a file is scanned and counted once, with the
+// location of its first occurrence, as restoring it again would conflict with
+// itself.
+type FileKeys[L any] struct {
+	mu   sync.Mutex
+	keys map[string]L
+}
+
+// FileReader reads a file in order, and calls yield with every key matching
+// a scan, the location L of the key, its offset in the file and the offset
+// following it.
+type FileReader[L any] func(yield func(key string, loc L, offset, next int64) error) error
+
+// NewFileKeys returns a [FileKeys] without any keys scanned.
+func NewFileKeys[L any]() *FileKeys[L] {
+	return &FileKeys[L]{keys: make(map[string]L)}
+}
+
+// Scan sends the keys read by read to batches. Keys before the cursor of a
+// resumed scan are read, e.g. to verify a checksum, but not sent.
+func (f *FileKeys[L]) Scan(ctx context.Context, opts ScanOptions, batches chan<- KeyBatch, read FileReader[L]) error {
+	var resume int64
+	if cursor, ok := opts.Resume[""]; ok {
+		if cursor == "" {
+			return nil
+		}
+		var err error
+		if resume, err = strconv.ParseInt(cursor, 10, 64); err != nil {
+			return fmt.Errorf("invalid file cursor %q", cursor)
+		}
+	}
+
+	send := func(batch KeyBatch) error {
+		select {
+		case batches <- batch:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	batchSize := max(opts.BatchSize, 1)
+	batch := KeyBatch{Cursor: strconv.FormatInt(resume, 10)}
+
+	// Duplicates of keys before the cursor are left out as well, as those
+	// keys have been sent before the scan was resumed.
+	seen := make(map[string]bool)
+
+	err := read(func(key string, loc L, offset, next int64) error {
+		if seen[key] {
+			return nil
+		}
+		seen[key] = true
+
+		f.mu.Lock()
+		if _, ok := f.keys[key]; !ok {
+			f.keys[key] = loc
+		}
+		f.mu.Unlock()
+
+		if offset < resume {
+			return nil
+		}
+
+		batch.Keys = append(batch.Keys, key)
+		if len(batch.Keys) < batchSize {
+			return nil
+		}
+
+		batch.Next = strconv.FormatInt(next, 10)
+		if err := send(batch); err != nil {
+			return err
+		}
+		batch = KeyBatch{Cursor: batch.Next}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return send(batch)
+}
+
+// Count returns the number of distinct keys read by read.
+func (f *FileKeys[L]) Count(read FileReader[L]) (int64, error) {
+	seen := make(map[string]bool)
+	err := read(func(key string, _ L, _, _ int64) error {
+		seen[key] = true
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(seen)), nil
+}
+
+// Location returns where key was found, and false if it hasn't been scanned.
+func (f *FileKeys[L]) Location(key string) (L, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	loc, ok := f.keys[key]
+	return loc, ok
+}
+
+// ExistingKeys reports for every key whether it has been scanned, in input
+// order.
+func (f *FileKeys[L]) ExistingKeys(_ context.Context, keys []string) ([]bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	exists := make([]bool, len(keys))
+	for i, key := range keys {
+		_, exists[i] = f.keys[key]
+	}
+	return exists, nil
+}
+
+// RestoreKeys returns [ErrReadOnly].
+func (f *FileKeys[L]) RestoreKeys(context.Context, []KeyData, ConflictBehavior) ([]RestoreResult, error) {
+	return nil, ErrReadOnly
+}
+
+// DeleteKeys returns [ErrReadOnly].
+func (f *FileKeys[L]) DeleteKeys(context.Context, []string) error {
+	return ErrReadOnly
+}
@@ -0,0 +1,75 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lineReader reads keys as if every key were a line of ten bytes, using the
+// line number as the location of a key.
+func lineReader(keys ...string) FileReader[int] {
+	return func(yield func(key string, loc int, offset, next int64) error) error {
+		for i, key := range keys {
+			if err := yield(key, i, int64(i*10), int64(i*10+10)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// scanFile collects the batches of a scan.
+func scanFile(t *testing.T, files *FileKeys[int], opts ScanOptions, read FileReader[int]) []KeyBatch {
+	t.Helper()
+
+	batches := make(chan KeyBatch, 100)
+	require.NoError(t, files.Scan(context.Background(), opts, batches, read))
+	close(batches)
+
+	var all []KeyBatch
+	for batch := range batches {
+		all = append(all, batch)
+	}
+	return all
+}
+
+func TestFileKeys_Duplicates(t *testing.T) {
+	read := lineReader("a", "b", "a", "c", "b")
+	files := NewFileKeys[int]()
+
+	count, err := files.Count(read)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	batches := scanFile(t, files, ScanOptions{BatchSize: 2}, read)
+	assert.Equal(t, []KeyBatch{
+		{Keys: []string{"a", "b"}, Cursor: "0", Next: "20"},
+		{Keys: []string{"c"}, Cursor: "20"},
+	}, batches)
+
+	loc, ok := files.Location("a")
+	assert.True(t, ok)
+	assert.Equal(t, 0, loc, "keys are read from their first occurrence")
+
+	// Keys before the cursor have been sent by the resumed scan.
+	resumed := scanFile(t, files, ScanOptions{BatchSize: 2, Resume: map[string]string{"": "20"}}, read)
+	assert.Equal(t, []KeyBatch{{Keys: []string{"c"}, Cursor: "20"}}, resumed)
+
+	exists, err := files.ExistingKeys(context.Background(), []string{"c", "d"})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, exists)
+}
+
+func TestFileKeys_Errors(t *testing.T) {
+	files := NewFileKeys[int]()
+
+	_, err := files.RestoreKeys(context.Background(), []KeyData{{Key: "a"}}, OverwriteOnConflict)
+	assert.ErrorIs(t, err, ErrReadOnly)
+	assert.ErrorIs(t, files.DeleteKeys(context.Background(), []string{"a"}), ErrReadOnly)
+
+	err = files.Scan(context.Background(), ScanOptions{Resume: map[string]string{"": "line 3"}}, nil, lineReader())
+	assert.EqualError(t, err, `invalid file cursor "line 3"`)
+}
@@ -0,0 +1,59 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrWriteOnly is returned by the methods of [WriteOnly] reading or deleting
+// keys.
+var ErrWriteOnly = errors.New("keys of a file being written can't be read or deleted")
+
+// WriteOnly is embedded by destinations writing files, like archives, for the
+// methods of [RedisClient] reading or deleting keys, which fail with
+// [ErrWriteOnly]. It also closes the output of the file.
+type WriteOnly struct {
+	// Format names the file in errors, e.g. "archive".
+	Format string
+
+	// Out is the output the file is written to.
+	Out io.Writer
+}
+
+// ScanKeys returns [ErrWriteOnly].
+func (w WriteOnly) ScanKeys(context.Context, ScanOptions, chan<- KeyBatch) error {
+	return ErrWriteOnly
+}
+
+// CountKeys returns [ErrWriteOnly].
+func (w WriteOnly) CountKeys(context.Context, ScanOptions) (int64, error) {
+	return 0, ErrWriteOnly
+}
+
+// DumpKeys returns [ErrWriteOnly].
+func (w WriteOnly) DumpKeys(context.Context, []string) ([]KeyData, error) {
+	return nil, ErrWriteOnly
+}
+
+// DeleteKeys returns [ErrWriteOnly].
+func (w WriteOnly) DeleteKeys(context.Context, []string) error {
+	return ErrWriteOnly
+}
+
+// CloseOutput closes Out if it's closable and returns err, or the error
+// closing it.
+func (w WriteOnly) CloseOutput(err error) error {
+	if closer, ok := w.Out.(io.Closer); ok {
+		if closeErr := closer.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close %s: %w", w.Format, closeErr)
+		}
+	}
+	return err
+}
+
+// ErrClosed returns the error of keys written after the file has been closed.
+func (w WriteOnly) ErrClosed() error {
+	return fmt.Errorf("%s is closed", w.Format)
+}
@@ -0,0 +1,33 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// closingBuffer is an output failing to close.
+type closingBuffer struct {
+	bytes.Buffer
+	err error
+}
+
+func (b *closingBuffer) Close() error {
+	return b.err
+}
+
+func TestWriteOnly(t *testing.T) {
+	out := &closingBuffer{err: errors.New("disk full")}
+	w := WriteOnly{Format: "archive", Out: out}
+
+	_, err := w.DumpKeys(context.Background(), []string{"key"})
+	assert.ErrorIs(t, err, ErrWriteOnly)
+	assert.ErrorIs(t, w.DeleteKeys(context.Background(), []string{"key"}), ErrWriteOnly)
+
+	assert.EqualError(t, w.CloseOutput(nil), "failed to close archive: disk full")
+	assert.EqualError(t, w.CloseOutput(errors.New("write failed")), "write failed", "the first error is kept")
+	assert.EqualError(t, w.ErrClosed(), "archive is closed")
+}
@@ -0,0 +1,264 @@
+package migrate
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// keyFilter selects the keys of a scan on the client side, in addition to
+// [Config.Pattern] which the server matches.
+type keyFilter struct {
+	include []string
+	exclude []string
+	regex   *regexp.Regexp
+}
+
+// newKeyFilter returns the filter configured by config, or nil if config
+// doesn't filter keys beyond its pattern.
+func newKeyFilter(config Config) *keyFilter {
+	if len(config.Include) == 0 && len(config.Exclude) == 0 && config.Regex == nil {
+		return nil
+	}
+
+	return &keyFilter{
+		include: config.Include,
+		exclude: config.Exclude,
+		regex:   config.Regex,
+	}
+}
+
+// match reports whether key matches any include pattern, no exclude pattern
+// and the regular expression.
+func (f *keyFilter) match(key string) bool {
+	if len(f.include) > 0 && !matchAny(f.include, key) {
+		return false
+	}
+	if matchAny(f.exclude, key) {
+		return false
+	}
+	return f.regex == nil || f.regex.MatchString(key)
+}
+
+func matchAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply returns the keys matching the filter.
+func (f *keyFilter) apply(keys []string) []string {
+	var matched []string
+	for _, key := range keys {
+		if f.match(key) {
+			matched = append(matched, key)
+		}
+	}
+	return matched
+}
+
+// String describes the filter. It is empty for a nil filter.
+func (f *keyFilter) String() string {
+	if f == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, pattern := range f.include {
+		parts = append(parts, "include "+pattern)
+	}
+	for _, pattern := range f.exclude {
+		parts = append(parts, "exclude "+pattern)
+	}
+	if f.regex != nil {
+		parts = append(parts, "regex "+f.regex.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeFilters describes the filters of config beyond its pattern:
+// client-side, by type and by access. It is empty if there are none.
+func describeFilters(config Config) string {
+	description := newKeyFilter(config).String()
+	if len(config.Types) > 0 {
+		if description != "" {
+			description += ", "
+		}
+		description += "type " + strings.Join(config.Types, ",")
+	}
+	if access := config.Access.String(); access != "" {
+		if description != "" {
+			description += ", "
+		}
+		description += access
+	}
+	return description
+}
+
+// scanKeys scans client like [RedisClient.ScanKeys] and removes the keys not
+// matching f from every batch. Batches are forwarded even if no key matches,
+// so that the scan progress of every shard is still recorded.
+func scanKeys(ctx context.Context, client RedisClient, opts ScanOptions, f *keyFilter, batches chan<- KeyBatch) error {
+	if f == nil {
+		return client.ScanKeys(ctx, opts, batches)
+	}
+
+	unfiltered := make(chan KeyBatch)
+	done := make(chan struct{})
+
+	// Once ctx is cancelled, nothing may receive batches anymore. The
+	// remaining ones are drained, so that the scan can return.
+	go func() {
+		defer close(done)
+		for batch := range unfiltered {
+			if ctx.Err() != nil {
+				continue
+			}
+
+			batch.Keys = f.apply(batch.Keys)
+			select {
+			case batches <- batch:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	err := client.ScanKeys(ctx, opts, unfiltered)
+	close(unfiltered)
+	<-done
+
+	return err
+}
+
+// countKeys counts the keys of client matching f. Without a filter the count
+// of [RedisClient.CountKeys] is used, otherwise the keys are scanned and
+// counted after filtering, so that the total matches the keys migrated.
+func countKeys(ctx context.Context, client RedisClient, opts ScanOptions, f *keyFilter) (int64, error) {
+	if f == nil {
+		return client.CountKeys(ctx, opts)
+	}
+
+	batches := make(chan KeyBatch)
+	done := make(chan int64)
+
+	go func() {
+		var count int64
+		for batch := range batches {
+			count += int64(len(batch.Keys))
+		}
+		done <- count
+	}()
+
+	err := scanKeys(ctx, client, opts, f, batches)
+	close(batches)
+	count := <-done
+
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// MatchPattern reports whether key matches [ScanOptions.Pattern] like SCAN
+// MATCH does, for sources that aren't scanned by a server.
+func MatchPattern(pattern, key string) bool {
+	return pattern == "" || matchGlob(pattern, key)
+}
+
+// matchGlob reports whether key matches a Redis glob pattern, which supports
+// '*', '?', character classes like "[a-z]" or "[^0-9]", and '\' to escape the
+// next character. It follows stringmatchlen of the Redis server, so that
+// filters behave like [Config.Pattern].
+func matchGlob(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if matchGlob(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			key = key[1:]
+
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+
+			pattern = pattern[1:]
+			not := len(pattern) > 0 && pattern[0] == '^'
+			if not {
+				pattern = pattern[1:]
+			}
+
+			match := false
+			for len(pattern) > 0 && pattern[0] != ']' {
+				switch {
+				case pattern[0] == '\\' && len(pattern) >= 2:
+					pattern = pattern[1:]
+					if pattern[0] == key[0] {
+						match = true
+					}
+				case len(pattern) >= 3 && pattern[1] == '-':
+					start, end := pattern[0], pattern[2]
+					if start > end {
+						start, end = end, start
+					}
+					pattern = pattern[2:]
+					if key[0] >= start && key[0] <= end {
+						match = true
+					}
+				default:
+					if pattern[0] == key[0] {
+						match = true
+					}
+				}
+				pattern = pattern[1:]
+			}
+
+			if not {
+				match = !match
+			}
+			if !match {
+				return false
+			}
+			key = key[1:]
+
+			// An unterminated class ends the pattern.
+			if len(pattern) == 0 {
+				return len(key) == 0
+			}
+
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+
+		default:
+			if len(key) == 0 || pattern[0] != key[0] {
+				return false
+			}
+			key = key[1:]
+		}
+
+		pattern = pattern[1:]
+	}
+
+	return len(key) == 0
+}
@@ -0,0 +1,161 @@
+package migrate
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"testing/synctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/stats"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"*", "", true},
+		{"*", "app:cache:1", true},
+		{"app:*", "app:cache:1", true},
+		{"app:*", "ap", false},
+		{"app:cache:*", "app:user:1", false},
+		{"*:1", "app:user:1", true},
+		{"a**b", "axxb", true},
+		{"user:?", "user:1", true},
+		{"user:?", "user:12", false},
+		{"user:[0-9]", "user:5", true},
+		{"user:[9-0]", "user:5", true},
+		{"user:[0-9]", "user:x", false},
+		{"user:[^0-9]", "user:x", true},
+		{"user:[^0-9]", "user:5", false},
+		{"user:[abc]", "user:b", true},
+		{`user:[\]]`, "user:]", true},
+		{`user:\*`, "user:*", true},
+		{`user:\*`, "user:1", false},
+		{"user:[ab", "user:a", true},
+		{"exact", "exact", true},
+		{"exact", "exactly", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchGlob(tt.pattern, tt.key))
+		})
+	}
+}
+
+func TestMigrate_Filters(t *testing.T) {
+	source := newMemoryClient(
+		"app:user:1", "app:user:2", "app:cache:1", "app:lock:1",
+		"app:session:1", "app:session:tmp", "other:1",
+	)
+	dest := newMemoryClient()
+	metrics := stats.NewMetrics()
+
+	config := testConfig(MoveMode, ErrorOnConflict)
+	config.Pattern = "app:*"
+	config.Exclude = []string{"app:cache:*", "app:lock:*"}
+	config.Regex = regexp.MustCompile(`:\d+$`)
+	config.BatchSize = 2
+
+	err := NewMigrator(source, dest, config, metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app:session:1", "app:user:1", "app:user:2"}, dest.sortedKeys("*"))
+	assert.Equal(t, int64(3), metrics.GetTotalKeys())
+	assert.Equal(t, int64(3), metrics.GetProcessedKeys())
+	assert.Equal(t, 1.0, metrics.GetProgress())
+	assert.Equal(t, []string{"app:cache:1", "app:lock:1", "app:session:tmp", "other:1"}, source.sortedKeys("*"))
+}
+
+func TestMigrate_Include(t *testing.T) {
+	source := newMemoryClient("user:1", "session:1", "cache:1")
+	dest := newMemoryClient()
+	metrics := stats.NewMetrics()
+
+	config := testConfig(CopyMode, ErrorOnConflict)
+	config.Include = []string{"user:*", "session:*"}
+
+	err := NewMigrator(source, dest, config, metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"session:1", "user:1"}, dest.sortedKeys("*"))
+	assert.Equal(t, int64(2), metrics.GetTotalKeys())
+}
+
+func TestMigrate_FilterAbort(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		source := &blockingClient{
+			memoryClient: newMemoryClient(numberedKeys("key", 1000)...),
+			started:      make(chan struct{}),
+			release:      make(chan struct{}),
+		}
+
+		config := testConfig(CopyMode, ErrorOnConflict)
+		config.Exclude = []string{"nothing:*"}
+		config.Concurrency = 1
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- NewMigrator(source, newMemoryClient(), config, stats.NewMetrics()).Migrate(ctx)
+		}()
+
+		// Stop the migration once the filtered scan is blocked on the batches
+		// nobody receives anymore.
+		<-source.started
+		synctest.Wait()
+		cancel()
+		close(source.release)
+
+		assert.ErrorIs(t, <-done, ErrInterrupted)
+	})
+}
+
+func TestVerify_Filters(t *testing.T) {
+	source := newMemoryClient("app:1", "app:cache:1")
+	dest := newMemoryClient("app:1", "app:cache:2")
+
+	config := verifyConfig()
+	config.Exclude = []string{"app:cache:*"}
+
+	report, err := NewVerifier(source, dest, config, stats.NewMetrics()).Verify(context.Background())
+
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Equal(t, VerifyReport{Checked: 1, Matched: 1}, report)
+}
+
+// typeClient records the types requested from its scans, which are filtered
+// by the server.
+type typeClient struct {
+	*memoryClient
+	scanned, counted []string
+}
+
+func (c *typeClient) ScanKeys(ctx context.Context, opts ScanOptions, batches chan<- KeyBatch) error {
+	c.scanned = opts.Types
+	return c.memoryClient.ScanKeys(ctx, opts, batches)
+}
+
+func (c *typeClient) CountKeys(ctx context.Context, opts ScanOptions) (int64, error) {
+	c.counted = opts.Types
+	return c.memoryClient.CountKeys(ctx, opts)
+}
+
+func TestMigrate_Types(t *testing.T) {
+	source := &typeClient{memoryClient: newMemoryClient("user:1")}
+
+	config := testConfig(CopyMode, ErrorOnConflict)
+	config.Types = []string{"hash", "zset"}
+
+	err := NewMigrator(source, newMemoryClient(), config, stats.NewMetrics()).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hash", "zset"}, source.scanned)
+	assert.Equal(t, []string{"hash", "zset"}, source.counted)
+}
@@ -0,0 +1,325 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// followRetryDelay is the time to wait before retrying to replicate changes
+// after a failure, e.g. while the destination is unreachable.
+const followRetryDelay = time.Second
+
+// KeyEvent is a keyspace notification of the source.
+type KeyEvent struct {
+	// Key is the key that changed.
+	Key string
+
+	// Event is the name of the notification, e.g. "set", "del" or "expired".
+	Event string
+
+	// Received is the time the notification has been received.
+	Received time.Time
+}
+
+// Notifier is implemented by clients that can follow changes of their keys.
+// It's required by [Migrator.Follow].
+type Notifier interface {
+	// EnableNotifications makes sure that keyspace notifications are
+	// published for all changes, and returns a function restoring the
+	// previous configuration. It fails if they are disabled and can't be
+	// enabled.
+	EnableNotifications(ctx context.Context) (restore func(ctx context.Context) error, err error)
+
+	// SubscribeKeyspace subscribes to keyspace notifications and returns once
+	// the subscription is active. Events are sent on the returned channel,
+	// which is closed once ctx is done.
+	SubscribeKeyspace(ctx context.Context) (<-chan KeyEvent, error)
+}
+
+// Follow migrates all keys like [Migrator.Migrate], then keeps replicating
+// changes of the source to the destination until ctx is cancelled. It's meant
+// for cutovers without downtime: once the lag has dropped to zero after
+// writes to the source have stopped, clients can switch to the destination.
+//
+// Changes are followed with keyspace notifications, subscribed to before the
+// initial migration starts so that no change is missed. They only mark keys as
+// changed: the current state of a changed key is copied, overwriting the
+// destination regardless of [Config.Conflict], or the key is deleted from the
+// destination if it doesn't exist in the source anymore. Notifications of the
+// same key are coalesced until it is copied.
+//
+// With [Config.Replicate], the source is followed as a replica instead: the
+// keys are migrated from the snapshot of a full synchronization, and the write
+// commands following it are applied to the destination.
+//
+// Keys changed before ctx is cancelled are still replicated before Follow
+// returns. Like [Migrator.Migrate], the errors of keys that failed are
+// returned, including those that could not be replicated before stopping.
+func (m *Migrator) Follow(ctx context.Context) (err error) {
+	if err := m.checkFanOut(); err != nil {
+		return err
+	}
+
+	if m.config.Replicate {
+		return m.replicateFollow(ctx)
+	}
+
+	notifier, ok := m.source.(Notifier)
+	if !ok {
+		return errors.New("source does not support following changes")
+	}
+
+	restore, err := notifier.EnableNotifications(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Keyspace notifications cost some CPU on the source, so a failure to
+		// restore the configuration is reported like any other error.
+		if restoreErr := restore(context.WithoutCancel(ctx)); restoreErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to restore keyspace notification settings: %w", restoreErr))
+		}
+	}()
+
+	subscribeCtx, unsubscribe := context.WithCancel(ctx)
+	defer unsubscribe()
+
+	events, err := notifier.SubscribeKeyspace(subscribeCtx)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to keyspace notifications: %w", err)
+	}
+
+	queue := newFollowQueue()
+	filter := newKeyFilter(m.config)
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		for event := range events {
+			if matchKey(m.config, filter, event.Key) {
+				queue.add(event)
+				m.metrics.SetPendingKeys(int64(queue.len()))
+			}
+		}
+	}()
+
+	if err := m.Migrate(ctx); err != nil {
+		return err
+	}
+
+	m.metrics.MarkFollowing()
+
+	for {
+		select {
+		case <-queue.wake:
+		case <-ctx.Done():
+			// Changes received so far are still replicated, like in-flight
+			// batches of a migration are completed.
+			unsubscribe()
+			<-forwarded
+			if err := m.replicate(context.WithoutCancel(ctx), queue, true); err != nil {
+				m.addError(err)
+			}
+			return errors.Join(m.GetErrors()...)
+		}
+
+		// Failed batches are retried until ctx is cancelled.
+		m.replicate(ctx, queue, false)
+	}
+}
+
+// replicate copies the keys in queue in batches until it is empty. Batches
+// failing as a whole are queued again and retried after a delay, unless final
+// is set, in which case an error is returned for them instead.
+func (m *Migrator) replicate(ctx context.Context, queue *followQueue, final bool) error {
+	var errs []error
+	for {
+		keys, events, oldest := queue.take(m.config.BatchSize)
+		if len(keys) == 0 {
+			m.metrics.SetFollowLag(0)
+			return errors.Join(errs...)
+		}
+
+		m.metrics.SetFollowLag(time.Since(oldest))
+
+		err := m.syncKeys(ctx, keys)
+		if err != nil && final {
+			errs = append(errs, fmt.Errorf("failed to replicate %d changed key(s): %w", len(keys), err))
+			continue
+		}
+		if err != nil {
+			queue.requeue(keys, events, oldest)
+			select {
+			case <-time.After(followRetryDelay):
+			case <-ctx.Done():
+				return nil
+			}
+			continue
+		}
+
+		m.metrics.AddFollowEvents(events)
+		m.metrics.SetPendingKeys(int64(queue.len()))
+	}
+}
+
+// syncKeys copies the current state of changed keys to the destination. Keys
+// that don't exist in the source anymore, or that expired or are dropped by
+// [Config.TTL], are deleted from the destination. An error is returned if
+// the batch failed as a whole, failures of single keys are counted and
+// recorded instead.
+func (m *Migrator) syncKeys(ctx context.Context, keys []string) error {
+	read := time.Now()
+	data, err := m.source.DumpKeys(ctx, keys)
+	if err != nil {
+		return fmt.Errorf("failed to dump keys: %w", err)
+	}
+
+	present := make(map[string]bool, len(data))
+	for _, d := range data {
+		present[d.Key] = true
+	}
+
+	var removed []string
+	for _, key := range keys {
+		if present[key] {
+			continue
+		}
+		if name, err := rewriteKey(m.config.Rewrites, key); err == nil {
+			removed = append(removed, name)
+		}
+	}
+
+	renamed := make(renames)
+	data, failed := m.rewriteKeyData(data, renamed)
+	data, unrestored := m.applyTTLs(data, time.Since(read))
+	for _, result := range unrestored {
+		removed = append(removed, result.Key)
+	}
+
+	results, err := m.dest.RestoreKeys(ctx, data, OverwriteOnConflict)
+	if err != nil {
+		return fmt.Errorf("failed to restore keys: %w", err)
+	}
+	results = m.restoreNative(ctx, m.dests[0], results, renamed, OverwriteOnConflict)
+
+	if err := m.dest.DeleteKeys(ctx, removed); err != nil {
+		return fmt.Errorf("failed to delete keys: %w", err)
+	}
+
+	for _, result := range results {
+		if result.Outcome == OutcomeFailed {
+			failed = append(failed, result)
+		}
+	}
+	if len(failed) > 0 {
+		m.metrics.AddFailed(int64(len(failed)))
+		m.addError(fmt.Errorf("failed to replicate %d key(s), first %q: %w", len(failed), failed[0].Key, failed[0].Err))
+	}
+
+	return nil
+}
+
+// matchKey reports whether key matches [Config.Pattern] and the filter f,
+// which may be nil.
+func matchKey(config Config, f *keyFilter, key string) bool {
+	return matchGlob(config.Pattern, key) && (f == nil || f.match(key))
+}
+
+// followQueue collects the keys changed since they have been replicated.
+type followQueue struct {
+	mu   sync.Mutex
+	keys map[string]*pendingKey
+
+	// order lists the keys in the order they changed first.
+	order []string
+
+	// wake is signalled when a key has been added.
+	wake chan struct{}
+}
+
+type pendingKey struct {
+	// events is the number of notifications received for the key.
+	events int64
+
+	// since is the time the first of them has been received.
+	since time.Time
+}
+
+func newFollowQueue() *followQueue {
+	return &followQueue{
+		keys: make(map[string]*pendingKey),
+		wake: make(chan struct{}, 1),
+	}
+}
+
+func (q *followQueue) add(event KeyEvent) {
+	q.mu.Lock()
+	if pending, ok := q.keys[event.Key]; ok {
+		pending.events++
+	} else {
+		q.keys[event.Key] = &pendingKey{events: 1, since: event.Received}
+		q.order = append(q.order, event.Key)
+	}
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// take removes up to n keys that changed first from the queue, and returns
+// them with their number of events and the time the oldest has been
+// received.
+func (q *followQueue) take(n int) (keys []string, events int64, oldest time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	keys = q.order[:min(n, len(q.order))]
+	q.order = q.order[len(keys):]
+
+	for _, key := range keys {
+		pending := q.keys[key]
+		delete(q.keys, key)
+
+		events += pending.events
+		if oldest.IsZero() || pending.since.Before(oldest) {
+			oldest = pending.since
+		}
+	}
+
+	return keys, events, oldest
+}
+
+// requeue adds keys taken from the queue back in front of it.
+func (q *followQueue) requeue(keys []string, events int64, since time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var requeued []string
+	for i, key := range keys {
+		if pending, ok := q.keys[key]; ok {
+			pending.since = since
+			continue
+		}
+
+		// The events are attributed to the first key, so that they are
+		// counted once the batch has been replicated.
+		pending := &pendingKey{since: since}
+		if i == 0 {
+			pending.events = events
+		}
+		q.keys[key] = pending
+		requeued = append(requeued, key)
+	}
+
+	q.order = append(requeued, q.order...)
+}
+
+func (q *followQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.order)
+}
@@ -0,0 +1,223 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/stats"
+)
+
+// notifyClient wraps a memoryClient with keyspace notifications for the
+// changes made with set and del.
+type notifyClient struct {
+	*memoryClient
+	enableErr error
+
+	mu          sync.Mutex
+	subscribers []chan KeyEvent
+	restored    bool
+}
+
+func (c *notifyClient) EnableNotifications(context.Context) (func(context.Context) error, error) {
+	if c.enableErr != nil {
+		return nil, c.enableErr
+	}
+
+	return func(context.Context) error {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.restored = true
+		return nil
+	}, nil
+}
+
+func (c *notifyClient) SubscribeKeyspace(ctx context.Context) (<-chan KeyEvent, error) {
+	events := make(chan KeyEvent, 100)
+
+	c.mu.Lock()
+	c.subscribers = append(c.subscribers, events)
+	c.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.subscribers = nil
+		close(events)
+	}()
+
+	return events, nil
+}
+
+func (c *notifyClient) notify(key, event string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, events := range c.subscribers {
+		events <- KeyEvent{Key: key, Event: event, Received: time.Now()}
+	}
+}
+
+func (c *notifyClient) set(key, value string) {
+	c.memoryClient.mu.Lock()
+	c.keys[key] = KeyData{Key: key, Data: value}
+	c.memoryClient.mu.Unlock()
+	c.notify(key, "set")
+}
+
+func (c *notifyClient) del(key string) {
+	c.memoryClient.mu.Lock()
+	delete(c.keys, key)
+	c.memoryClient.mu.Unlock()
+	c.notify(key, "del")
+}
+
+func (c *notifyClient) isRestored() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.restored
+}
+
+func followConfig() Config {
+	config := testConfig(CopyMode, SkipOnConflict)
+	config.Pattern = "user:*"
+	config.Follow = true
+	return config
+}
+
+func TestFollow_ReplicatesChanges(t *testing.T) {
+	source := &notifyClient{memoryClient: newMemoryClient("user:1", "user:2", "order:1")}
+	dest := newMemoryClient()
+	metrics := stats.NewMetrics()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewMigrator(source, dest, followConfig(), metrics).Follow(ctx)
+	}()
+
+	require.Eventually(t, metrics.IsFollowing, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"user:1", "user:2"}, dest.sortedKeys("*"))
+
+	source.set("user:2", "updated")
+	source.set("user:2", "updated again")
+	source.set("user:3", "created")
+	source.del("user:1")
+	source.set("order:2", "not matching")
+
+	require.Eventually(t, func() bool {
+		return metrics.GetFollowEvents() == 4
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, []string{"user:2", "user:3"}, dest.sortedKeys("*"))
+	assert.Equal(t, "updated again", dest.keys["user:2"].Data, "changes overwrite the destination regardless of the conflict behavior")
+	assert.Equal(t, time.Duration(0), metrics.GetFollowLag())
+	assert.Equal(t, int64(0), metrics.GetPendingKeys())
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.True(t, source.isRestored())
+}
+
+func TestFollow_ReplicatesPendingChangesOnStop(t *testing.T) {
+	source := &notifyClient{memoryClient: newMemoryClient("user:1")}
+	dest := newMemoryClient()
+	metrics := stats.NewMetrics()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewMigrator(source, dest, followConfig(), metrics).Follow(ctx)
+	}()
+
+	require.Eventually(t, metrics.IsFollowing, time.Second, time.Millisecond)
+
+	source.set("user:2", "created")
+	cancel()
+
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"user:1", "user:2"}, dest.sortedKeys("*"))
+}
+
+func TestFollow_ExpiredKeysAreDeleted(t *testing.T) {
+	source := &notifyClient{memoryClient: newMemoryClient("user:1", "user:2")}
+	dest := newMemoryClient()
+	metrics := stats.NewMetrics()
+
+	config := followConfig()
+	config.Rewrites = []KeyRewrite{NewPrefixRewrite("user:", "account:")}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewMigrator(source, dest, config, metrics).Follow(ctx)
+	}()
+
+	require.Eventually(t, metrics.IsFollowing, time.Second, time.Millisecond)
+
+	source.memoryClient.mu.Lock()
+	source.keys["user:1"] = KeyData{Key: "user:1", Data: "payload:user:1", TTL: ExpiredTTL}
+	delete(source.keys, "user:2")
+	source.memoryClient.mu.Unlock()
+	source.notify("user:1", "expire")
+	source.notify("user:2", "expired")
+
+	require.Eventually(t, func() bool {
+		return metrics.GetFollowEvents() == 2
+	}, time.Second, time.Millisecond)
+
+	assert.Empty(t, dest.sortedKeys("*"), "keys are deleted under their rewritten names")
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestFollow_NotificationsUnavailable(t *testing.T) {
+	source := &notifyClient{
+		memoryClient: newMemoryClient("user:1"),
+		enableErr:    errors.New("keyspace notifications are disabled"),
+	}
+	dest := newMemoryClient()
+
+	err := NewMigrator(source, dest, followConfig(), stats.NewMetrics()).Follow(context.Background())
+
+	assert.EqualError(t, err, "keyspace notifications are disabled")
+	assert.Empty(t, dest.sortedKeys("*"), "nothing is migrated without notifications")
+
+	err = NewMigrator(newMemoryClient("user:1"), dest, followConfig(), stats.NewMetrics()).Follow(context.Background())
+
+	assert.EqualError(t, err, "source does not support following changes")
+}
+
+func TestConfig_ValidateFollow(t *testing.T) {
+	config := followConfig()
+	assert.NoError(t, config.Validate())
+
+	config.Mode = MoveMode
+	assert.ErrorContains(t, config.Validate(), "not supported in move mode")
+
+	config = followConfig()
+	config.Verify = true
+	assert.ErrorContains(t, config.Validate(), "not supported while following changes")
+
+	config = followConfig()
+	config.Types = []string{"hash"}
+	assert.ErrorContains(t, config.Validate(), "changed keys are only matched by name")
+
+	config = followConfig()
+	config.Replicate = true
+	assert.NoError(t, config.Validate())
+
+	config.Follow = false
+	assert.ErrorContains(t, config.Validate(), "replication requires following changes")
+
+	config = followConfig()
+	config.Replicate = true
+	config.TTL = TTLPolicy{Max: time.Hour}
+	assert.ErrorContains(t, config.Validate(), "a TTL policy is not supported with replication")
+}
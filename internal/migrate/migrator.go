@@ -13,17 +13,21 @@ import (
 
 // RedisClient defines the interface for Redis operations needed by the migrator.
 type RedisClient interface {
-	// ScanKeys streams keys matching a pattern to a channel in batches.
-	ScanKeys(ctx context.Context, pattern string, batchSize int, keysChan chan<- []string) error
+	// ScanKeys streams keys matching the scan options to a channel in batches.
+	// Batches of the same shard must be sent in scan order.
+	ScanKeys(ctx context.Context, opts ScanOptions, batches chan<- KeyBatch) error
 
-	// CountKeys counts keys matching a pattern.
-	CountKeys(ctx context.Context, pattern string, batchSize int) (int64, error)
+	// CountKeys counts keys matching the scan options.
+	CountKeys(ctx context.Context, opts ScanOptions) (int64, error)
 
-	// DumpKeys dumps multiple keys and returns their data and TTL.
+	// DumpKeys dumps multiple keys and returns their data and TTL. Keys that
+	// don't exist are omitted.
 	DumpKeys(ctx context.Context, keys []string) ([]KeyData, error)
 
-	// RestoreKeys restores multiple keys with conflict handling.
-	RestoreKeys(ctx context.Context, data []KeyData, behavior ConflictBehavior) ([]string, error)
+	// RestoreKeys restores multiple keys with conflict handling and returns
+	// one result per key, in the order of data. An error is only returned if
+	// the batch as a whole could not be processed.
+	RestoreKeys(ctx context.Context, data []KeyData, behavior ConflictBehavior) ([]RestoreResult, error)
 
 	// DeleteKeys deletes multiple keys.
 	DeleteKeys(ctx context.Context, keys []string) error
@@ -32,11 +36,129 @@ type RedisClient interface {
 	Close() error
 }
 
+// ErrInterrupted is returned when the migration is cancelled before all keys
+// have been processed.
+var ErrInterrupted = errors.New("migration interrupted")
+
+// ErrIncompatiblePayload is wrapped by the error of a [RestoreResult] when the
+// destination rejected the DUMP payload of a key, e.g. because it uses an
+// older RDB version than the source.
+var ErrIncompatiblePayload = errors.New("incompatible DUMP payload")
+
+// ConflictError is returned when a key already exists in the destination while
+// the conflict behavior is [ErrorOnConflict]. It aborts the migration.
+type ConflictError struct {
+	// Key is the destination key that already existed.
+	Key string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("key conflict: %q already exists in destination", e.Key)
+}
+
+// ScanOptions configures a scan of the keyspace.
+type ScanOptions struct {
+	// Pattern is the Redis glob pattern keys have to match.
+	Pattern string
+
+	// BatchSize is the number of keys requested per scan step.
+	BatchSize int
+
+	// Types restricts the scan to keys of these types, e.g. "hash". Keys of
+	// all types are scanned if empty.
+	Types []string
+
+	// Resume continues a previous scan. It maps shards to the cursor to
+	// continue from: shards mapped to an empty cursor have been scanned
+	// completely and are skipped, while shards missing from the map are
+	// scanned from the beginning.
+	Resume map[string]string
+}
+
+// KeyBatch is a batch of keys read by a single step of a scan.
+type KeyBatch struct {
+	Keys []string
+
+	// Shard identifies the independently scanned part of the keyspace the
+	// batch was read from, e.g. a cluster master. It is empty for sources
+	// scanned as a whole.
+	Shard string
+
+	// Cursor is the cursor the batch was read at.
+	Cursor string
+
+	// Next is the cursor to continue the scan of the shard from. It is empty
+	// once the shard has been scanned completely.
+	Next string
+}
+
+// Outcome classifies the result of restoring a single key.
+type Outcome int
+
+const (
+	// OutcomeCreated means the key did not exist in the destination and was created.
+	OutcomeCreated Outcome = iota
+	// OutcomeOverwritten means an existing key in the destination was replaced.
+	OutcomeOverwritten
+	// OutcomeSkipped means the key already existed in the destination and was left untouched.
+	OutcomeSkipped
+	// OutcomeConflict means the key already existed in the destination and conflicts are errors.
+	OutcomeConflict
+	// OutcomeFailed means the key could not be restored. See [RestoreResult.Err] for the reason.
+	OutcomeFailed
+	// OutcomeExpired means the key expired in the source while it was migrated.
+	OutcomeExpired
+	// OutcomeDropped means the key was left out because it expires sooner than [TTLPolicy.MinRemaining].
+	OutcomeDropped
+)
+
+// String returns the string representation of the outcome.
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeCreated:
+		return "created"
+	case OutcomeOverwritten:
+		return "overwritten"
+	case OutcomeSkipped:
+		return "skipped"
+	case OutcomeConflict:
+		return "conflict"
+	case OutcomeFailed:
+		return "failed"
+	case OutcomeExpired:
+		return "expired during migration"
+	case OutcomeDropped:
+		return "dropped"
+	default:
+		return "unknown"
+	}
+}
+
+// RestoreResult is the outcome of restoring a single key.
+type RestoreResult struct {
+	Key     string
+	Outcome Outcome
+
+	// Err is the reason the key could not be restored, set for [OutcomeFailed].
+	Err error
+
+	// native reports whether the key has been copied with native commands,
+	// see [ValueReader].
+	native bool
+}
+
 // KeyData represents a Redis key with its data and TTL.
 type KeyData struct {
 	Key  string
 	Data string
-	TTL  time.Duration
+
+	// TTL is the remaining time to live of the key, 0 if it doesn't expire,
+	// or [ExpiredTTL] if it expired after Data had been read.
+	TTL time.Duration
+
+	// Value is the value of the key read with native commands. It is written
+	// instead of Data if Data is empty, see [ValueReader].
+	Value *Value
 }
 
 type Migrator struct {
@@ -46,30 +168,89 @@ type Migrator struct {
 	// dest is the redis client for the destination database.
 	dest RedisClient
 
+	// dests are the destinations every batch is restored to, see
+	// [NewFanOutMigrator]. A single destination is dest, counted in metrics.
+	dests []Destination
+
 	// config contains the migration configuration.
 	config Config
 
 	// metrics is used to track migration progress and statistics.
 	metrics *stats.Metrics
 
+	// plan records the result of a dry run, see [Config.DryRun].
+	plan *planRecorder
+
+	// resume is the checkpoint of a previous run to continue, if any.
+	resume *Checkpoint
+
+	// checkpoint tracks completed batches, see [Checkpoint].
+	checkpoint *checkpointer
+
+	// batchMu is held for reading while a batch is processed, and for writing
+	// while a checkpoint is taken, so that checkpoints never contain a batch
+	// that has been processed only partially.
+	batchMu sync.RWMutex
+
 	errors []error
 	mu     sync.Mutex
 }
 
 func NewMigrator(source, dest RedisClient, config Config, metrics *stats.Metrics) *Migrator {
-	return &Migrator{
+	m := &Migrator{
 		source:  source,
 		dest:    dest,
 		config:  config,
 		metrics: metrics,
 		errors:  make([]error, 0),
 	}
+
+	// A dry run goes through the same pipeline, with inspecting clients that
+	// don't write anything.
+	if config.DryRun {
+		m.plan = newPlanRecorder()
+		m.source = &dryRunSource{RedisClient: source, plan: m.plan}
+		m.dest = &dryRunDest{RedisClient: dest, plan: m.plan}
+	}
+	m.dests = []Destination{{Name: config.DestURL, Client: m.dest, Metrics: metrics}}
+
+	return m
 }
 
 func (m *Migrator) GetConfig() Config {
 	return m.config
 }
 
+// Plan returns what the migration would do, as recorded by a dry run so far.
+// It is empty unless [Config.DryRun] is set.
+func (m *Migrator) Plan() Plan {
+	if m.plan == nil {
+		return Plan{}
+	}
+	return m.plan.snapshot()
+}
+
+// Resume continues the migration recorded in checkpoint instead of starting
+// over. The checkpoint must match the configuration of the migrator, and
+// belong to a migration that has not completed yet.
+//
+// Metrics are restored from the checkpoint, and the initial key count is
+// skipped.
+func (m *Migrator) Resume(checkpoint *Checkpoint) error {
+	if err := checkpoint.Matches(m.config); err != nil {
+		return err
+	}
+
+	if checkpoint.Complete {
+		return errors.New("checkpoint belongs to a completed migration")
+	}
+
+	m.resume = checkpoint
+	m.metrics.Restore(checkpoint.Metrics)
+
+	return nil
+}
+
 func (m *Migrator) addError(err error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -82,19 +263,65 @@ func (m *Migrator) GetErrors() []error {
 	return slices.Clone(m.errors)
 }
 
-func (m *Migrator) Migrate(ctx context.Context) error {
-	totalKeys, err := m.source.CountKeys(ctx, m.config.Pattern, m.config.BatchSize)
-	if err != nil {
-		return fmt.Errorf("failed to count keys: %w", err)
+// Migrate copies or moves all keys matching the configured pattern.
+//
+// Cancelling ctx stops the migration gracefully: the scan stops, batches that
+// are already in flight are completed, and the returned error wraps
+// [ErrInterrupted].
+//
+// With [ErrorOnConflict] the first conflicting key cancels the migration in
+// the same way, and the returned error contains a [ConflictError] naming the key.
+//
+// If [Config.CheckpointFile] is set, the progress is written to it
+// periodically and once the migration stops, see [Checkpoint].
+//
+// With [Config.DryRun] nothing is written or deleted, and conflicts don't
+// abort the migration. The result is available from [Migrator.Plan].
+func (m *Migrator) Migrate(parent context.Context) error {
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	if err := m.checkFanOut(); err != nil {
+		return err
 	}
 
-	m.metrics.SetTotal(totalKeys)
+	if m.plan != nil {
+		if err := checkDryRun(m.source.(*dryRunSource).RedisClient, m.dest.(*dryRunDest).RedisClient); err != nil {
+			return err
+		}
+	}
 
-	if totalKeys == 0 {
-		return nil
+	opts := ScanOptions{
+		Pattern:   m.config.Pattern,
+		BatchSize: m.config.BatchSize,
+		Types:     m.config.Types,
 	}
 
-	keysChan := make(chan []string, m.config.Concurrency*2)
+	if m.resume != nil {
+		opts.Resume = m.resume.resumeCursors()
+	} else {
+		totalKeys, err := countKeys(ctx, m.source, opts, newKeyFilter(m.config))
+		if parent.Err() != nil {
+			m.eachMetrics((*stats.Metrics).MarkInterrupted)
+			return ErrInterrupted
+		}
+		if err != nil {
+			return fmt.Errorf("failed to count keys: %w", err)
+		}
+
+		m.eachMetrics(func(metrics *stats.Metrics) {
+			metrics.SetTotal(totalKeys)
+		})
+
+		if totalKeys == 0 {
+			return nil
+		}
+	}
+
+	m.checkpoint = newCheckpointer(m.resume)
+
+	batchesChan := make(chan KeyBatch, m.config.Concurrency*2)
+	scheduledChan := make(chan *scheduledBatch, m.config.Concurrency*2)
 	errorsChan := make(chan error, m.config.Concurrency)
 
 	var wg sync.WaitGroup
@@ -103,19 +330,46 @@ func (m *Migrator) Migrate(ctx context.Context) error {
 	for range m.config.Concurrency {
 		go func() {
 			defer wg.Done()
-			m.worker(ctx, keysChan, errorsChan)
+			m.worker(ctx, cancel, scheduledChan, errorsChan)
 		}()
 	}
 
+	wg.Add(2)
 	go func() {
-		defer close(keysChan)
-		err := m.source.ScanKeys(ctx, m.config.Pattern, m.config.BatchSize, keysChan)
-		if err != nil {
+		defer wg.Done()
+		defer close(batchesChan)
+		err := scanKeys(ctx, m.source, opts, newKeyFilter(m.config), batchesChan)
+		// An aborted migration stops the scan, which is not an error on its own.
+		if err != nil && ctx.Err() == nil {
 			errorsChan <- fmt.Errorf("failed to scan keys: %w", err)
 		}
 	}()
 
-	// Wait for workers to finish.
+	// Every batch is registered with the checkpointer in scan order before a
+	// worker picks it up.
+	go func() {
+		defer wg.Done()
+		defer close(scheduledChan)
+		for batch := range batchesChan {
+			scheduled := m.checkpoint.schedule(batch)
+			if scheduled == nil {
+				continue
+			}
+
+			select {
+			case scheduledChan <- scheduled:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	saverDone := make(chan struct{})
+	if m.config.CheckpointFile != "" {
+		go m.saveCheckpoints(saverDone)
+	}
+
+	// Wait for the scan and the workers to finish.
 	go func() {
 		wg.Wait()
 		close(errorsChan)
@@ -127,64 +381,306 @@ func (m *Migrator) Migrate(ctx context.Context) error {
 		}
 	}
 
+	close(saverDone)
+
+	if parent.Err() != nil && m.metrics.GetRemainingKeys() > 0 {
+		m.eachMetrics((*stats.Metrics).MarkInterrupted)
+		m.addError(ErrInterrupted)
+	}
+
+	if m.config.CheckpointFile != "" {
+		if err := m.saveCheckpoint(); err != nil {
+			m.addError(err)
+		}
+	}
+
 	return errors.Join(m.GetErrors()...)
 }
 
-func (m *Migrator) worker(ctx context.Context, keysChan <-chan []string, errorsChan chan<- error) {
+// saveCheckpoints writes the checkpoint file periodically until done is closed.
+// Failures are ignored, as the final checkpoint written by [Migrator.Migrate]
+// reports them.
+func (m *Migrator) saveCheckpoints(done <-chan struct{}) {
+	ticker := time.NewTicker(checkpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = m.saveCheckpoint()
+		case <-done:
+			return
+		}
+	}
+}
+
+// saveCheckpoint writes the current progress to the checkpoint file, waiting
+// for batches in flight to finish first.
+func (m *Migrator) saveCheckpoint() error {
+	m.batchMu.Lock()
+	checkpoint := m.checkpoint.checkpoint(m.config, m.metrics.Snapshot())
+	m.batchMu.Unlock()
+
+	return checkpoint.save(m.config.CheckpointFile)
+}
+
+func (m *Migrator) worker(ctx context.Context, cancel context.CancelCauseFunc, batches <-chan *scheduledBatch, errorsChan chan<- error) {
 	for {
+		// Don't pick up new batches once the migration has been aborted, even
+		// if some are still buffered.
+		if ctx.Err() != nil {
+			return
+		}
+
 		select {
-		case keys, ok := <-keysChan:
+		case batch, ok := <-batches:
 			if !ok {
 				return
 			}
-			m.processBatch(ctx, keys, errorsChan)
+			// A batch that has been started is always completed, so that move
+			// mode never leaves keys restored but not deleted.
+			if err := m.runBatch(context.WithoutCancel(ctx), batch, errorsChan); err != nil {
+				cancel(err)
+			}
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
-func (m *Migrator) processBatch(ctx context.Context, keys []string, errorsChan chan<- error) {
-	keyData, err := m.source.DumpKeys(ctx, keys)
+// runBatch processes a scheduled batch and records it as completed, unless
+// it aborts the migration. Such a batch is processed again on resume.
+func (m *Migrator) runBatch(ctx context.Context, batch *scheduledBatch, errorsChan chan<- error) error {
+	m.batchMu.RLock()
+	defer m.batchMu.RUnlock()
+
+	counts, err := m.processBatch(ctx, batch.Keys, errorsChan)
 	if err != nil {
-		errorsChan <- fmt.Errorf("failed to dump keys: %w", err)
-		m.metrics.AddProcessed(int64(len(keys)))
-		m.metrics.AddFailed(int64(len(keys)))
-		return
+		m.checkpoint.exclude(counts)
+		return err
 	}
 
-	successfulKeys, err := m.dest.RestoreKeys(ctx, keyData, m.config.Conflict)
+	m.checkpoint.complete(batch)
+	return nil
+}
+
+// processBatch migrates a single batch of keys and returns the outcome of
+// every key. Errors that only affect the batch are reported on errorsChan,
+// while errors that must abort the whole migration are returned.
+func (m *Migrator) processBatch(ctx context.Context, keys []string, errorsChan chan<- error) (outcomeCounts, error) {
+	var counts outcomeCounts
+
+	// Keys filtered by access are not part of the total anymore, as they
+	// can't be filtered while counting.
+	matched, err := m.filterAccess(ctx, keys)
+	if errors.Is(err, ErrAccessUnavailable) {
+		errorsChan <- err
+		return counts, err
+	}
 	if err != nil {
-		errorsChan <- fmt.Errorf("failed to restore keys: %w", err)
-		m.metrics.AddProcessed(int64(len(keyData)))
-		m.metrics.AddFailed(int64(len(keyData)))
-		return
+		errorsChan <- err
+		counts[OutcomeFailed] = int64(len(keys))
+		m.updateMetrics(counts)
+		return counts, nil
+	}
+	m.eachMetrics(func(metrics *stats.Metrics) {
+		metrics.AddTotal(-int64(len(keys) - len(matched)))
+	})
+	keys = matched
+
+	smallKeys, bigKeys, err := m.splitBigKeys(ctx, keys)
+	if err != nil {
+		errorsChan <- err
+		counts[OutcomeFailed] = int64(len(keys))
+		m.updateMetrics(counts)
+		return counts, nil
+	}
+
+	read := time.Now()
+	keyData, err := m.readKeys(ctx, smallKeys)
+	if errors.Is(err, ErrValuesUnavailable) {
+		errorsChan <- err
+		return counts, err
+	}
+	if err != nil {
+		errorsChan <- fmt.Errorf("failed to dump keys: %w", err)
+		counts[OutcomeFailed] = int64(len(keys))
+		m.updateMetrics(counts)
+		return counts, nil
+	}
+
+	// Keys deleted or expired since the scan are left out by the source.
+	expired := missingKeys(smallKeys, keyData)
+
+	// Keys are restored under their rewritten names, while deletes in move
+	// mode and native reads use the source names.
+	renamed := make(renames)
+	keyData, rewriteFailed := m.rewriteKeyData(keyData, renamed)
+	big, bigRewriteFailed := m.rewriteBigKeys(bigKeys, renamed)
+	keyData, unrestored := m.applyTTLs(keyData, time.Since(read))
+
+	if m.plan != nil {
+		m.plan.addKeys(smallKeys, keyData, renamed)
+	}
+
+	results := m.restoreAll(ctx, keyData, big, renamed, slices.Concat(expired, rewriteFailed, bigRewriteFailed, unrestored), errorsChan)
+
+	var restoredKeys []string
+	var failed []RestoreResult
+	var conflict *ConflictError
+
+	for _, result := range results {
+		counts[result.Outcome]++
+
+		switch result.Outcome {
+		case OutcomeCreated, OutcomeOverwritten:
+			restoredKeys = append(restoredKeys, renamed.source(result.Key))
+		case OutcomeConflict:
+			if conflict == nil {
+				conflict = &ConflictError{Key: result.Key}
+			}
+		case OutcomeFailed:
+			// Keys failing along with their batch have been reported.
+			if !errors.Is(result.Err, errBatchFailed) {
+				failed = append(failed, result)
+			}
+		}
 	}
 
-	m.updateMetricsForBatch(keyData, successfulKeys)
+	// The metrics of separately tracked destinations have been updated with
+	// their own outcomes.
+	updateMetrics(m.metrics, counts)
 
-	// Delete from source if move mode.
-	if m.config.Mode == MoveMode && len(successfulKeys) > 0 {
-		if err := m.source.DeleteKeys(ctx, successfulKeys); err != nil {
+	if len(failed) > 0 {
+		errorsChan <- fmt.Errorf("failed to restore %d key(s), first %q: %w", len(failed), failed[0].Key, failed[0].Err)
+	}
+
+	// Delete from source if move mode. Keys restored next to a conflict are
+	// deleted as well, so an aborted move leaves no duplicates behind. With
+	// several destinations, only keys restored to all of them are deleted.
+	if m.config.Mode == MoveMode && len(restoredKeys) > 0 {
+		if err := m.source.DeleteKeys(ctx, restoredKeys); err != nil {
 			errorsChan <- fmt.Errorf("failed to delete keys from source: %w", err)
 		}
 	}
+
+	// A dry run reports every conflict in its plan instead.
+	if conflict != nil && !m.config.DryRun {
+		errorsChan <- conflict
+		return counts, conflict
+	}
+
+	return counts, nil
 }
 
-func (m *Migrator) updateMetricsForBatch(keyData []KeyData, successfulKeys []string) {
-	successCount := int64(len(successfulKeys))
-	totalCount := int64(len(keyData))
+// outcomeCounts counts the keys of a batch per [Outcome].
+type outcomeCounts [OutcomeDropped + 1]int64
 
-	m.metrics.AddProcessed(totalCount)
+func (c outcomeCounts) total() int64 {
+	var total int64
+	for _, count := range c {
+		total += count
+	}
+	return total
+}
 
-	if m.config.Conflict == OverwriteOnConflict {
-		m.metrics.AddOverwritten(successCount)
-	} else {
-		m.metrics.AddSuccess(successCount)
+// readKeys dumps keys, or reads their values with native commands if a
+// destination is a [ValueWriter] requiring them.
+func (m *Migrator) readKeys(ctx context.Context, keys []string) ([]KeyData, error) {
+	requiresValues := false
+	for _, dest := range m.dests {
+		if writer, ok := dest.Client.(ValueWriter); ok && writer.RequiresValues() {
+			requiresValues = true
+		}
+	}
+	if !requiresValues {
+		return m.source.DumpKeys(ctx, keys)
+	}
+
+	reader, ok := m.source.(ValueReader)
+	if !ok {
+		return nil, ErrValuesUnavailable
+	}
+	return reader.ReadValues(ctx, keys)
+}
+
+// restoreNative copies the keys whose DUMP payload dest rejected with native
+// commands, if the source implements [ValueReader], and returns results with
+// their outcomes replaced.
+func (m *Migrator) restoreNative(ctx context.Context, dest Destination, results []RestoreResult, renamed renames, behavior ConflictBehavior) []RestoreResult {
+	reader, ok := m.source.(ValueReader)
+	if !ok {
+		return results
+	}
+
+	indexes := make(map[string]int)
+	var keys []string
+	for i, result := range results {
+		if result.Outcome == OutcomeFailed && errors.Is(result.Err, ErrIncompatiblePayload) {
+			indexes[result.Key] = i
+			keys = append(keys, renamed.source(result.Key))
+		}
+	}
+
+	if len(keys) == 0 {
+		return results
+	}
+
+	read := time.Now()
+	values, err := reader.ReadValues(ctx, keys)
+	if err != nil {
+		return failNative(results, indexes, fmt.Errorf("failed to read values: %w", err))
+	}
+
+	values, _ = m.rewriteKeyData(values, make(renames))
+	values, unrestored := m.applyTTLs(values, time.Since(read))
+
+	nativeResults, err := dest.Client.RestoreKeys(ctx, values, behavior)
+	if err != nil {
+		return failNative(results, indexes, err)
 	}
 
-	failedCount := totalCount - successCount
-	if failedCount > 0 {
-		m.metrics.AddFailed(failedCount)
+	for _, result := range unrestored {
+		results[indexes[result.Key]] = result
 	}
+
+	// Keys deleted since the dump are not part of the native results, and keep
+	// their original error.
+	for _, result := range nativeResults {
+		if result.Outcome == OutcomeCreated || result.Outcome == OutcomeOverwritten {
+			result.native = true
+			dest.Metrics.AddNative(1)
+		}
+		results[indexes[result.Key]] = result
+	}
+
+	return results
+}
+
+// failNative adds the error of a failed native copy to the results at indexes.
+func failNative(results []RestoreResult, indexes map[string]int, err error) []RestoreResult {
+	for _, i := range indexes {
+		results[i].Err = fmt.Errorf("%w, and the native copy failed: %w", results[i].Err, err)
+	}
+	return results
+}
+
+// updateMetrics adds the outcomes of a batch that are the same for every
+// destination to all metrics.
+func (m *Migrator) updateMetrics(counts outcomeCounts) {
+	m.eachMetrics(func(metrics *stats.Metrics) {
+		updateMetrics(metrics, counts)
+	})
+}
+
+// updateMetrics adds the outcomes of a batch to metrics.
+func updateMetrics(metrics *stats.Metrics, counts outcomeCounts) {
+	metrics.AddProcessed(counts.total())
+	metrics.AddSuccess(counts[OutcomeCreated])
+	metrics.AddOverwritten(counts[OutcomeOverwritten])
+	metrics.AddSkipped(counts[OutcomeSkipped])
+	metrics.AddConflicted(counts[OutcomeConflict])
+	metrics.AddFailed(counts[OutcomeFailed])
+	metrics.AddExpired(counts[OutcomeExpired])
+	metrics.AddDropped(counts[OutcomeDropped])
 }
@@ -0,0 +1,431 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/stats"
+)
+
+// memoryClient is an in-memory [RedisClient] mimicking the semantics of the
+// Redis implementation.
+type memoryClient struct {
+	mu   sync.Mutex
+	keys map[string]KeyData
+}
+
+func newMemoryClient(keys ...string) *memoryClient {
+	c := &memoryClient{keys: make(map[string]KeyData)}
+	for _, key := range keys {
+		c.keys[key] = KeyData{Key: key, Data: "payload:" + key}
+	}
+	return c
+}
+
+func (c *memoryClient) sortedKeys(pattern string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var keys []string
+	for key := range c.keys {
+		if ok, _ := path.Match(pattern, key); ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// ScanKeys scans the sorted keys as a single shard, using the offset of a
+// batch as its cursor.
+func (c *memoryClient) ScanKeys(ctx context.Context, opts ScanOptions, batches chan<- KeyBatch) error {
+	offset := 0
+	if cursor, ok := opts.Resume[""]; ok {
+		if cursor == "" {
+			return nil
+		}
+		offset, _ = strconv.Atoi(cursor)
+	}
+
+	keys := c.sortedKeys(opts.Pattern)
+	for {
+		end := min(offset+opts.BatchSize, len(keys))
+		batch := KeyBatch{Keys: keys[offset:end], Cursor: strconv.Itoa(offset)}
+		if end < len(keys) {
+			batch.Next = strconv.Itoa(end)
+		}
+
+		select {
+		case batches <- batch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if batch.Next == "" {
+			return nil
+		}
+		offset = end
+	}
+}
+
+func (c *memoryClient) CountKeys(_ context.Context, opts ScanOptions) (int64, error) {
+	return int64(len(c.sortedKeys(opts.Pattern))), nil
+}
+
+func (c *memoryClient) DumpKeys(_ context.Context, keys []string) ([]KeyData, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var data []KeyData
+	for _, key := range keys {
+		if d, ok := c.keys[key]; ok {
+			data = append(data, d)
+		}
+	}
+	return data, nil
+}
+
+func (c *memoryClient) RestoreKeys(_ context.Context, data []KeyData, behavior ConflictBehavior) ([]RestoreResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	results := make([]RestoreResult, len(data))
+	for i, d := range data {
+		results[i] = RestoreResult{Key: d.Key, Outcome: OutcomeCreated}
+
+		if _, exists := c.keys[d.Key]; exists {
+			switch behavior {
+			case ErrorOnConflict:
+				results[i].Outcome = OutcomeConflict
+				continue
+			case SkipOnConflict:
+				results[i].Outcome = OutcomeSkipped
+				continue
+			case OverwriteOnConflict:
+				results[i].Outcome = OutcomeOverwritten
+			}
+		}
+
+		c.keys[d.Key] = d
+	}
+
+	return results, nil
+}
+
+func (c *memoryClient) DeleteKeys(_ context.Context, keys []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		delete(c.keys, key)
+	}
+	return nil
+}
+
+func (c *memoryClient) InspectKeys(_ context.Context, keys []string) ([]KeyInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var infos []KeyInfo
+	for _, key := range keys {
+		if d, ok := c.keys[key]; ok {
+			infos = append(infos, KeyInfo{Key: key, Type: "string", Size: int64(len(d.Data)), TTL: d.TTL})
+		}
+	}
+	return infos, nil
+}
+
+func (c *memoryClient) ExistingKeys(_ context.Context, keys []string) ([]bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	exists := make([]bool, len(keys))
+	for i, key := range keys {
+		_, exists[i] = c.keys[key]
+	}
+	return exists, nil
+}
+
+func (c *memoryClient) Close() error {
+	return nil
+}
+
+func numberedKeys(prefix string, n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%s:%04d", prefix, i)
+	}
+	return keys
+}
+
+func testConfig(mode Mode, conflict ConflictBehavior) Config {
+	return Config{
+		SourceURL:   "redis://source",
+		DestURL:     "redis://dest",
+		Pattern:     "*",
+		Mode:        mode,
+		Conflict:    conflict,
+		BatchSize:   10,
+		Concurrency: 2,
+	}
+}
+
+func TestMigrate_CopiesAllKeys(t *testing.T) {
+	source := newMemoryClient(numberedKeys("key", 95)...)
+	dest := newMemoryClient()
+	metrics := stats.NewMetrics()
+
+	err := NewMigrator(source, dest, testConfig(CopyMode, ErrorOnConflict), metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, source.sortedKeys("*"), dest.sortedKeys("*"))
+	assert.Equal(t, int64(95), metrics.GetProcessedKeys())
+	assert.Equal(t, int64(95), metrics.GetSuccessfulKeys())
+}
+
+func TestMigrate_ErrorOnConflictAborts(t *testing.T) {
+	for _, mode := range []Mode{CopyMode, MoveMode} {
+		t.Run(mode.String(), func(t *testing.T) {
+			keys := numberedKeys("key", 1000)
+			source := newMemoryClient(keys...)
+			dest := newMemoryClient("key:0015")
+			metrics := stats.NewMetrics()
+
+			err := NewMigrator(source, dest, testConfig(mode, ErrorOnConflict), metrics).Migrate(context.Background())
+
+			var conflict *ConflictError
+			require.ErrorAs(t, err, &conflict)
+			assert.Equal(t, "key:0015", conflict.Key)
+			assert.Less(t, metrics.GetProcessedKeys(), int64(len(keys)), "migration should stop early")
+			assert.Less(t, len(dest.sortedKeys("*")), len(keys))
+			assert.Equal(t, int64(1), metrics.GetConflictedKeys())
+
+			if mode == MoveMode {
+				// Every key is either still in the source or has been moved,
+				// except for the conflicting one which exists in both.
+				for _, key := range keys {
+					_, inSource := source.keys[key]
+					_, inDest := dest.keys[key]
+					assert.True(t, inSource != inDest || key == "key:0015", "key %s must be in exactly one database", key)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrate_OutcomeMetrics(t *testing.T) {
+	tests := []struct {
+		name            string
+		conflict        ConflictBehavior
+		wantSuccess     int64
+		wantSkipped     int64
+		wantOverwritten int64
+	}{
+		{"skip", SkipOnConflict, 40, 10, 0},
+		{"overwrite", OverwriteOnConflict, 40, 0, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := numberedKeys("key", 50)
+			source := newMemoryClient(keys...)
+			dest := newMemoryClient(keys[:10]...)
+			metrics := stats.NewMetrics()
+
+			err := NewMigrator(source, dest, testConfig(MoveMode, tt.conflict), metrics).Migrate(context.Background())
+
+			require.NoError(t, err)
+			assert.Equal(t, int64(50), metrics.GetProcessedKeys())
+			assert.Equal(t, tt.wantSuccess, metrics.GetSuccessfulKeys())
+			assert.Equal(t, tt.wantSkipped, metrics.GetSkippedKeys())
+			assert.Equal(t, tt.wantOverwritten, metrics.GetOverwrittenKeys())
+			assert.Zero(t, metrics.GetFailedKeys())
+
+			// Skipped keys stay in the source, everything else has been moved.
+			assert.Len(t, source.sortedKeys("*"), int(tt.wantSkipped))
+		})
+	}
+}
+
+// blockingClient wraps a memoryClient and blocks DumpKeys until released, to
+// cancel a migration while a batch is in flight.
+type blockingClient struct {
+	*memoryClient
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (c *blockingClient) DumpKeys(ctx context.Context, keys []string) ([]KeyData, error) {
+	c.once.Do(func() {
+		close(c.started)
+		<-c.release
+	})
+	return c.memoryClient.DumpKeys(ctx, keys)
+}
+
+func TestMigrate_CancelFinishesInFlightBatches(t *testing.T) {
+	keys := numberedKeys("key", 1000)
+	source := &blockingClient{
+		memoryClient: newMemoryClient(keys...),
+		started:      make(chan struct{}),
+		release:      make(chan struct{}),
+	}
+	dest := newMemoryClient()
+	metrics := stats.NewMetrics()
+
+	config := testConfig(MoveMode, ErrorOnConflict)
+	config.Concurrency = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewMigrator(source, dest, config, metrics).Migrate(ctx)
+	}()
+
+	<-source.started
+	cancel()
+	close(source.release)
+
+	err := <-done
+	assert.ErrorIs(t, err, ErrInterrupted)
+	assert.True(t, metrics.IsInterrupted())
+
+	// The batch in flight when cancelling has been completed.
+	assert.Equal(t, int64(config.BatchSize), metrics.GetProcessedKeys())
+	assert.Len(t, dest.sortedKeys("*"), config.BatchSize)
+	assert.Len(t, source.sortedKeys("*"), len(keys)-config.BatchSize)
+	assert.Equal(t, int64(len(keys)-config.BatchSize), metrics.GetRemainingKeys())
+}
+
+// nativeClient wraps a memoryClient with native value reads, and rejects the
+// DUMP payloads of keys listed in incompatible like an older server would.
+type nativeClient struct {
+	*memoryClient
+	incompatible map[string]bool
+}
+
+func (c *nativeClient) ReadValues(_ context.Context, keys []string) ([]KeyData, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var keyData []KeyData
+	for _, key := range keys {
+		if data, ok := c.keys[key]; ok {
+			keyData = append(keyData, KeyData{Key: key, TTL: data.TTL, Value: &Value{Type: "string", String: data.Data}})
+		}
+	}
+	return keyData, nil
+}
+
+func (c *nativeClient) RestoreKeys(ctx context.Context, data []KeyData, behavior ConflictBehavior) ([]RestoreResult, error) {
+	results := make([]RestoreResult, len(data))
+	var accepted []KeyData
+	for i, d := range data {
+		if d.Value == nil && c.incompatible[d.Key] {
+			results[i] = RestoreResult{Key: d.Key, Outcome: OutcomeFailed, Err: ErrIncompatiblePayload}
+			continue
+		}
+		if d.Value != nil {
+			d.Data = d.Value.String
+		}
+		accepted = append(accepted, d)
+	}
+
+	restored, err := c.memoryClient.RestoreKeys(ctx, accepted, behavior)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		if results[i].Key == "" {
+			results[i], restored = restored[0], restored[1:]
+		}
+	}
+	return results, nil
+}
+
+func TestMigrate_NativeFallback(t *testing.T) {
+	keys := numberedKeys("key", 50)
+	source := &nativeClient{memoryClient: newMemoryClient(keys...)}
+	dest := &nativeClient{
+		memoryClient: newMemoryClient("key:0001"),
+		incompatible: map[string]bool{"key:0001": true, "key:0002": true, "key:0003": true},
+	}
+	metrics := stats.NewMetrics()
+
+	err := NewMigrator(source, dest, testConfig(MoveMode, SkipOnConflict), metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(49), metrics.GetSuccessfulKeys())
+	assert.Equal(t, int64(1), metrics.GetSkippedKeys())
+	assert.Equal(t, int64(2), metrics.GetNativeKeys())
+	assert.Zero(t, metrics.GetFailedKeys())
+	assert.Equal(t, "payload:key:0002", dest.keys["key:0002"].Data)
+
+	// Keys copied natively are moved like any other.
+	assert.Equal(t, []string{"key:0001"}, source.sortedKeys("*"))
+}
+
+// valueWriter wraps a memoryClient as a destination requiring values read
+// with native commands.
+type valueWriter struct {
+	*memoryClient
+}
+
+func (c *valueWriter) RequiresValues() bool {
+	return true
+}
+
+func (c *valueWriter) RestoreKeys(ctx context.Context, data []KeyData, behavior ConflictBehavior) ([]RestoreResult, error) {
+	for i, d := range data {
+		if d.Value == nil {
+			return nil, fmt.Errorf("key %q has no value", d.Key)
+		}
+		data[i].Data = "value:" + d.Value.String
+	}
+	return c.memoryClient.RestoreKeys(ctx, data, behavior)
+}
+
+func TestMigrate_ValueWriter(t *testing.T) {
+	source := &nativeClient{memoryClient: newMemoryClient(numberedKeys("key", 50)...)}
+	dest := &valueWriter{memoryClient: newMemoryClient()}
+	metrics := stats.NewMetrics()
+
+	err := NewMigrator(source, dest, testConfig(CopyMode, ErrorOnConflict), metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), metrics.GetSuccessfulKeys())
+	assert.Zero(t, metrics.GetNativeKeys(), "keys read natively up front are no fallback")
+	assert.Equal(t, "value:payload:key:0001", dest.keys["key:0001"].Data)
+}
+
+func TestMigrate_ValueWriterWithoutReader(t *testing.T) {
+	source := newMemoryClient("key:1", "key:2")
+	dest := &valueWriter{memoryClient: newMemoryClient()}
+
+	err := NewMigrator(source, dest, testConfig(CopyMode, ErrorOnConflict), stats.NewMetrics()).Migrate(context.Background())
+
+	assert.ErrorIs(t, err, ErrValuesUnavailable)
+	assert.Empty(t, dest.sortedKeys("*"))
+}
+
+func TestMigrate_IncompatiblePayloadWithoutReader(t *testing.T) {
+	source := newMemoryClient("key:1", "key:2")
+	dest := &nativeClient{memoryClient: newMemoryClient(), incompatible: map[string]bool{"key:1": true}}
+	metrics := stats.NewMetrics()
+
+	err := NewMigrator(source, dest, testConfig(CopyMode, ErrorOnConflict), metrics).Migrate(context.Background())
+
+	assert.ErrorIs(t, err, ErrIncompatiblePayload)
+	assert.Equal(t, int64(1), metrics.GetFailedKeys())
+	assert.Zero(t, metrics.GetNativeKeys())
+}
@@ -0,0 +1,265 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Replicator is implemented by sources that can be followed as a replica. It's
+// required by [Migrator.Follow] with [Config.Replicate].
+type Replicator interface {
+	// Replicate requests a full synchronization from the source, passes the
+	// keys of its snapshot and then its write commands to handler, until ctx
+	// is done or an error occurs. In a cluster every master is replicated
+	// concurrently, while the calls for a single master are made in order.
+	Replicate(ctx context.Context, handler ReplicationHandler) error
+}
+
+// ReplicationHandler receives the data of a [Replicator].
+type ReplicationHandler interface {
+	// AddSnapshotSize is called with the number of keys of a snapshot before
+	// they are loaded, or with the size of every batch if the snapshot doesn't
+	// announce it.
+	AddSnapshotSize(keys int64)
+
+	// LoadSnapshot restores a batch of keys of a snapshot. Their data is a
+	// DUMP payload.
+	LoadSnapshot(ctx context.Context, data []KeyData) error
+
+	// SnapshotLoaded is called once the snapshots of all masters have been
+	// loaded.
+	SnapshotLoaded()
+
+	// Apply executes write commands received after a snapshot in order.
+	// received is the time the first of them has been received.
+	Apply(ctx context.Context, cmds []Command, received time.Time) error
+}
+
+// Command is a write command of a replication stream.
+type Command struct {
+	// Args holds the name of the command followed by its arguments.
+	Args []string
+
+	// Keys holds the indexes of the arguments that are key names.
+	Keys []int
+}
+
+// Name returns the upper-case name of the command.
+func (c Command) Name() string {
+	if len(c.Args) == 0 {
+		return ""
+	}
+	return strings.ToUpper(c.Args[0])
+}
+
+// CommandExecutor is implemented by clients that can execute the commands of
+// a replication stream. It's required by [Config.Replicate].
+type CommandExecutor interface {
+	// ExecCommands executes cmds in order and returns the error of every
+	// command. An error is only returned if the commands as a whole could not
+	// be executed.
+	ExecCommands(ctx context.Context, cmds []Command) ([]error, error)
+}
+
+// replicateFollow migrates all keys from the snapshot of a full
+// synchronization and then applies the write commands of the source, acting
+// as a replica. See [Config.Replicate].
+func (m *Migrator) replicateFollow(ctx context.Context) error {
+	source, ok := m.source.(Replicator)
+	if !ok {
+		return errors.New("source does not support replication")
+	}
+	dest, ok := m.dest.(CommandExecutor)
+	if !ok {
+		return errors.New("destination does not support executing replicated commands")
+	}
+
+	handler := &replicationHandler{
+		m:      m,
+		dest:   dest,
+		filter: newKeyFilter(m.config),
+	}
+
+	err := source.Replicate(ctx, handler)
+	if ctx.Err() != nil {
+		// Stopping is how replication ends, unless the snapshot has not been
+		// loaded completely.
+		if !handler.loaded.Load() {
+			m.metrics.MarkInterrupted()
+			m.addError(ErrInterrupted)
+		}
+	} else if err != nil {
+		m.addError(err)
+	}
+
+	return errors.Join(m.GetErrors()...)
+}
+
+// replicationHandler loads snapshots and applies commands for a
+// [Migrator] following a [Replicator].
+type replicationHandler struct {
+	m      *Migrator
+	dest   CommandExecutor
+	filter *keyFilter
+	loaded atomic.Bool
+}
+
+func (h *replicationHandler) AddSnapshotSize(keys int64) {
+	h.m.metrics.AddTotal(keys)
+}
+
+// LoadSnapshot restores the keys matching the configuration like a batch of
+// a migration. Keys that don't match are subtracted from the total.
+func (h *replicationHandler) LoadSnapshot(ctx context.Context, data []KeyData) error {
+	m := h.m
+
+	matched := make([]KeyData, 0, len(data))
+	for _, d := range data {
+		if matchKey(m.config, h.filter, d.Key) {
+			matched = append(matched, d)
+		}
+	}
+	m.metrics.AddTotal(-int64(len(data) - len(matched)))
+
+	var counts outcomeCounts
+
+	renamed := make(renames)
+	keyData, rewriteFailed := m.rewriteKeyData(matched, renamed)
+	keyData, unrestored := m.applyTTLs(keyData, 0)
+
+	results, err := m.dest.RestoreKeys(ctx, keyData, m.config.Conflict)
+	if err != nil {
+		counts[OutcomeFailed] = int64(len(keyData) + len(rewriteFailed))
+		for _, result := range unrestored {
+			counts[result.Outcome]++
+		}
+		m.updateMetrics(counts)
+		return fmt.Errorf("failed to restore keys: %w", err)
+	}
+
+	results = append(results, rewriteFailed...)
+	results = append(results, unrestored...)
+
+	var failed []RestoreResult
+	var conflict *ConflictError
+	for _, result := range results {
+		counts[result.Outcome]++
+
+		switch result.Outcome {
+		case OutcomeConflict:
+			if conflict == nil {
+				conflict = &ConflictError{Key: result.Key}
+			}
+		case OutcomeFailed:
+			failed = append(failed, result)
+		}
+	}
+
+	m.updateMetrics(counts)
+
+	if len(failed) > 0 {
+		m.addError(fmt.Errorf("failed to restore %d key(s), first %q: %w", len(failed), failed[0].Key, failed[0].Err))
+	}
+
+	// A conflict aborts like in a migration, as the commands following the
+	// snapshot would be applied to a different value.
+	if conflict != nil {
+		return conflict
+	}
+	return nil
+}
+
+func (h *replicationHandler) SnapshotLoaded() {
+	h.loaded.Store(true)
+	h.m.metrics.MarkFollowing()
+}
+
+// Apply executes the commands touching matching keys on the destination,
+// with their keys rewritten. Commands touching both matching and other keys
+// can't be replicated faithfully, and fail.
+func (h *replicationHandler) Apply(ctx context.Context, cmds []Command, received time.Time) error {
+	m := h.m
+
+	var apply []Command
+	var failed []error
+
+	for _, cmd := range cmds {
+		if len(cmd.Keys) == 0 {
+			// Flushing can't be limited to the keys of the migration.
+			switch cmd.Name() {
+			case "FLUSHALL", "FLUSHDB", "SWAPDB":
+				failed = append(failed, fmt.Errorf("%s is not replicated", cmd.Name()))
+			}
+			continue
+		}
+
+		var matching int
+		for _, i := range cmd.Keys {
+			if matchKey(m.config, h.filter, cmd.Args[i]) {
+				matching++
+			}
+		}
+
+		switch {
+		case matching == 0:
+			continue
+		case matching < len(cmd.Keys):
+			failed = append(failed, fmt.Errorf("%s touches keys both matching and not matching the migration, and is not replicated", cmd.Name()))
+			continue
+		}
+
+		rewritten, err := m.rewriteCommand(cmd)
+		if err != nil {
+			failed = append(failed, err)
+			continue
+		}
+		apply = append(apply, rewritten)
+	}
+
+	if len(apply) > 0 {
+		errs, err := h.dest.ExecCommands(ctx, apply)
+		if err != nil {
+			return fmt.Errorf("failed to apply replicated commands: %w", err)
+		}
+
+		applied := len(apply)
+		for i, err := range errs {
+			if err != nil {
+				failed = append(failed, fmt.Errorf("%s: %w", apply[i].Name(), err))
+				applied--
+			}
+		}
+		m.metrics.AddFollowEvents(int64(applied))
+	}
+
+	m.metrics.SetFollowLag(time.Since(received))
+
+	if len(failed) > 0 {
+		m.metrics.AddFailed(int64(len(failed)))
+		m.addError(fmt.Errorf("failed to replicate %d command(s), first: %w", len(failed), failed[0]))
+	}
+
+	return nil
+}
+
+// rewriteCommand returns a copy of cmd with its keys rewritten.
+func (m *Migrator) rewriteCommand(cmd Command) (Command, error) {
+	if len(m.config.Rewrites) == 0 {
+		return cmd, nil
+	}
+
+	args := append([]string(nil), cmd.Args...)
+	for _, i := range cmd.Keys {
+		name, err := rewriteKey(m.config.Rewrites, args[i])
+		if err != nil {
+			return Command{}, err
+		}
+		args[i] = name
+	}
+
+	return Command{Args: args, Keys: cmd.Keys}, nil
+}
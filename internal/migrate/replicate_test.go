@@ -0,0 +1,199 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/stats"
+)
+
+// replicaSource wraps a memoryClient as a replicated source: its keys are the
+// snapshot, followed by cmds.
+type replicaSource struct {
+	*memoryClient
+	cmds []Command
+}
+
+func (c *replicaSource) Replicate(ctx context.Context, handler ReplicationHandler) error {
+	var snapshot []KeyData
+	for _, key := range c.sortedKeys("*") {
+		snapshot = append(snapshot, c.keys[key])
+	}
+
+	handler.AddSnapshotSize(int64(len(snapshot)))
+	if err := handler.LoadSnapshot(ctx, snapshot); err != nil {
+		return err
+	}
+	handler.SnapshotLoaded()
+
+	if err := handler.Apply(ctx, c.cmds, time.Now()); err != nil {
+		return err
+	}
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// execClient wraps a memoryClient executing SET and DEL commands.
+type execClient struct {
+	*memoryClient
+	executed [][]string
+}
+
+func (c *execClient) ExecCommands(_ context.Context, cmds []Command) ([]error, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	errs := make([]error, len(cmds))
+	for i, cmd := range cmds {
+		c.executed = append(c.executed, cmd.Args)
+
+		switch cmd.Name() {
+		case "SET":
+			c.keys[cmd.Args[1]] = KeyData{Key: cmd.Args[1], Data: cmd.Args[2]}
+		case "DEL":
+			for _, key := range cmd.Args[1:] {
+				delete(c.keys, key)
+			}
+		default:
+			errs[i] = fmt.Errorf("unknown command %q", cmd.Args[0])
+		}
+	}
+	return errs, nil
+}
+
+func replicateConfig() Config {
+	config := followConfig()
+	config.Replicate = true
+	return config
+}
+
+func TestFollow_Replicate(t *testing.T) {
+	source := &replicaSource{
+		memoryClient: newMemoryClient("user:1", "user:2", "order:1"),
+		cmds: []Command{
+			{Args: []string{"SET", "user:3", "created"}, Keys: []int{1}},
+			{Args: []string{"SET", "order:2", "not matching"}, Keys: []int{1}},
+			{Args: []string{"DEL", "user:1", "user:2"}, Keys: []int{1, 2}},
+			{Args: []string{"PUBLISH", "channel", "message"}},
+		},
+	}
+	dest := &execClient{memoryClient: newMemoryClient()}
+	metrics := stats.NewMetrics()
+
+	config := replicateConfig()
+	config.Rewrites = []KeyRewrite{NewPrefixRewrite("user:", "account:")}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewMigrator(source, dest, config, metrics).Follow(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return metrics.GetFollowEvents() == 2
+	}, time.Second, time.Millisecond)
+
+	assert.True(t, metrics.IsFollowing())
+	assert.Equal(t, int64(2), metrics.GetTotalKeys(), "keys not matching are not counted")
+	assert.Equal(t, int64(2), metrics.GetProcessedKeys())
+	assert.Equal(t, []string{"account:3"}, dest.sortedKeys("*"))
+	assert.Equal(t, [][]string{
+		{"SET", "account:3", "created"},
+		{"DEL", "account:1", "account:2"},
+	}, dest.executed)
+	assert.Equal(t, []string{"SET", "user:3", "created"}, source.cmds[0].Args, "commands are rewritten as copies")
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestFollow_ReplicateRejectsCommands(t *testing.T) {
+	source := &replicaSource{
+		memoryClient: newMemoryClient("user:1"),
+		cmds: []Command{
+			{Args: []string{"RENAME", "user:1", "order:1"}, Keys: []int{1, 2}},
+			{Args: []string{"FLUSHDB"}},
+			{Args: []string{"INCR", "user:1"}, Keys: []int{1}},
+			{Args: []string{"SET", "user:2", "created"}, Keys: []int{1}},
+		},
+	}
+	dest := &execClient{memoryClient: newMemoryClient()}
+	metrics := stats.NewMetrics()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewMigrator(source, dest, replicateConfig(), metrics).Follow(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return metrics.GetFollowEvents() == 1
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, int64(3), metrics.GetFailedKeys())
+	assert.Equal(t, []string{"user:1", "user:2"}, dest.sortedKeys("*"))
+
+	cancel()
+	err := <-done
+	assert.ErrorContains(t, err, "failed to replicate 3 command(s)")
+	assert.ErrorContains(t, err, "RENAME touches keys both matching and not matching the migration")
+}
+
+func TestFollow_ReplicateInterruptedSnapshot(t *testing.T) {
+	source := &blockingReplicaSource{memoryClient: newMemoryClient()}
+	dest := &execClient{memoryClient: newMemoryClient()}
+	metrics := stats.NewMetrics()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := NewMigrator(source, dest, replicateConfig(), metrics).Follow(ctx)
+	assert.ErrorIs(t, err, ErrInterrupted)
+	assert.True(t, metrics.IsInterrupted())
+}
+
+// blockingReplicaSource never completes its snapshot.
+type blockingReplicaSource struct {
+	*memoryClient
+}
+
+func (c *blockingReplicaSource) Replicate(ctx context.Context, _ ReplicationHandler) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestFollow_ReplicateUnsupported(t *testing.T) {
+	source := newMemoryClient()
+	dest := &execClient{memoryClient: newMemoryClient()}
+
+	err := NewMigrator(source, dest, replicateConfig(), stats.NewMetrics()).Follow(context.Background())
+	assert.EqualError(t, err, "source does not support replication")
+
+	err = NewMigrator(&replicaSource{memoryClient: source}, newMemoryClient(), replicateConfig(), stats.NewMetrics()).Follow(context.Background())
+	assert.EqualError(t, err, "destination does not support executing replicated commands")
+}
+
+func TestFollow_ReplicateFailure(t *testing.T) {
+	source := &failingReplicaSource{memoryClient: newMemoryClient(), err: errors.New("connection reset")}
+	dest := &execClient{memoryClient: newMemoryClient()}
+
+	err := NewMigrator(source, dest, replicateConfig(), stats.NewMetrics()).Follow(context.Background())
+	assert.EqualError(t, err, "connection reset")
+}
+
+// failingReplicaSource fails right away.
+type failingReplicaSource struct {
+	*memoryClient
+	err error
+}
+
+func (c *failingReplicaSource) Replicate(context.Context, ReplicationHandler) error {
+	return c.err
+}
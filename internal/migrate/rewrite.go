@@ -0,0 +1,203 @@
+package migrate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// KeyRewrite renames keys on their way into the destination. Rewrites are
+// created with [NewPrefixRewrite], [NewRegexRewrite] or [NewTemplateRewrite].
+type KeyRewrite struct {
+	spec  string
+	apply func(key string) (string, bool, error)
+}
+
+// NewPrefixRewrite replaces the prefix from of a key with to.
+func NewPrefixRewrite(from, to string) KeyRewrite {
+	return KeyRewrite{
+		spec: fmt.Sprintf("prefix %s=%s", from, to),
+		apply: func(key string) (string, bool, error) {
+			rest, ok := strings.CutPrefix(key, from)
+			if !ok {
+				return key, false, nil
+			}
+			return to + rest, true, nil
+		},
+	}
+}
+
+// NewRegexRewrite replaces the matches of expr in a key with replacement, in
+// which $1 or ${name} refer to capture groups as in
+// [regexp.Regexp.ReplaceAllString].
+func NewRegexRewrite(expr, replacement string) (KeyRewrite, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return KeyRewrite{}, fmt.Errorf("invalid rewrite expression %q: %w", expr, err)
+	}
+
+	return KeyRewrite{
+		spec: fmt.Sprintf("regex %s=%s", expr, replacement),
+		apply: func(key string) (string, bool, error) {
+			if !re.MatchString(key) {
+				return key, false, nil
+			}
+			return re.ReplaceAllString(key, replacement), true, nil
+		},
+	}, nil
+}
+
+// templateData is the data a rewrite template is executed with.
+type templateData struct {
+	// Key is the name of the key in the source.
+	Key string
+
+	// Parts are the segments of Key separated by colons.
+	Parts []string
+}
+
+// templateFuncs are the functions available in rewrite templates.
+var templateFuncs = template.FuncMap{
+	"replace":    strings.ReplaceAll,
+	"trimPrefix": strings.TrimPrefix,
+	"trimSuffix": strings.TrimSuffix,
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"join":       func(sep string, parts []string) string { return strings.Join(parts, sep) },
+}
+
+// NewTemplateRewrite renames every key with a [text/template], executed with
+// the key as .Key and its colon-separated segments as .Parts, e.g.
+// "legacy:{{.Key}}" or "{{index .Parts 1}}:{{index .Parts 0}}". The functions
+// replace, trimPrefix, trimSuffix, upper, lower and join are available.
+func NewTemplateRewrite(text string) (KeyRewrite, error) {
+	tmpl, err := template.New("rewrite").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return KeyRewrite{}, fmt.Errorf("invalid rewrite template %q: %w", text, err)
+	}
+
+	return KeyRewrite{
+		spec: "template " + text,
+		apply: func(key string) (string, bool, error) {
+			var b strings.Builder
+			if err := tmpl.Execute(&b, templateData{Key: key, Parts: strings.Split(key, ":")}); err != nil {
+				return "", false, err
+			}
+			return b.String(), true, nil
+		},
+	}, nil
+}
+
+// ParseKeyRewrite parses the definition of a rewrite of the given kind:
+// "from=to" for prefix, "expression=replacement" for regex, and the template
+// text for template. Definitions are split at the first '='.
+func ParseKeyRewrite(kind, spec string) (KeyRewrite, error) {
+	if kind == "template" {
+		return NewTemplateRewrite(spec)
+	}
+
+	from, to, ok := strings.Cut(spec, "=")
+	if !ok || from == "" {
+		return KeyRewrite{}, fmt.Errorf("invalid %s rewrite: %s (must be 'from=to')", kind, spec)
+	}
+
+	switch kind {
+	case "prefix":
+		return NewPrefixRewrite(from, to), nil
+	case "regex":
+		return NewRegexRewrite(from, to)
+	default:
+		return KeyRewrite{}, fmt.Errorf("invalid rewrite kind: %s (must be 'prefix', 'regex' or 'template')", kind)
+	}
+}
+
+// String returns the kind and definition of the rewrite.
+func (r KeyRewrite) String() string {
+	return r.spec
+}
+
+// rewriteKey applies the first of rewrites that matches key. Keys not matched
+// by any rewrite keep their name.
+func rewriteKey(rewrites []KeyRewrite, key string) (string, error) {
+	for _, rewrite := range rewrites {
+		rewritten, ok, err := rewrite.apply(key)
+		if err != nil {
+			return "", fmt.Errorf("failed to rewrite key %q with %s: %w", key, rewrite, err)
+		}
+		if !ok {
+			continue
+		}
+		if rewritten == "" {
+			return "", fmt.Errorf("rewriting key %q with %s results in an empty name", key, rewrite)
+		}
+		return rewritten, nil
+	}
+
+	return key, nil
+}
+
+// renames maps the destination names of the keys of a batch to their source
+// names. It is only filled if rewrites are configured.
+type renames map[string]string
+
+// source returns the source name of the destination key key.
+func (r renames) source(key string) string {
+	if source, ok := r[key]; ok {
+		return source
+	}
+	return key
+}
+
+// rewriteKeys returns the destination names of keys and records them in
+// renamed. Keys that can't be rewritten, or whose new name collides with
+// another key of the batch, have an empty name and are returned as failed.
+func (m *Migrator) rewriteKeys(keys []string, renamed renames) ([]string, []RestoreResult) {
+	if len(m.config.Rewrites) == 0 {
+		return keys, nil
+	}
+
+	names := make([]string, len(keys))
+	var failed []RestoreResult
+
+	for i, key := range keys {
+		name, err := rewriteKey(m.config.Rewrites, key)
+		if other, ok := renamed[name]; err == nil && ok {
+			err = fmt.Errorf("key %q is rewritten to %q like %q", key, name, other)
+		}
+		if err != nil {
+			failed = append(failed, RestoreResult{Key: key, Outcome: OutcomeFailed, Err: err})
+			continue
+		}
+
+		renamed[name] = key
+		names[i] = name
+	}
+
+	return names, failed
+}
+
+// rewriteKeyData renames data with [Migrator.rewriteKeys], and returns the
+// keys that have been renamed successfully along with the failed ones.
+func (m *Migrator) rewriteKeyData(data []KeyData, renamed renames) ([]KeyData, []RestoreResult) {
+	if len(m.config.Rewrites) == 0 {
+		return data, nil
+	}
+
+	keys := make([]string, len(data))
+	for i, d := range data {
+		keys[i] = d.Key
+	}
+
+	names, failed := m.rewriteKeys(keys, renamed)
+
+	rewritten := make([]KeyData, 0, len(data))
+	for i, d := range data {
+		if names[i] != "" {
+			d.Key = names[i]
+			rewritten = append(rewritten, d)
+		}
+	}
+
+	return rewritten, failed
+}
@@ -0,0 +1,168 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/stats"
+)
+
+func TestRewriteKey(t *testing.T) {
+	regex, err := NewRegexRewrite(`^user:(\d+):(?P<field>\w+)$`, "account:$1:${field}")
+	require.NoError(t, err)
+
+	template, err := NewTemplateRewrite(`{{index .Parts 1}}:{{upper (index .Parts 0)}}`)
+	require.NoError(t, err)
+
+	rewrites := []KeyRewrite{
+		NewPrefixRewrite("tenantA:", "legacy:tenantA:"),
+		regex,
+		template,
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"tenantA:session:1", "legacy:tenantA:session:1"},
+		{"user:42:name", "account:42:name"},
+		{"cache:home", "home:CACHE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := rewriteKey(rewrites, tt.key)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// Keys matched by no rewrite keep their name.
+	got, err := rewriteKey(rewrites[:2], "other")
+	require.NoError(t, err)
+	assert.Equal(t, "other", got)
+
+	// The template fails for keys without a second segment.
+	_, err = rewriteKey(rewrites, "single")
+	assert.ErrorContains(t, err, `failed to rewrite key "single"`)
+}
+
+func TestNewRewrite_Invalid(t *testing.T) {
+	_, err := NewRegexRewrite("(", "x")
+	assert.Error(t, err)
+
+	_, err = NewTemplateRewrite("{{.Key")
+	assert.Error(t, err)
+
+	template, err := NewTemplateRewrite(`{{trimPrefix "x" .Key}}`)
+	require.NoError(t, err)
+	_, err = rewriteKey([]KeyRewrite{template}, "x")
+	assert.ErrorContains(t, err, "empty name")
+}
+
+func TestParseKeyRewrite(t *testing.T) {
+	tests := []struct {
+		kind, spec string
+		key, want  string
+		wantErr    bool
+	}{
+		{kind: "prefix", spec: "tenantA:=legacy:tenantA:", key: "tenantA:1", want: "legacy:tenantA:1"},
+		{kind: "prefix", spec: "tenantA:=", key: "tenantA:1", want: "1"},
+		{kind: "regex", spec: `^(\w+):(\d+)$=$2:$1`, key: "user:1", want: "1:user"},
+		{kind: "template", spec: "legacy:{{.Key}}", key: "user:1", want: "legacy:user:1"},
+		{kind: "prefix", spec: "no separator", wantErr: true},
+		{kind: "regex", spec: "=x", wantErr: true},
+		{kind: "suffix", spec: "a=b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind+" "+tt.spec, func(t *testing.T) {
+			rewrite, err := ParseKeyRewrite(tt.kind, tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := rewriteKey([]KeyRewrite{rewrite}, tt.key)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMigrate_Rewrite(t *testing.T) {
+	source := newMemoryClient("tenantA:1", "tenantA:2", "tenantA:3", "other")
+	dest := newMemoryClient("legacy:tenantA:2")
+	metrics := stats.NewMetrics()
+
+	config := testConfig(MoveMode, SkipOnConflict)
+	config.Rewrites = []KeyRewrite{NewPrefixRewrite("tenantA:", "legacy:tenantA:")}
+
+	err := NewMigrator(source, dest, config, metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"legacy:tenantA:1", "legacy:tenantA:2", "legacy:tenantA:3", "other"}, dest.sortedKeys("*"))
+	assert.Equal(t, "payload:tenantA:1", dest.keys["legacy:tenantA:1"].Data)
+	assert.Equal(t, int64(1), metrics.GetSkippedKeys())
+
+	// Moved keys are deleted under their source names, the key skipped
+	// because of its rewritten name stays.
+	assert.Equal(t, []string{"tenantA:2"}, source.sortedKeys("*"))
+}
+
+func TestMigrate_RewriteConflict(t *testing.T) {
+	source := newMemoryClient("tenantA:1")
+	dest := newMemoryClient("tenantA:1", "legacy:tenantA:1")
+
+	config := testConfig(CopyMode, ErrorOnConflict)
+	config.Rewrites = []KeyRewrite{NewPrefixRewrite("tenantA:", "legacy:tenantA:")}
+
+	err := NewMigrator(source, dest, config, stats.NewMetrics()).Migrate(context.Background())
+
+	var conflict *ConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "legacy:tenantA:1", conflict.Key)
+}
+
+func TestMigrate_RewriteCollision(t *testing.T) {
+	source := newMemoryClient("a:1", "b:1")
+	dest := newMemoryClient()
+	metrics := stats.NewMetrics()
+
+	template, err := NewTemplateRewrite(`merged:{{index .Parts 1}}`)
+	require.NoError(t, err)
+
+	config := testConfig(MoveMode, OverwriteOnConflict)
+	config.Rewrites = []KeyRewrite{template}
+
+	err = NewMigrator(source, dest, config, metrics).Migrate(context.Background())
+
+	assert.ErrorContains(t, err, `is rewritten to "merged:1" like "a:1"`)
+	assert.Equal(t, int64(1), metrics.GetSuccessfulKeys())
+	assert.Equal(t, int64(1), metrics.GetFailedKeys())
+	assert.Equal(t, []string{"b:1"}, source.sortedKeys("*"))
+}
+
+func TestVerify_Rewrite(t *testing.T) {
+	source := newMemoryClient("tenantA:1", "tenantA:2", "tenantA:3")
+	dest := newMemoryClient()
+	dest.keys["legacy:tenantA:1"] = KeyData{Key: "legacy:tenantA:1", Data: "payload:tenantA:1"}
+	dest.keys["legacy:tenantA:2"] = KeyData{Key: "legacy:tenantA:2", Data: "changed"}
+
+	config := verifyConfig()
+	config.Rewrites = []KeyRewrite{NewPrefixRewrite("tenantA:", "legacy:tenantA:")}
+
+	report, err := NewVerifier(source, dest, config, stats.NewMetrics()).Verify(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), report.Checked)
+	assert.Equal(t, int64(1), report.Matched)
+	assert.Equal(t, []Discrepancy{
+		{Key: "tenantA:2", Kind: DiscrepancyValue, Detail: `destination key "legacy:tenantA:2"`},
+		{Key: "tenantA:3", Kind: DiscrepancyMissing, Detail: `destination key "legacy:tenantA:3"`},
+	}, report.Samples)
+}
@@ -0,0 +1,73 @@
+package migrate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSOptions configures the TLS connection to a single Redis endpoint.
+type TLSOptions struct {
+	// CAFile is the path to a PEM bundle of certificate authorities used to
+	// verify the server certificate. The system pool is used when empty.
+	CAFile string
+
+	// CertFile and KeyFile are the paths to a PEM client certificate and key
+	// presented for mutual TLS. Both or neither must be set.
+	CertFile string
+	KeyFile  string
+
+	// ServerName overrides the name used for SNI and certificate verification.
+	ServerName string
+
+	// InsecureSkipVerify disables verification of the server certificate.
+	InsecureSkipVerify bool
+}
+
+// Enabled reports whether any TLS option has been set.
+func (o TLSOptions) Enabled() bool {
+	return o != TLSOptions{}
+}
+
+// Config loads the referenced files and builds a [tls.Config]. It returns nil
+// if no option has been set.
+func (o TLSOptions) Config() (*tls.Config, error) {
+	if !o.Enabled() {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", o.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return nil, errors.New("client certificate and key must be provided together")
+	}
+
+	if o.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
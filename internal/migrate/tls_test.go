@@ -0,0 +1,109 @@
+package migrate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertificate writes a self-signed certificate and its key to dir.
+func writeCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "redis.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	return certFile, keyFile
+}
+
+func TestTLSOptions_Config(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir)
+
+	garbage := filepath.Join(dir, "garbage.pem")
+	require.NoError(t, os.WriteFile(garbage, []byte("not a certificate"), 0600))
+
+	tests := []struct {
+		name    string
+		opts    TLSOptions
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", opts: TLSOptions{}, wantNil: true},
+		{name: "insecure only", opts: TLSOptions{InsecureSkipVerify: true}},
+		{name: "server name only", opts: TLSOptions{ServerName: "redis.test"}},
+		{name: "ca file", opts: TLSOptions{CAFile: certFile}},
+		{name: "mutual tls", opts: TLSOptions{CAFile: certFile, CertFile: certFile, KeyFile: keyFile}},
+		{name: "missing ca file", opts: TLSOptions{CAFile: filepath.Join(dir, "missing.pem")}, wantErr: true},
+		{name: "invalid ca file", opts: TLSOptions{CAFile: garbage}, wantErr: true},
+		{name: "certificate without key", opts: TLSOptions{CertFile: certFile}, wantErr: true},
+		{name: "key without certificate", opts: TLSOptions{KeyFile: keyFile}, wantErr: true},
+		{name: "invalid key pair", opts: TLSOptions{CertFile: certFile, KeyFile: garbage}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := tt.opts.Config()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			if tt.wantNil {
+				assert.Nil(t, config)
+				return
+			}
+
+			require.NotNil(t, config)
+			assert.Equal(t, tt.opts.ServerName, config.ServerName)
+			assert.Equal(t, tt.opts.InsecureSkipVerify, config.InsecureSkipVerify)
+			assert.Equal(t, tt.opts.CAFile != "", config.RootCAs != nil)
+			assert.Equal(t, tt.opts.CertFile != "", len(config.Certificates) == 1)
+		})
+	}
+}
+
+func TestConfig_ValidateTLS(t *testing.T) {
+	config := Config{
+		SourceURL: "rediss://source:6379/0",
+		DestURL:   "rediss://dest:6379/0",
+		SourceTLS: TLSOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		DestTLS:   TLSOptions{CertFile: "client.pem"},
+	}
+
+	err := config.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid source TLS configuration")
+	assert.Contains(t, err.Error(), "invalid destination TLS configuration")
+}
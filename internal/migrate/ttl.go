@@ -0,0 +1,141 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ExpiredTTL is the TTL of a [KeyData] whose key expired, or was deleted,
+// after its value had been read. Such keys are reported as
+// [OutcomeExpired] instead of being restored.
+const ExpiredTTL time.Duration = -2
+
+// TTLPolicy adjusts the TTLs of keys on their way into the destination. The
+// zero value keeps every TTL as it is.
+type TTLPolicy struct {
+	// MinRemaining leaves out keys whose remaining TTL is below it, as they
+	// would expire shortly after being copied. They are reported as
+	// [OutcomeDropped]. Keys without expiry are always copied.
+	MinRemaining time.Duration
+
+	// Fixed sets the TTL of every key, including keys without expiry.
+	Fixed time.Duration
+
+	// Offset is added to the TTL of keys that expire.
+	Offset time.Duration
+
+	// Max caps the TTL of every key. Keys without expiry get the maximum TTL
+	// as well.
+	Max time.Duration
+
+	// Persist removes the TTL of every key.
+	Persist bool
+}
+
+// validate checks that the policy is consistent.
+func (p TTLPolicy) validate() error {
+	var errs []error
+
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"minimum remaining TTL", p.MinRemaining},
+		{"fixed TTL", p.Fixed},
+		{"TTL offset", p.Offset},
+		{"maximum TTL", p.Max},
+	} {
+		if d.value < 0 {
+			errs = append(errs, fmt.Errorf("invalid %s: %s (must not be negative)", d.name, d.value))
+		}
+	}
+
+	if p.Persist && (p.Fixed > 0 || p.Offset > 0 || p.Max > 0) {
+		errs = append(errs, errors.New("invalid TTL policy: persistent keys can't have a fixed, offset or maximum TTL"))
+	}
+	if p.Fixed > 0 && p.Offset > 0 {
+		errs = append(errs, errors.New("invalid TTL policy: a fixed TTL can't be combined with an offset"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// apply returns the TTL to restore a key with, given its remaining TTL in the
+// source, and false if the key is to be dropped because it expires too soon.
+func (p TTLPolicy) apply(ttl time.Duration) (time.Duration, bool) {
+	if ttl > 0 && ttl < p.MinRemaining {
+		return 0, false
+	}
+
+	switch {
+	case p.Persist:
+		return 0, true
+	case p.Fixed > 0:
+		ttl = p.Fixed
+	case ttl > 0:
+		ttl += p.Offset
+	}
+
+	if p.Max > 0 && (ttl <= 0 || ttl > p.Max) {
+		ttl = p.Max
+	}
+
+	return ttl, true
+}
+
+// adjusts reports whether the policy sets or caps TTLs, so that the TTL in the
+// destination counts down from a value unrelated to the source.
+func (p TTLPolicy) adjusts() bool {
+	return p.Persist || p.Fixed > 0 || p.Max > 0
+}
+
+// missingKeys returns keys that are not part of data as [OutcomeExpired]
+// results, as sources omit keys that no longer exist when they are read.
+func missingKeys(keys []string, data []KeyData) []RestoreResult {
+	read := make(map[string]bool, len(data))
+	for _, d := range data {
+		read[d.Key] = true
+	}
+
+	var results []RestoreResult
+	for _, key := range keys {
+		if !read[key] {
+			results = append(results, RestoreResult{Key: key, Outcome: OutcomeExpired})
+		}
+	}
+	return results
+}
+
+// applyTTLs adjusts the TTLs of data according to [Config.TTL], after
+// subtracting the time elapsed since they have been read. Keys that expired in
+// the meantime, or that expire too soon for the policy, are returned as
+// results instead of being kept.
+func (m *Migrator) applyTTLs(data []KeyData, elapsed time.Duration) ([]KeyData, []RestoreResult) {
+	kept := make([]KeyData, 0, len(data))
+	var results []RestoreResult
+
+	for _, d := range data {
+		if d.TTL > 0 {
+			d.TTL -= elapsed
+			if d.TTL <= 0 {
+				d.TTL = ExpiredTTL
+			}
+		}
+		if d.TTL == ExpiredTTL {
+			results = append(results, RestoreResult{Key: d.Key, Outcome: OutcomeExpired})
+			continue
+		}
+
+		ttl, ok := m.config.TTL.apply(d.TTL)
+		if !ok {
+			results = append(results, RestoreResult{Key: d.Key, Outcome: OutcomeDropped})
+			continue
+		}
+
+		d.TTL = ttl
+		kept = append(kept, d)
+	}
+
+	return kept, results
+}
@@ -0,0 +1,146 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/stats"
+)
+
+func TestTTLPolicy_Apply(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy TTLPolicy
+		ttl    time.Duration
+		want   time.Duration
+		wantOK bool
+	}{
+		{"unchanged", TTLPolicy{}, time.Hour, time.Hour, true},
+		{"no expiry", TTLPolicy{}, 0, 0, true},
+		{"below minimum", TTLPolicy{MinRemaining: time.Minute}, 30 * time.Second, 0, false},
+		{"above minimum", TTLPolicy{MinRemaining: time.Minute}, time.Hour, time.Hour, true},
+		{"no expiry ignores minimum", TTLPolicy{MinRemaining: time.Minute}, 0, 0, true},
+		{"fixed", TTLPolicy{Fixed: time.Hour}, time.Minute, time.Hour, true},
+		{"fixed without expiry", TTLPolicy{Fixed: time.Hour}, 0, time.Hour, true},
+		{"offset", TTLPolicy{Offset: time.Hour}, time.Minute, time.Hour + time.Minute, true},
+		{"offset without expiry", TTLPolicy{Offset: time.Hour}, 0, 0, true},
+		{"capped", TTLPolicy{Max: time.Hour}, 2 * time.Hour, time.Hour, true},
+		{"below cap", TTLPolicy{Max: time.Hour}, time.Minute, time.Minute, true},
+		{"capped without expiry", TTLPolicy{Max: time.Hour}, 0, time.Hour, true},
+		{"offset then capped", TTLPolicy{Offset: time.Hour, Max: 90 * time.Minute}, time.Hour, 90 * time.Minute, true},
+		{"persist", TTLPolicy{Persist: true}, time.Hour, 0, true},
+		{"minimum before persist", TTLPolicy{MinRemaining: time.Minute, Persist: true}, time.Second, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.policy.apply(tt.ttl)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTTLPolicy_Validate(t *testing.T) {
+	assert.NoError(t, TTLPolicy{MinRemaining: time.Minute, Offset: time.Hour, Max: 24 * time.Hour}.validate())
+	assert.NoError(t, TTLPolicy{MinRemaining: time.Minute, Persist: true}.validate())
+
+	assert.ErrorContains(t, TTLPolicy{Fixed: -time.Hour}.validate(), "invalid fixed TTL")
+	assert.ErrorContains(t, TTLPolicy{Persist: true, Max: time.Hour}.validate(), "persistent keys")
+	assert.ErrorContains(t, TTLPolicy{Fixed: time.Hour, Offset: time.Hour}.validate(), "can't be combined with an offset")
+}
+
+func TestMigrate_TTLPolicy(t *testing.T) {
+	source := newMemoryClient("persistent")
+	source.keys["expiring"] = KeyData{Key: "expiring", Data: "payload:expiring", TTL: time.Hour}
+	source.keys["soon"] = KeyData{Key: "soon", Data: "payload:soon", TTL: 10 * time.Second}
+	dest := newMemoryClient()
+	metrics := stats.NewMetrics()
+
+	config := testConfig(MoveMode, ErrorOnConflict)
+	config.TTL = TTLPolicy{MinRemaining: time.Minute, Max: 30 * time.Minute}
+
+	err := NewMigrator(source, dest, config, metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"expiring", "persistent"}, dest.sortedKeys("*"))
+	assert.InDelta(t, 30*time.Minute, dest.keys["expiring"].TTL, float64(time.Second))
+	assert.Equal(t, 30*time.Minute, dest.keys["persistent"].TTL)
+
+	assert.Equal(t, int64(1), metrics.GetDroppedKeys())
+	assert.Equal(t, int64(0), metrics.GetFailedKeys())
+	assert.Equal(t, 1.0, metrics.GetProgress())
+
+	// Dropped keys are not migrated, so they stay in the source until they
+	// expire.
+	assert.Equal(t, []string{"soon"}, source.sortedKeys("*"))
+}
+
+func TestMigrate_ExpiredDuringMigration(t *testing.T) {
+	source := newMemoryClient("key:1")
+	source.keys["key:2"] = KeyData{Key: "key:2", Data: "payload:key:2", TTL: ExpiredTTL}
+	dest := newMemoryClient()
+	metrics := stats.NewMetrics()
+
+	err := NewMigrator(source, dest, testConfig(CopyMode, ErrorOnConflict), metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"key:1"}, dest.sortedKeys("*"))
+	assert.Equal(t, int64(1), metrics.GetExpiredKeys())
+	assert.Equal(t, int64(1), metrics.GetSuccessfulKeys())
+	assert.Equal(t, int64(0), metrics.GetFailedKeys())
+	assert.Equal(t, int64(2), metrics.GetProcessedKeys())
+}
+
+// vanishingClient wraps a memoryClient and deletes keys right before a dump,
+// like keys expiring between SCAN and DUMP.
+type vanishingClient struct {
+	*memoryClient
+	vanish []string
+}
+
+func (c *vanishingClient) DumpKeys(ctx context.Context, keys []string) ([]KeyData, error) {
+	_ = c.memoryClient.DeleteKeys(ctx, c.vanish)
+	return c.memoryClient.DumpKeys(ctx, keys)
+}
+
+func TestMigrate_DeletedBeforeDump(t *testing.T) {
+	source := &vanishingClient{memoryClient: newMemoryClient("key:1", "key:2"), vanish: []string{"key:2"}}
+	dest := newMemoryClient()
+	metrics := stats.NewMetrics()
+
+	err := NewMigrator(source, dest, testConfig(CopyMode, ErrorOnConflict), metrics).Migrate(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"key:1"}, dest.sortedKeys("*"))
+	assert.Equal(t, int64(1), metrics.GetExpiredKeys())
+	assert.Equal(t, int64(1), metrics.GetSuccessfulKeys())
+	assert.Equal(t, 1.0, metrics.GetProgress())
+}
+
+func TestVerify_TTLPolicy(t *testing.T) {
+	source := newMemoryClient("persistent")
+	source.keys["soon"] = KeyData{Key: "soon", Data: "payload:soon", TTL: 10 * time.Second}
+	dest := newMemoryClient()
+	dest.keys["persistent"] = KeyData{Key: "persistent", Data: "payload:persistent", TTL: 20 * time.Hour}
+
+	config := verifyConfig()
+	config.TTL = TTLPolicy{MinRemaining: time.Minute, Fixed: 24 * time.Hour}
+
+	report, err := NewVerifier(source, dest, config, stats.NewMetrics()).Verify(context.Background())
+
+	require.NoError(t, err)
+	assert.True(t, report.OK(), "fixed TTLs count down in the destination, and dropped keys are not missing")
+	assert.Equal(t, int64(1), report.Matched)
+
+	dest.keys["persistent"] = KeyData{Key: "persistent", Data: "payload:persistent"}
+
+	report, err = NewVerifier(source, dest, config, stats.NewMetrics()).Verify(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.TTLMismatches)
+}
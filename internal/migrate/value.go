@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrValuesUnavailable is returned when the destination is a [ValueWriter],
+// but the source can't read values with native commands. It aborts the
+// migration.
+var ErrValuesUnavailable = errors.New("the destination requires values read with native commands, which the source can't read")
+
+// ValueReader is implemented by clients that can read values with native
+// commands instead of DUMP. It is used to copy keys whose DUMP payload the
+// destination rejects with [ErrIncompatiblePayload], and to read keys for a
+// [ValueWriter].
+type ValueReader interface {
+	// ReadValues reads the value and TTL of multiple keys. Keys that don't
+	// exist, or whose type can't be read natively, are omitted.
+	ReadValues(ctx context.Context, keys []string) ([]KeyData, error)
+}
+
+// ValueWriter is implemented by destinations that only store values read
+// with native commands, like readable exports. Keys are read with the
+// [ValueReader] of the source instead of DUMP for them.
+type ValueWriter interface {
+	// RequiresValues reports whether keys have to be restored with their
+	// Value rather than a DUMP payload.
+	RequiresValues() bool
+}
+
+// DataTypes are the Redis types a [Value] can hold, which can be copied
+// natively and in chunks.
+var DataTypes = map[string]bool{
+	"string": true,
+	"hash":   true,
+	"list":   true,
+	"set":    true,
+	"zset":   true,
+	"stream": true,
+}
+
+// Value is the value of a key, independent of any serialization format.
+// Only the field matching Type is set.
+type Value struct {
+	// Type is the Redis type of the value: string, hash, list, set, zset or
+	// stream.
+	Type string
+
+	String string
+	Hash   map[string]string
+	List   []string
+	Set    []string
+	ZSet   []ScoredMember
+	Stream []StreamEntry
+}
+
+// ScoredMember is a member of a sorted set.
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// StreamEntry is an entry of a stream.
+type StreamEntry struct {
+	ID string
+
+	// Fields holds the field-value pairs of the entry in order.
+	Fields []string
+}
@@ -0,0 +1,479 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pucke-dev/go-redismigrate/internal/stats"
+)
+
+const (
+	// DefaultTTLTolerance is the default maximum difference between the TTLs
+	// of a key in source and destination.
+	DefaultTTLTolerance = 5 * time.Second
+
+	// maxVerifySamples is the number of discrepancies listed in a report.
+	maxVerifySamples = 20
+
+	// dumpFooterSize is the size of the RDB version and CRC64 checksum that
+	// end every DUMP payload.
+	dumpFooterSize = 10
+)
+
+// Digester is implemented by clients that can digest values independently of
+// their serialization, to compare keys whose DUMP payloads differ only in
+// encoding, e.g. between Redis versions or configurations.
+type Digester interface {
+	// DigestKeys returns a digest of the type and value of multiple keys.
+	// Keys that don't exist, or whose type can't be digested, are omitted.
+	DigestKeys(ctx context.Context, keys []string) (map[string]string, error)
+}
+
+// DiscrepancyKind classifies a difference between source and destination.
+type DiscrepancyKind int
+
+const (
+	// DiscrepancyMissing is a source key that doesn't exist in the destination.
+	DiscrepancyMissing DiscrepancyKind = iota
+	// DiscrepancyExtra is a destination key that doesn't exist in the source.
+	DiscrepancyExtra
+	// DiscrepancyValue is a key whose values differ.
+	DiscrepancyValue
+	// DiscrepancyTTL is a key whose TTLs differ by more than the tolerance.
+	DiscrepancyTTL
+)
+
+// String returns the string representation of the discrepancy kind.
+func (k DiscrepancyKind) String() string {
+	switch k {
+	case DiscrepancyMissing:
+		return "missing"
+	case DiscrepancyExtra:
+		return "extra"
+	case DiscrepancyValue:
+		return "value mismatch"
+	case DiscrepancyTTL:
+		return "TTL mismatch"
+	default:
+		return "unknown"
+	}
+}
+
+// Discrepancy is a key that differs between source and destination.
+type Discrepancy struct {
+	Key    string
+	Kind   DiscrepancyKind
+	Detail string
+}
+
+// VerifyReport is the result of [Verifier.Verify].
+type VerifyReport struct {
+	// Checked is the number of source keys compared with the destination.
+	Checked int64
+
+	// Matched is the number of source keys with an identical value and TTL
+	// in the destination.
+	Matched int64
+
+	Missing         int64
+	Extra           int64
+	ValueMismatches int64
+	TTLMismatches   int64
+
+	// Samples lists the first discrepancies in lexical order of their keys.
+	Samples []Discrepancy
+}
+
+// OK reports whether source and destination match.
+func (r VerifyReport) OK() bool {
+	return r.Missing == 0 && r.Extra == 0 && r.ValueMismatches == 0 && r.TTLMismatches == 0
+}
+
+// Verifier compares the keys matching a pattern between source and
+// destination.
+type Verifier struct {
+	source  RedisClient
+	dest    RedisClient
+	config  Config
+	metrics *stats.Metrics
+
+	mu     sync.Mutex
+	report VerifyReport
+}
+
+// NewVerifier creates a verifier. Progress is tracked in metrics, counting
+// matching keys as successful and discrepancies as failed.
+func NewVerifier(source, dest RedisClient, config Config, metrics *stats.Metrics) *Verifier {
+	return &Verifier{
+		source:  source,
+		dest:    dest,
+		config:  config,
+		metrics: metrics,
+	}
+}
+
+// Verify scans the source and compares every key with the destination, then
+// scans the destination for keys missing in the source.
+//
+// Values are compared by their DUMP payloads first. If those differ and both
+// clients implement [Digester], the values are compared by digest instead.
+//
+// With key rewrites, source keys are compared with the keys they have been
+// rewritten to. The destination is not scanned for extra keys then, as the
+// pattern selects source keys only.
+//
+// Cancelling ctx stops the verification and returns [ErrInterrupted] along
+// with the partial report.
+func (v *Verifier) Verify(ctx context.Context) (VerifyReport, error) {
+	opts := ScanOptions{
+		Pattern:   v.config.Pattern,
+		BatchSize: v.config.BatchSize,
+		Types:     v.config.Types,
+	}
+
+	clients := []RedisClient{v.source, v.dest}
+	if len(v.config.Rewrites) > 0 {
+		clients = clients[:1]
+	}
+
+	var total int64
+	for _, client := range clients {
+		count, err := countKeys(ctx, client, opts, newKeyFilter(v.config))
+		if ctx.Err() != nil {
+			v.metrics.MarkInterrupted()
+			return v.snapshot(), ErrInterrupted
+		}
+		if err != nil {
+			return v.snapshot(), fmt.Errorf("failed to count keys: %w", err)
+		}
+		total += count
+	}
+	v.metrics.SetTotal(total)
+
+	if err := v.run(ctx, v.source, opts, v.compareBatch); err != nil {
+		return v.snapshot(), err
+	}
+
+	if len(clients) > 1 {
+		if err := v.run(ctx, v.dest, opts, v.findExtra); err != nil {
+			return v.snapshot(), err
+		}
+	}
+
+	return v.snapshot(), nil
+}
+
+// run scans client and processes every batch with fn on concurrent workers.
+func (v *Verifier) run(parent context.Context, client RedisClient, opts ScanOptions, fn func(ctx context.Context, keys []string) error) error {
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	batchesChan := make(chan KeyBatch, v.config.Concurrency*2)
+
+	var wg sync.WaitGroup
+
+	wg.Add(v.config.Concurrency)
+	for range v.config.Concurrency {
+		go func() {
+			defer wg.Done()
+			for batch := range batchesChan {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := fn(ctx, batch.Keys); err != nil {
+					cancel(err)
+				}
+			}
+		}()
+	}
+
+	err := scanKeys(ctx, client, opts, newKeyFilter(v.config), batchesChan)
+	close(batchesChan)
+	wg.Wait()
+
+	if parent.Err() != nil {
+		v.metrics.MarkInterrupted()
+		return ErrInterrupted
+	}
+
+	// A failed batch stops the scan, so its error takes precedence.
+	if cause := context.Cause(ctx); cause != nil {
+		return cause
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to scan keys: %w", err)
+	}
+
+	return nil
+}
+
+// compareBatch compares a batch of source keys with the destination.
+func (v *Verifier) compareBatch(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	sourceData, err := v.source.DumpKeys(ctx, keys)
+	if err != nil {
+		return fmt.Errorf("failed to dump source keys: %w", err)
+	}
+
+	// Keys deleted from the source since the scan are not compared, and
+	// neither are keys that expired since or are dropped by the TTL policy.
+	// The others are expected with the TTL the policy gives them.
+	expected := make(map[string]time.Duration, len(sourceData))
+	compared := sourceData[:0]
+	for _, data := range sourceData {
+		ttl, ok := v.config.TTL.apply(data.TTL)
+		if data.TTL == ExpiredTTL || !ok {
+			continue
+		}
+		expected[data.Key] = ttl
+		compared = append(compared, data)
+	}
+	sourceData = compared
+
+	v.metrics.AddProcessed(int64(len(keys) - len(sourceData)))
+
+	// names maps the source keys to their names in the destination.
+	names := make(map[string]string, len(sourceData))
+	var present []string
+	for _, data := range sourceData {
+		name, err := rewriteKey(v.config.Rewrites, data.Key)
+		if err != nil {
+			v.addDiscrepancy(Discrepancy{Key: data.Key, Kind: DiscrepancyMissing, Detail: err.Error()})
+			continue
+		}
+		names[data.Key] = name
+		present = append(present, name)
+	}
+
+	destData, err := v.dest.DumpKeys(ctx, present)
+	if err != nil {
+		return fmt.Errorf("failed to dump destination keys: %w", err)
+	}
+
+	destByKey := make(map[string]KeyData, len(destData))
+	for _, data := range destData {
+		destByKey[data.Key] = data
+	}
+
+	var differing []string
+	for _, data := range sourceData {
+		dest, ok := destByKey[names[data.Key]]
+		if ok && dumpBody(data.Data) != dumpBody(dest.Data) {
+			differing = append(differing, data.Key)
+		}
+	}
+
+	equal, err := v.digestsEqual(ctx, differing, names)
+	if err != nil {
+		return err
+	}
+
+	for _, data := range sourceData {
+		// Keys that failed to be rewritten have been reported already.
+		name, rewritten := names[data.Key]
+		if !rewritten {
+			continue
+		}
+
+		// Rewritten keys name their destination key in the detail.
+		var details []string
+		if name != data.Key {
+			details = append(details, fmt.Sprintf("destination key %q", name))
+		}
+
+		dest, ok := destByKey[name]
+		switch {
+		case !ok:
+			v.addDiscrepancy(Discrepancy{Key: data.Key, Kind: DiscrepancyMissing, Detail: strings.Join(details, ", ")})
+		case dumpBody(data.Data) != dumpBody(dest.Data) && !equal[data.Key]:
+			v.addDiscrepancy(Discrepancy{Key: data.Key, Kind: DiscrepancyValue, Detail: strings.Join(details, ", ")})
+		case !v.ttlMatches(expected[data.Key], dest.TTL):
+			if expected[data.Key] != data.TTL {
+				details = append(details, fmt.Sprintf("source %s, expected %s, destination %s", formatTTL(data.TTL), formatTTL(expected[data.Key]), formatTTL(dest.TTL)))
+			} else {
+				details = append(details, fmt.Sprintf("source %s, destination %s", formatTTL(data.TTL), formatTTL(dest.TTL)))
+			}
+			v.addDiscrepancy(Discrepancy{Key: data.Key, Kind: DiscrepancyTTL, Detail: strings.Join(details, ", ")})
+		default:
+			v.addMatch()
+		}
+	}
+
+	return nil
+}
+
+// digestsEqual compares the digests of source keys with those of their names
+// in the destination.
+func (v *Verifier) digestsEqual(ctx context.Context, keys []string, names map[string]string) (map[string]bool, error) {
+	sourceDigester, sourceOK := v.source.(Digester)
+	destDigester, destOK := v.dest.(Digester)
+	if len(keys) == 0 || !sourceOK || !destOK {
+		return nil, nil
+	}
+
+	sourceDigests, err := sourceDigester.DigestKeys(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to digest source keys: %w", err)
+	}
+
+	destKeys := make([]string, len(keys))
+	for i, key := range keys {
+		destKeys[i] = names[key]
+	}
+
+	destDigests, err := destDigester.DigestKeys(ctx, destKeys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to digest destination keys: %w", err)
+	}
+
+	equal := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		digest, ok := sourceDigests[key]
+		equal[key] = ok && digest == destDigests[names[key]]
+	}
+
+	return equal, nil
+}
+
+// findExtra reports the destination keys of a batch that don't exist in the
+// source. Keys that do exist have been compared by the source pass already.
+func (v *Verifier) findExtra(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	exists, err := existingKeys(ctx, v.source, keys)
+	if err != nil {
+		return fmt.Errorf("failed to check source keys: %w", err)
+	}
+
+	var found int64
+	for i, key := range keys {
+		if exists[i] {
+			found++
+			continue
+		}
+		v.addDiscrepancy(Discrepancy{Key: key, Kind: DiscrepancyExtra})
+	}
+
+	v.metrics.AddProcessed(found)
+	v.metrics.AddSuccess(found)
+
+	return nil
+}
+
+// existingKeys reports for every key whether it exists in client, using
+// [Inspector] if implemented and DUMP otherwise.
+func existingKeys(ctx context.Context, client RedisClient, keys []string) ([]bool, error) {
+	if inspector, ok := client.(Inspector); ok {
+		return inspector.ExistingKeys(ctx, keys)
+	}
+
+	data, err := client.DumpKeys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	exists := make([]bool, len(keys))
+	for i, key := range keys {
+		exists[i] = slices.ContainsFunc(data, func(d KeyData) bool { return d.Key == key })
+	}
+	return exists, nil
+}
+
+func (v *Verifier) addMatch() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.report.Checked++
+	v.report.Matched++
+	v.metrics.AddProcessed(1)
+	v.metrics.AddSuccess(1)
+}
+
+func (v *Verifier) addDiscrepancy(d Discrepancy) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	switch d.Kind {
+	case DiscrepancyMissing:
+		v.report.Missing++
+	case DiscrepancyExtra:
+		v.report.Extra++
+	case DiscrepancyValue:
+		v.report.ValueMismatches++
+	case DiscrepancyTTL:
+		v.report.TTLMismatches++
+	}
+
+	if d.Kind != DiscrepancyExtra {
+		v.report.Checked++
+	}
+
+	v.metrics.AddProcessed(1)
+	v.metrics.AddFailed(1)
+
+	i, _ := slices.BinarySearchFunc(v.report.Samples, d.Key, func(d Discrepancy, key string) int {
+		return strings.Compare(d.Key, key)
+	})
+	if i < maxVerifySamples {
+		v.report.Samples = slices.Insert(v.report.Samples, i, d)
+		if len(v.report.Samples) > maxVerifySamples {
+			v.report.Samples = v.report.Samples[:maxVerifySamples]
+		}
+	}
+}
+
+func (v *Verifier) snapshot() VerifyReport {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	report := v.report
+	report.Samples = slices.Clone(v.report.Samples)
+	return report
+}
+
+// dumpBody strips the RDB version and checksum from a DUMP payload, which
+// differ between servers even for identical values.
+func dumpBody(payload string) string {
+	if len(payload) < dumpFooterSize {
+		return payload
+	}
+	return payload[:len(payload)-dumpFooterSize]
+}
+
+// ttlMatches reports whether the TTL of a key in the destination matches the
+// TTL expected by the TTL policy. TTLs set or capped by the policy have been
+// counting down since the key was copied, so they only must not exceed the
+// expected TTL.
+func (v *Verifier) ttlMatches(expected, dest time.Duration) bool {
+	tolerance := v.config.VerifyTTLTolerance
+	if !v.config.TTL.adjusts() || expected <= 0 || dest <= 0 {
+		return ttlsMatch(expected, dest, tolerance)
+	}
+	return dest <= expected+tolerance
+}
+
+// ttlsMatch reports whether two TTLs as returned by PTTL are equal within
+// tolerance. Non-positive TTLs denote keys without expiry.
+func ttlsMatch(a, b, tolerance time.Duration) bool {
+	if a <= 0 || b <= 0 {
+		return (a <= 0) == (b <= 0)
+	}
+	return (a - b).Abs() <= tolerance
+}
+
+func formatTTL(ttl time.Duration) string {
+	if ttl <= 0 {
+		return "no expiry"
+	}
+	return ttl.Round(time.Millisecond).String()
+}
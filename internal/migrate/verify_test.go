@@ -0,0 +1,149 @@
+package migrate
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/stats"
+)
+
+func verifyConfig() Config {
+	config := testConfig(CopyMode, ErrorOnConflict)
+	config.VerifyTTLTolerance = DefaultTTLTolerance
+	return config
+}
+
+func TestVerify_AfterCopy(t *testing.T) {
+	keys := numberedKeys("key", 95)
+	source := newMemoryClient(keys...)
+	dest := newMemoryClient()
+
+	require.NoError(t, NewMigrator(source, dest, verifyConfig(), stats.NewMetrics()).Migrate(context.Background()))
+
+	metrics := stats.NewMetrics()
+	report, err := NewVerifier(source, dest, verifyConfig(), metrics).Verify(context.Background())
+
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Equal(t, VerifyReport{Checked: 95, Matched: 95}, report)
+	assert.Equal(t, int64(190), metrics.GetTotalKeys())
+	assert.Equal(t, int64(190), metrics.GetProcessedKeys())
+	assert.Zero(t, metrics.GetFailedKeys())
+}
+
+func TestVerify_Discrepancies(t *testing.T) {
+	// The last 10 bytes of a payload are the RDB version and checksum.
+	footer := strings.Repeat("0", dumpFooterSize)
+	otherFooter := strings.Repeat("1", dumpFooterSize)
+
+	source := newMemoryClient()
+	dest := newMemoryClient()
+	for key, data := range map[string][2]KeyData{
+		"identical":   {{Data: "a" + footer}, {Data: "a" + footer}},
+		"footer":      {{Data: "a" + footer}, {Data: "a" + otherFooter}},
+		"missing":     {{Data: "a" + footer}},
+		"value":       {{Data: "a" + footer}, {Data: "b" + footer}},
+		"ttl":         {{Data: "a" + footer, TTL: time.Hour}, {Data: "a" + footer}},
+		"ttl-drift":   {{Data: "a" + footer, TTL: time.Hour}, {Data: "a" + footer, TTL: time.Hour - time.Second}},
+		"ttl-expired": {{Data: "a" + footer, TTL: time.Hour}, {Data: "a" + footer, TTL: time.Minute}},
+	} {
+		data[0].Key = key
+		source.keys[key] = data[0]
+		if data[1].Data != "" {
+			data[1].Key = key
+			dest.keys[key] = data[1]
+		}
+	}
+	dest.keys["extra"] = KeyData{Key: "extra", Data: "a" + footer}
+
+	metrics := stats.NewMetrics()
+	report, err := NewVerifier(source, dest, verifyConfig(), metrics).Verify(context.Background())
+
+	require.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Equal(t, int64(7), report.Checked)
+	assert.Equal(t, int64(3), report.Matched)
+	assert.Equal(t, int64(1), report.Missing)
+	assert.Equal(t, int64(1), report.Extra)
+	assert.Equal(t, int64(1), report.ValueMismatches)
+	assert.Equal(t, int64(2), report.TTLMismatches)
+	assert.Equal(t, []Discrepancy{
+		{Key: "extra", Kind: DiscrepancyExtra},
+		{Key: "missing", Kind: DiscrepancyMissing},
+		{Key: "ttl", Kind: DiscrepancyTTL, Detail: "source 1h0m0s, destination no expiry"},
+		{Key: "ttl-expired", Kind: DiscrepancyTTL, Detail: "source 1h0m0s, destination 1m0s"},
+		{Key: "value", Kind: DiscrepancyValue},
+	}, report.Samples)
+	assert.Equal(t, int64(5), metrics.GetFailedKeys())
+}
+
+// digestClient wraps a memoryClient with fixed digests.
+type digestClient struct {
+	*memoryClient
+	digests map[string]string
+}
+
+func (c *digestClient) DigestKeys(_ context.Context, keys []string) (map[string]string, error) {
+	digests := make(map[string]string)
+	for _, key := range keys {
+		if digest, ok := c.digests[key]; ok {
+			digests[key] = digest
+		}
+	}
+	return digests, nil
+}
+
+func TestVerify_DigestFallback(t *testing.T) {
+	source := &digestClient{
+		memoryClient: newMemoryClient("reencoded", "changed"),
+		digests:      map[string]string{"reencoded": "1", "changed": "2"},
+	}
+	dest := &digestClient{
+		memoryClient: newMemoryClient(),
+		digests:      map[string]string{"reencoded": "1", "changed": "3"},
+	}
+	for _, key := range []string{"reencoded", "changed"} {
+		dest.keys[key] = KeyData{Key: key, Data: "other encoding of " + key}
+	}
+
+	report, err := NewVerifier(source, dest, verifyConfig(), stats.NewMetrics()).Verify(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.Matched)
+	assert.Equal(t, []Discrepancy{{Key: "changed", Kind: DiscrepancyValue}}, report.Samples)
+}
+
+func TestVerify_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	metrics := stats.NewMetrics()
+	_, err := NewVerifier(newMemoryClient("key"), newMemoryClient(), verifyConfig(), metrics).Verify(ctx)
+
+	assert.ErrorIs(t, err, ErrInterrupted)
+	assert.True(t, metrics.IsInterrupted())
+}
+
+func TestTTLsMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b time.Duration
+		want bool
+	}{
+		{"both persistent", -1, -1, true},
+		{"persistent and expiring", -1, time.Hour, false},
+		{"within tolerance", time.Hour, time.Hour - time.Second, true},
+		{"beyond tolerance", time.Hour, time.Hour - time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ttlsMatch(tt.a, tt.b, 5*time.Second))
+		})
+	}
+}
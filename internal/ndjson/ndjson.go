@@ -0,0 +1,250 @@
+// Package ndjson reads and writes keys as newline-delimited JSON, a readable
+// format to inspect, edit and diff keys, or to load fixtures.
+//
+// Every line holds a key, its type, its TTL in seconds if it expires, and its
+// value:
+//
+//	{"key":"greeting","type":"string","value":"hello"}
+//	{"key":"user:1","type":"hash","ttl":3600,"value":{"name":"Ada"}}
+//	{"key":"queue","type":"list","value":["a","b"]}
+//	{"key":"tags","type":"set","value":["a","b"]}
+//	{"key":"scores","type":"zset","value":[{"member":"a","score":1.5}]}
+//	{"key":"events","type":"stream","value":[{"id":"1-0","fields":["f","v"]}]}
+//
+// Members of sets are sorted, and infinite scores of sorted sets are written
+// as "inf" and "-inf". As JSON strings are text, keys and values have to be
+// valid UTF-8.
+package ndjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+// ErrBinary is returned when a key or value that isn't valid UTF-8 is
+// written, as it can't be represented by a JSON string.
+var ErrBinary = errors.New("binary data can't be written as JSON")
+
+// Record is a key of an NDJSON file.
+type Record struct {
+	Key string
+
+	// TTL is the time to live of the key, or 0 if it doesn't expire.
+	TTL time.Duration
+
+	Value *migrate.Value
+}
+
+// line is the JSON representation of a [Record].
+type line struct {
+	Key   string          `json:"key"`
+	Type  string          `json:"type"`
+	TTL   float64         `json:"ttl,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+type member struct {
+	Member string `json:"member"`
+	Score  score  `json:"score"`
+}
+
+type entry struct {
+	ID     string   `json:"id"`
+	Fields []string `json:"fields"`
+}
+
+// score is the score of a sorted set member, written as a string if it's
+// infinite.
+type score float64
+
+func (s score) MarshalJSON() ([]byte, error) {
+	switch {
+	case math.IsInf(float64(s), 1):
+		return []byte(`"inf"`), nil
+	case math.IsInf(float64(s), -1):
+		return []byte(`"-inf"`), nil
+	}
+	return json.Marshal(float64(s))
+}
+
+func (s *score) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return json.Unmarshal(data, (*float64)(s))
+	}
+
+	switch text {
+	case "inf", "+inf":
+		*s = score(math.Inf(1))
+	case "-inf":
+		*s = score(math.Inf(-1))
+	default:
+		return fmt.Errorf("invalid score %q", text)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the record as a line of an NDJSON file, without the
+// newline.
+func (r Record) MarshalJSON() ([]byte, error) {
+	if r.Value == nil {
+		return nil, errors.New("record has no value")
+	}
+	if !utf8.ValidString(r.Key) || !validValue(r.Value) {
+		return nil, ErrBinary
+	}
+
+	var value any
+	switch r.Value.Type {
+	case "string":
+		value = r.Value.String
+	case "hash":
+		value = r.Value.Hash
+		if r.Value.Hash == nil {
+			value = map[string]string{}
+		}
+	case "list":
+		value = orEmpty(r.Value.List)
+	case "set":
+		value = slices.Sorted(slices.Values(orEmpty(r.Value.Set)))
+	case "zset":
+		members := make([]member, len(r.Value.ZSet))
+		for i, m := range r.Value.ZSet {
+			members[i] = member{Member: m.Member, Score: score(m.Score)}
+		}
+		value = members
+	case "stream":
+		entries := make([]entry, len(r.Value.Stream))
+		for i, e := range r.Value.Stream {
+			entries[i] = entry{ID: e.ID, Fields: orEmpty(e.Fields)}
+		}
+		value = entries
+	default:
+		return nil, fmt.Errorf("unsupported type %q", r.Value.Type)
+	}
+
+	raw, err := marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	l := line{Key: r.Key, Type: r.Value.Type, Value: raw}
+	if r.TTL > 0 {
+		l.TTL = r.TTL.Round(time.Millisecond).Seconds()
+	}
+	return marshal(l)
+}
+
+// UnmarshalJSON decodes a line of an NDJSON file.
+func (r *Record) UnmarshalJSON(data []byte) error {
+	var l line
+	if err := json.Unmarshal(data, &l); err != nil {
+		return err
+	}
+	if l.Key == "" {
+		return errors.New("missing key")
+	}
+	if l.TTL < 0 {
+		return fmt.Errorf("invalid TTL %v of key %q", l.TTL, l.Key)
+	}
+	if len(l.Value) == 0 {
+		return fmt.Errorf("missing value of key %q", l.Key)
+	}
+
+	value := &migrate.Value{Type: l.Type}
+	var err error
+	switch l.Type {
+	case "string":
+		err = json.Unmarshal(l.Value, &value.String)
+	case "hash":
+		err = json.Unmarshal(l.Value, &value.Hash)
+	case "list":
+		err = json.Unmarshal(l.Value, &value.List)
+	case "set":
+		err = json.Unmarshal(l.Value, &value.Set)
+	case "zset":
+		var members []member
+		err = json.Unmarshal(l.Value, &members)
+		for _, m := range members {
+			value.ZSet = append(value.ZSet, migrate.ScoredMember{Member: m.Member, Score: float64(m.Score)})
+		}
+	case "stream":
+		var entries []entry
+		err = json.Unmarshal(l.Value, &entries)
+		for _, e := range entries {
+			if len(e.Fields)%2 != 0 {
+				return fmt.Errorf("entry %s of key %q has a field without a value", e.ID, l.Key)
+			}
+			value.Stream = append(value.Stream, migrate.StreamEntry{ID: e.ID, Fields: e.Fields})
+		}
+	default:
+		return fmt.Errorf("unsupported type %q of key %q", l.Type, l.Key)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s value of key %q: %w", l.Type, l.Key, err)
+	}
+
+	*r = Record{
+		Key:   l.Key,
+		TTL:   time.Duration(l.TTL * float64(time.Second)),
+		Value: value,
+	}
+	return nil
+}
+
+// marshal encodes v as JSON without escaping HTML characters, which keeps
+// values readable.
+func marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// orEmpty returns an empty slice for nil, so that it's written as [].
+func orEmpty(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// validValue reports whether all strings of a value are valid UTF-8.
+func validValue(v *migrate.Value) bool {
+	valid := func(strings ...string) bool {
+		for _, s := range strings {
+			if !utf8.ValidString(s) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for field, value := range v.Hash {
+		if !valid(field, value) {
+			return false
+		}
+	}
+	for _, m := range v.ZSet {
+		if !valid(m.Member) {
+			return false
+		}
+	}
+	for _, e := range v.Stream {
+		if !valid(e.ID) || !valid(e.Fields...) {
+			return false
+		}
+	}
+	return valid(v.String) && valid(v.List...) && valid(v.Set...)
+}
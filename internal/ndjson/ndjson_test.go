@@ -0,0 +1,145 @@
+package ndjson
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+func TestRecord_RoundTrip(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		record Record
+		line   string
+	}{
+		{
+			name:   "string",
+			record: Record{Key: "greeting", Value: &migrate.Value{Type: "string", String: "<hello>"}},
+			line:   `{"key":"greeting","type":"string","value":"<hello>"}`,
+		},
+		{
+			name:   "hash",
+			record: Record{Key: "user:1", TTL: 90 * time.Second, Value: &migrate.Value{Type: "hash", Hash: map[string]string{"name": "Ada", "age": "36"}}},
+			line:   `{"key":"user:1","type":"hash","ttl":90,"value":{"age":"36","name":"Ada"}}`,
+		},
+		{
+			name:   "list",
+			record: Record{Key: "queue", TTL: 1500 * time.Millisecond, Value: &migrate.Value{Type: "list", List: []string{"b", "a"}}},
+			line:   `{"key":"queue","type":"list","ttl":1.5,"value":["b","a"]}`,
+		},
+		{
+			name:   "set",
+			record: Record{Key: "tags", Value: &migrate.Value{Type: "set", Set: []string{"a", "b"}}},
+			line:   `{"key":"tags","type":"set","value":["a","b"]}`,
+		},
+		{
+			name: "zset",
+			record: Record{Key: "scores", Value: &migrate.Value{Type: "zset", ZSet: []migrate.ScoredMember{
+				{Member: "low", Score: math.Inf(-1)},
+				{Member: "mid", Score: 1.5},
+				{Member: "high", Score: math.Inf(1)},
+			}}},
+			line: `{"key":"scores","type":"zset","value":[{"member":"low","score":"-inf"},{"member":"mid","score":1.5},{"member":"high","score":"inf"}]}`,
+		},
+		{
+			name: "stream",
+			record: Record{Key: "events", Value: &migrate.Value{Type: "stream", Stream: []migrate.StreamEntry{
+				{ID: "1-0", Fields: []string{"f", "v", "f", "w"}},
+			}}},
+			line: `{"key":"events","type":"stream","value":[{"id":"1-0","fields":["f","v","f","w"]}]}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			line, err := tt.record.MarshalJSON()
+			require.NoError(t, err)
+			assert.Equal(t, tt.line, string(line))
+
+			var record Record
+			require.NoError(t, record.UnmarshalJSON(line))
+			assert.Equal(t, tt.record, record)
+		})
+	}
+}
+
+func TestRecord_Normalizes(t *testing.T) {
+	line, err := Record{Key: "tags", Value: &migrate.Value{Type: "set", Set: []string{"b", "c", "a"}}}.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, `{"key":"tags","type":"set","value":["a","b","c"]}`, string(line), "members of sets are sorted")
+
+	line, err = Record{Key: "empty", Value: &migrate.Value{Type: "list"}}.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, `{"key":"empty","type":"list","value":[]}`, string(line))
+}
+
+func TestRecord_Errors(t *testing.T) {
+	_, err := Record{Key: "bin\xff", Value: &migrate.Value{Type: "string"}}.MarshalJSON()
+	assert.ErrorIs(t, err, ErrBinary)
+
+	_, err = Record{Key: "hash", Value: &migrate.Value{Type: "hash", Hash: map[string]string{"f": "\xfe"}}}.MarshalJSON()
+	assert.ErrorIs(t, err, ErrBinary)
+
+	_, err = Record{Key: "module", Value: &migrate.Value{Type: "ReJSON-RL"}}.MarshalJSON()
+	assert.ErrorContains(t, err, "unsupported type")
+
+	for line, want := range map[string]string{
+		`{"type":"string","value":"x"}`:                                     "missing key",
+		`{"key":"a","type":"string"}`:                                       "missing value",
+		`{"key":"a","type":"string","ttl":-1,"value":"x"}`:                  "invalid TTL",
+		`{"key":"a","type":"json","value":"x"}`:                             "unsupported type",
+		`{"key":"a","type":"hash","value":["x"]}`:                           "invalid hash value",
+		`{"key":"a","type":"zset","value":[{"member":"m","score":"high"}]}`: "invalid score",
+		`{"key":"a","type":"stream","value":[{"id":"1-0","fields":["f"]}]}`: "without a value",
+		`{"key":`: "unexpected end",
+	} {
+		var record Record
+		assert.ErrorContains(t, record.UnmarshalJSON([]byte(line)), want, line)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewWriter(&buf)
+	assert.True(t, writer.RequiresValues())
+
+	results, err := writer.RestoreKeys(context.Background(), []migrate.KeyData{
+		{Key: "a", Value: &migrate.Value{Type: "string", String: "1"}},
+		{Key: "b", TTL: time.Minute, Value: &migrate.Value{Type: "list", List: []string{"x"}}},
+		{Key: "c", Data: "payload"},
+		{Key: "d", Value: &migrate.Value{Type: "string", String: "\xff"}},
+	}, migrate.ErrorOnConflict)
+	require.NoError(t, err)
+	assert.Equal(t, migrate.OutcomeCreated, results[0].Outcome)
+	assert.Equal(t, migrate.OutcomeCreated, results[1].Outcome)
+	assert.Equal(t, migrate.OutcomeFailed, results[2].Outcome, "DUMP payloads")
+	assert.Equal(t, migrate.OutcomeFailed, results[3].Outcome)
+	assert.ErrorIs(t, results[3].Err, ErrBinary)
+
+	for behavior, outcome := range map[migrate.ConflictBehavior]migrate.Outcome{
+		migrate.ErrorOnConflict:     migrate.OutcomeConflict,
+		migrate.SkipOnConflict:      migrate.OutcomeSkipped,
+		migrate.OverwriteOnConflict: migrate.OutcomeFailed,
+	} {
+		results, err := writer.RestoreKeys(context.Background(), []migrate.KeyData{
+			{Key: "a", Value: &migrate.Value{Type: "string", String: "2"}},
+		}, behavior)
+		require.NoError(t, err)
+		assert.Equal(t, outcome, results[0].Outcome, behavior.String())
+	}
+
+	assert.Equal(t, int64(2), writer.Count())
+	require.NoError(t, writer.Close())
+
+	assert.Equal(t, strings.Join([]string{
+		`{"key":"a","type":"string","value":"1"}`,
+		`{"key":"b","type":"list","ttl":60,"value":["x"]}`,
+		``,
+	}, "\n"), buf.String())
+}
@@ -0,0 +1,160 @@
+package ndjson
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+// Source reads the keys of an NDJSON file. It implements
+// [migrate.RedisClient] as the source of a migration, [migrate.ValueReader]
+// and [migrate.Inspector] for dry runs. The keys are restored with their
+// values, as there are no DUMP payloads. A key found on several lines is only
+// migrated from the first of them.
+type Source struct {
+	*migrate.FileKeys[location]
+
+	f *os.File
+}
+
+// location is where the line of a key was found in the file.
+type location struct {
+	offset int64
+	number int
+}
+
+// Open opens an NDJSON file.
+func Open(path string) (*Source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open NDJSON file: %w", err)
+	}
+	return &Source{FileKeys: migrate.NewFileKeys[location](), f: f}, nil
+}
+
+// read returns a reader of the records of the file matching a scan, with the
+// offsets of their line and of the line following it. Blank lines are
+// skipped.
+func (s *Source) read(ctx context.Context, opts migrate.ScanOptions) migrate.FileReader[location] {
+	return func(yield func(key string, loc location, offset, next int64) error) error {
+		r := bufio.NewReader(io.NewSectionReader(s.f, 0, math.MaxInt64))
+
+		var offset int64
+		for number := 1; ; number++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			data, err := r.ReadBytes('\n')
+			if err != nil && err != io.EOF {
+				return fmt.Errorf("failed to read NDJSON file: %w", err)
+			}
+			if len(data) == 0 {
+				return nil
+			}
+			next := offset + int64(len(data))
+
+			if len(bytes.TrimSpace(data)) > 0 {
+				var record Record
+				if err := record.UnmarshalJSON(data); err != nil {
+					return fmt.Errorf("line %d: %w", number, err)
+				}
+				if matches(&record, opts) {
+					if err := yield(record.Key, location{offset: offset, number: number}, offset, next); err != nil {
+						return err
+					}
+				}
+			}
+			offset = next
+		}
+	}
+}
+
+// ScanKeys reads the keys of the file in order. Lines before the cursor of a
+// resumed scan are read as well, so that errors report their line number.
+func (s *Source) ScanKeys(ctx context.Context, opts migrate.ScanOptions, batches chan<- migrate.KeyBatch) error {
+	return s.Scan(ctx, opts, batches, s.read(ctx, opts))
+}
+
+// matches reports whether a record matches the pattern and types of a scan.
+func matches(record *Record, opts migrate.ScanOptions) bool {
+	if !migrate.MatchPattern(opts.Pattern, record.Key) {
+		return false
+	}
+	return len(opts.Types) == 0 || slices.Contains(opts.Types, record.Value.Type)
+}
+
+// CountKeys reads the file to count the keys matching the scan, which
+// validates every line before the migration starts.
+func (s *Source) CountKeys(ctx context.Context, opts migrate.ScanOptions) (int64, error) {
+	return s.Count(s.read(ctx, opts))
+}
+
+// record reads the record of a key scanned before, if any.
+func (s *Source) record(key string) (*Record, int, error) {
+	loc, ok := s.Location(key)
+	if !ok {
+		return nil, 0, nil
+	}
+
+	r := bufio.NewReader(io.NewSectionReader(s.f, loc.offset, math.MaxInt64-loc.offset))
+	data, err := r.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return nil, 0, fmt.Errorf("failed to read NDJSON file: %w", err)
+	}
+
+	var record Record
+	if err := record.UnmarshalJSON(data); err != nil {
+		return nil, 0, fmt.Errorf("line %d: %w", loc.number, err)
+	}
+	return &record, len(data), nil
+}
+
+// DumpKeys returns the values of keys scanned before. The TTL of a key is
+// the one of its line, independent of the time since the file was written.
+func (s *Source) DumpKeys(_ context.Context, keys []string) ([]migrate.KeyData, error) {
+	var data []migrate.KeyData
+	for _, key := range keys {
+		record, _, err := s.record(key)
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			data = append(data, migrate.KeyData{Key: key, TTL: record.TTL, Value: record.Value})
+		}
+	}
+	return data, nil
+}
+
+// ReadValues implements [migrate.ValueReader], like [Source.DumpKeys].
+func (s *Source) ReadValues(ctx context.Context, keys []string) ([]migrate.KeyData, error) {
+	return s.DumpKeys(ctx, keys)
+}
+
+// InspectKeys returns the type and TTL of keys scanned before, and the length
+// of their line as their memory usage.
+func (s *Source) InspectKeys(_ context.Context, keys []string) ([]migrate.KeyInfo, error) {
+	var infos []migrate.KeyInfo
+	for _, key := range keys {
+		record, size, err := s.record(key)
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			infos = append(infos, migrate.KeyInfo{Key: key, Type: record.Value.Type, Size: int64(size), TTL: record.TTL})
+		}
+	}
+	return infos, nil
+}
+
+// Close closes the NDJSON file.
+func (s *Source) Close() error {
+	return s.f.Close()
+}
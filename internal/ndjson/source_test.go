@@ -0,0 +1,144 @@
+package ndjson
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+// writeFile writes lines to an NDJSON file and returns its path.
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "keys.ndjson")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+// scanAll collects the batches of a scan.
+func scanAll(t *testing.T, source *Source, opts migrate.ScanOptions) ([]migrate.KeyBatch, error) {
+	t.Helper()
+
+	batches := make(chan migrate.KeyBatch, 100)
+	err := source.ScanKeys(context.Background(), opts, batches)
+	close(batches)
+
+	var all []migrate.KeyBatch
+	for batch := range batches {
+		all = append(all, batch)
+	}
+	return all, err
+}
+
+const testLines = `{"key":"user:1","type":"string","value":"one"}
+{"key":"user:2","type":"hash","ttl":3600,"value":{"name":"Ada"}}
+
+{"key":"order:1","type":"list","value":["a"]}
+{"key":"user:3","type":"set","value":["b","a"]}
+{"key":"user:1","type":"string","value":"again"}`
+
+func TestSource_ScanAndDump(t *testing.T) {
+	source, err := Open(writeFile(t, testLines))
+	require.NoError(t, err)
+	defer source.Close()
+
+	count, err := source.CountKeys(context.Background(), migrate.ScanOptions{Pattern: "user:*"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count, "keys on several lines are counted once")
+
+	batches, err := scanAll(t, source, migrate.ScanOptions{Pattern: "user:*", BatchSize: 2})
+	require.NoError(t, err)
+	require.Len(t, batches, 2)
+	assert.Equal(t, []string{"user:1", "user:2"}, batches[0].Keys)
+	assert.Equal(t, batches[0].Next, batches[1].Cursor)
+	assert.Equal(t, []string{"user:3"}, batches[1].Keys, "keys on several lines are scanned once")
+	assert.Empty(t, batches[1].Next)
+
+	data, err := source.DumpKeys(context.Background(), []string{"user:1", "user:2", "user:3", "order:1"})
+	require.NoError(t, err)
+	require.Len(t, data, 3, "keys not scanned are missing")
+
+	assert.Equal(t, migrate.KeyData{Key: "user:1", Value: &migrate.Value{Type: "string", String: "one"}}, data[0], "the first line of a key")
+	assert.Equal(t, migrate.KeyData{Key: "user:2", TTL: time.Hour, Value: &migrate.Value{Type: "hash", Hash: map[string]string{"name": "Ada"}}}, data[1])
+	assert.Equal(t, []string{"b", "a"}, data[2].Value.Set)
+
+	values, err := source.ReadValues(context.Background(), []string{"user:1"})
+	require.NoError(t, err)
+	assert.Equal(t, data[:1], values)
+
+	infos, err := source.InspectKeys(context.Background(), []string{"user:3"})
+	require.NoError(t, err)
+	assert.Equal(t, []migrate.KeyInfo{{Key: "user:3", Type: "set", Size: int64(len(`{"key":"user:3","type":"set","value":["b","a"]}` + "\n"))}}, infos)
+
+	exists, err := source.ExistingKeys(context.Background(), []string{"user:1", "order:1"})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, exists)
+}
+
+func TestSource_Types(t *testing.T) {
+	source, err := Open(writeFile(t, testLines))
+	require.NoError(t, err)
+	defer source.Close()
+
+	batches, err := scanAll(t, source, migrate.ScanOptions{Pattern: "*", BatchSize: 10, Types: []string{"list", "set"}})
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	assert.Equal(t, []string{"order:1", "user:3"}, batches[0].Keys)
+}
+
+func TestSource_Resume(t *testing.T) {
+	source, err := Open(writeFile(t, testLines))
+	require.NoError(t, err)
+	defer source.Close()
+
+	first, err := scanAll(t, source, migrate.ScanOptions{Pattern: "*", BatchSize: 1})
+	require.NoError(t, err)
+	require.Len(t, first, 5)
+
+	resumed, err := scanAll(t, source, migrate.ScanOptions{
+		Pattern:   "*",
+		BatchSize: 1,
+		Resume:    map[string]string{"": first[1].Next},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, first[2:], resumed)
+
+	done, err := scanAll(t, source, migrate.ScanOptions{Pattern: "*", BatchSize: 1, Resume: map[string]string{"": ""}})
+	require.NoError(t, err)
+	assert.Empty(t, done)
+}
+
+func TestSource_InvalidLine(t *testing.T) {
+	source, err := Open(writeFile(t, testLines+"\n{\"key\":\"broken\"\n"))
+	require.NoError(t, err)
+	defer source.Close()
+
+	_, err = source.CountKeys(context.Background(), migrate.ScanOptions{Pattern: "*"})
+	assert.ErrorContains(t, err, "line 7:")
+
+	_, err = Open(filepath.Join(t.TempDir(), "missing.ndjson"))
+	assert.ErrorContains(t, err, "failed to open NDJSON file")
+}
+
+func TestSource_ChangedLine(t *testing.T) {
+	path := writeFile(t, testLines)
+	source, err := Open(path)
+	require.NoError(t, err)
+	defer source.Close()
+
+	_, err = scanAll(t, source, migrate.ScanOptions{Pattern: "*", BatchSize: 10})
+	require.NoError(t, err)
+
+	// The file is truncated after its keys have been scanned.
+	require.NoError(t, os.WriteFile(path, []byte("{}\n"), 0o644))
+
+	_, err = source.DumpKeys(context.Background(), []string{"user:2"})
+	assert.ErrorContains(t, err, "line 2:")
+}
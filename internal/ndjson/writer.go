@@ -0,0 +1,142 @@
+package ndjson
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+// Writer writes keys as NDJSON. It implements [migrate.RedisClient] as the
+// destination of an export, and [migrate.ValueWriter] so that keys are read
+// with native commands: keys restored are appended to the file.
+type Writer struct {
+	migrate.WriteOnly
+
+	w *bufio.Writer
+
+	mu      sync.Mutex
+	written map[string]struct{}
+	count   int64
+	err     error
+}
+
+// NewWriter returns a writer of NDJSON to out.
+func NewWriter(out io.Writer) *Writer {
+	return &Writer{
+		WriteOnly: migrate.WriteOnly{Format: "NDJSON file", Out: out},
+		w:         bufio.NewWriter(out),
+		written:   make(map[string]struct{}),
+	}
+}
+
+// RequiresValues implements [migrate.ValueWriter].
+func (w *Writer) RequiresValues() bool {
+	return true
+}
+
+// Write appends a record to the file.
+func (w *Writer) Write(record Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data, err := record.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return w.write(record.Key, data)
+}
+
+func (w *Writer) write(key string, data []byte) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	if _, err := w.w.Write(append(data, '\n')); err != nil {
+		// The file is unusable after a partial write.
+		w.err = fmt.Errorf("failed to write NDJSON file: %w", err)
+		return w.err
+	}
+
+	w.written[key] = struct{}{}
+	w.count++
+	return nil
+}
+
+// RestoreKeys appends the keys to the file. Keys written before are
+// conflicts, which can't be overwritten in the file.
+func (w *Writer) RestoreKeys(_ context.Context, data []migrate.KeyData, behavior migrate.ConflictBehavior) ([]migrate.RestoreResult, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	results := make([]migrate.RestoreResult, len(data))
+
+	for i, d := range data {
+		results[i].Key = d.Key
+
+		if _, ok := w.written[d.Key]; ok {
+			switch behavior {
+			case migrate.SkipOnConflict:
+				results[i].Outcome = migrate.OutcomeSkipped
+			case migrate.OverwriteOnConflict:
+				results[i].Outcome = migrate.OutcomeFailed
+				results[i].Err = errors.New("key has already been written to the NDJSON file, and can't be overwritten")
+			default:
+				results[i].Outcome = migrate.OutcomeConflict
+			}
+			continue
+		}
+
+		if d.Value == nil {
+			results[i].Outcome = migrate.OutcomeFailed
+			results[i].Err = errors.New("only keys read with native commands can be written as NDJSON")
+			continue
+		}
+
+		line, err := Record{Key: d.Key, TTL: max(d.TTL, 0), Value: d.Value}.MarshalJSON()
+		if err != nil {
+			results[i].Outcome = migrate.OutcomeFailed
+			results[i].Err = err
+			continue
+		}
+
+		if err := w.write(d.Key, line); err != nil {
+			return nil, err
+		}
+		results[i].Outcome = migrate.OutcomeCreated
+	}
+
+	return results, nil
+}
+
+// Count returns the number of keys written so far.
+func (w *Writer) Count() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.count
+}
+
+// Close flushes the file and closes its output.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer func() { w.err = w.ErrClosed() }()
+
+	if w.err != nil {
+		return w.CloseOutput(w.err)
+	}
+	if err := w.w.Flush(); err != nil {
+		return w.CloseOutput(fmt.Errorf("failed to write NDJSON file: %w", err))
+	}
+	return w.CloseOutput(nil)
+}
+
+// Abort flushes the keys written so far and closes the output. Unlike other
+// export formats, NDJSON has no end marking a file as complete.
+func (w *Writer) Abort() error {
+	return w.Close()
+}
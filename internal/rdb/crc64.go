@@ -0,0 +1,13 @@
+package rdb
+
+import "hash/crc64"
+
+// jonesTable is the table of the CRC-64 variant used by Redis for RDB files
+// and DUMP payloads, with the reflected Jones polynomial.
+var jonesTable = crc64.MakeTable(0x95ac9329ac4bc9b5)
+
+// CRC64 updates the Redis checksum crc with p. Unlike [crc64.Update], Redis
+// neither inverts the initial value nor the result, which is undone here.
+func CRC64(crc uint64, p []byte) uint64 {
+	return ^crc64.Update(^crc, jonesTable, p)
+}
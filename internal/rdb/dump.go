@@ -0,0 +1,35 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// DumpPayload serializes a value read from an RDB file as the payload of
+// DUMP, which RESTORE accepts: the type and value, followed by the RDB
+// version and a checksum of both. Servers reject payloads of a newer RDB
+// version than their own.
+func DumpPayload(typ byte, value []byte, version int) []byte {
+	payload := make([]byte, 0, 1+len(value)+10)
+	payload = append(payload, typ)
+	payload = append(payload, value...)
+	payload = binary.LittleEndian.AppendUint16(payload, uint16(version))
+	return binary.LittleEndian.AppendUint64(payload, CRC64(0, payload))
+}
+
+// ParsePayload splits a DUMP payload into the type and serialized value of
+// the key, and the RDB version they were serialized for, after verifying its
+// checksum. It reverses [DumpPayload].
+func ParsePayload(payload []byte) (typ byte, value []byte, version int, err error) {
+	if len(payload) < 11 {
+		return 0, nil, 0, errors.New("DUMP payload is too short")
+	}
+
+	body, sum := payload[:len(payload)-8], payload[len(payload)-8:]
+	if CRC64(0, body) != binary.LittleEndian.Uint64(sum) {
+		return 0, nil, 0, errors.New("DUMP payload checksum mismatch")
+	}
+
+	version = int(binary.LittleEndian.Uint16(body[len(body)-2:]))
+	return body[0], body[1 : len(body)-2], version, nil
+}
@@ -0,0 +1,848 @@
+// Package rdb reads the RDB format Redis uses for snapshots, full
+// synchronizations of replicas and DUMP payloads.
+//
+// Values are not decoded: a key's value is kept in its serialized form, which
+// is the body of a DUMP payload as well. See [DumpPayload].
+package rdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Value types of the RDB format, as found in front of every key and at the
+// start of a DUMP payload.
+const (
+	TypeString           byte = 0
+	TypeList             byte = 1
+	TypeSet              byte = 2
+	TypeZSet             byte = 3
+	TypeHash             byte = 4
+	TypeZSet2            byte = 5
+	TypeModule           byte = 6
+	TypeModule2          byte = 7
+	TypeHashZipmap       byte = 9
+	TypeListZiplist      byte = 10
+	TypeSetIntset        byte = 11
+	TypeZSetZiplist      byte = 12
+	TypeHashZiplist      byte = 13
+	TypeListQuicklist    byte = 14
+	TypeStreamListpacks  byte = 15
+	TypeHashListpack     byte = 16
+	TypeZSetListpack     byte = 17
+	TypeListQuicklist2   byte = 18
+	TypeStreamListpacks2 byte = 19
+	TypeSetListpack      byte = 20
+	TypeStreamListpacks3 byte = 21
+
+	// Hashes with field expiration, added in Redis 7.4.
+	TypeHashMetadataPreGA   byte = 22
+	TypeHashListpackExPreGA byte = 23
+	TypeHashMetadata        byte = 24
+	TypeHashListpackEx      byte = 25
+)
+
+// Opcodes of the RDB format, which share the byte in front of every key with
+// the value types.
+const (
+	opSlotInfo      byte = 0xf4
+	opFunction2     byte = 0xf5
+	opFunctionPreGA byte = 0xf6
+	opModuleAux     byte = 0xf7
+	opIdle          byte = 0xf8
+	opFreq          byte = 0xf9
+	opAux           byte = 0xfa
+	opResizeDB      byte = 0xfb
+	opExpireTimeMS  byte = 0xfc
+	opExpireTime    byte = 0xfd
+	opSelectDB      byte = 0xfe
+	opEOF           byte = 0xff
+	moduleOpcodeEOF      = 0
+)
+
+// Special encodings of a length-prefixed string.
+const (
+	encInt8  = 0
+	encInt16 = 1
+	encInt32 = 2
+	encLZF   = 3
+)
+
+// ErrChecksum is returned when the checksum at the end of an RDB file doesn't
+// match its content.
+var ErrChecksum = errors.New("RDB checksum mismatch")
+
+// Entry is a key read from an RDB file.
+type Entry struct {
+	// DB is the number of the database holding the key.
+	DB int
+
+	// Key is the name of the key.
+	Key string
+
+	// Type is the value type, one of the Type constants.
+	Type byte
+
+	// Value is the serialized value, without the type.
+	Value []byte
+
+	// ExpireAt is the time the key expires, or zero if it doesn't.
+	ExpireAt time.Time
+
+	// Offset is the position of the key's type in the file, see
+	// [ReadEntryAt].
+	Offset int64
+}
+
+// Reader reads the keys of an RDB file in order.
+type Reader struct {
+	r       *bufio.Reader
+	version int
+	crc     uint64
+
+	db    int
+	sizes map[int]int64
+	aux   map[string]string
+
+	// value collects the bytes read while recording is set.
+	value     []byte
+	recording bool
+
+	// offset is the number of bytes of the file read so far.
+	offset int64
+
+	done bool
+}
+
+// NewReader reads the header of an RDB file from r. The reader consumes
+// exactly the bytes of the file, so that data following it in r, like the
+// command stream of a replica, can be read afterwards.
+func NewReader(r io.Reader) (*Reader, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	reader := &Reader{
+		r:     br,
+		sizes: make(map[int]int64),
+		aux:   make(map[string]string),
+	}
+
+	header := make([]byte, 9)
+	if err := reader.read(header); err != nil {
+		return nil, fmt.Errorf("failed to read RDB header: %w", err)
+	}
+	if string(header[:5]) != "REDIS" {
+		return nil, errors.New("not an RDB file")
+	}
+
+	version, err := strconv.Atoi(string(header[5:]))
+	if err != nil || version < 1 {
+		return nil, fmt.Errorf("invalid RDB version %q", header[5:])
+	}
+	reader.version = version
+
+	return reader, nil
+}
+
+// ReadEntryAt reads the key at offset in an RDB file of the given version, as
+// returned in [Entry.Offset]. The database and expire time of the key precede
+// it in the file, and are not set.
+func ReadEntryAt(r io.ReaderAt, offset int64, version int) (*Entry, error) {
+	reader := &Reader{
+		r:       bufio.NewReader(io.NewSectionReader(r, offset, math.MaxInt64-offset)),
+		version: version,
+		offset:  offset,
+	}
+
+	typ, err := reader.readByte()
+	if err != nil {
+		return nil, reader.unexpected(err)
+	}
+	return reader.readEntry(typ, time.Time{})
+}
+
+// Version returns the RDB version of the file.
+func (r *Reader) Version() int {
+	return r.version
+}
+
+// DBSize returns the number of keys of database db announced by the file, if
+// it has been read so far. It precedes the keys of the database.
+func (r *Reader) DBSize(db int) (int64, bool) {
+	size, ok := r.sizes[db]
+	return size, ok
+}
+
+// Offset returns the number of bytes of the file read so far.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// Aux returns the auxiliary fields read so far, like "redis-ver".
+func (r *Reader) Aux() map[string]string {
+	return r.aux
+}
+
+// Next returns the next key of the file, or [io.EOF] once the end of the file
+// has been read and its checksum verified.
+func (r *Reader) Next() (*Entry, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+
+	var expireAt time.Time
+	for {
+		op, err := r.readByte()
+		if err != nil {
+			return nil, r.unexpected(err)
+		}
+
+		switch op {
+		case opEOF:
+			r.done = true
+			if err := r.verifyChecksum(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+
+		case opSelectDB:
+			db, err := r.readLength()
+			if err != nil {
+				return nil, r.unexpected(err)
+			}
+			r.db = int(db)
+
+		case opResizeDB:
+			size, err := r.readLength()
+			if err != nil {
+				return nil, r.unexpected(err)
+			}
+			if _, err := r.readLength(); err != nil {
+				return nil, r.unexpected(err)
+			}
+			r.sizes[r.db] = int64(size)
+
+		case opExpireTime:
+			buf := make([]byte, 4)
+			if err := r.read(buf); err != nil {
+				return nil, r.unexpected(err)
+			}
+			expireAt = time.Unix(int64(binary.LittleEndian.Uint32(buf)), 0)
+
+		case opExpireTimeMS:
+			ms, err := r.readMillis()
+			if err != nil {
+				return nil, r.unexpected(err)
+			}
+			expireAt = time.UnixMilli(ms)
+
+		case opAux:
+			key, err := r.readString()
+			if err != nil {
+				return nil, r.unexpected(err)
+			}
+			value, err := r.readString()
+			if err != nil {
+				return nil, r.unexpected(err)
+			}
+			r.aux[key] = value
+
+		case opFreq:
+			if _, err := r.readByte(); err != nil {
+				return nil, r.unexpected(err)
+			}
+
+		case opIdle:
+			if _, err := r.readLength(); err != nil {
+				return nil, r.unexpected(err)
+			}
+
+		case opSlotInfo:
+			for range 3 {
+				if _, err := r.readLength(); err != nil {
+					return nil, r.unexpected(err)
+				}
+			}
+
+		case opFunction2:
+			if err := r.skipString(); err != nil {
+				return nil, r.unexpected(err)
+			}
+
+		case opModuleAux:
+			// The module ID, and the "when" field preceded by its opcode.
+			for range 3 {
+				if _, err := r.readLength(); err != nil {
+					return nil, r.unexpected(err)
+				}
+			}
+			if err := r.skipModuleValue(); err != nil {
+				return nil, r.unexpected(err)
+			}
+
+		case opFunctionPreGA:
+			return nil, errors.New("RDB files with functions of Redis 7.0 release candidates are not supported")
+
+		default:
+			return r.readEntry(op, expireAt)
+		}
+	}
+}
+
+// readEntry reads the key and value of type typ, which has just been read.
+func (r *Reader) readEntry(typ byte, expireAt time.Time) (*Entry, error) {
+	offset := r.offset - 1
+
+	key, err := r.readString()
+	if err != nil {
+		return nil, r.unexpected(err)
+	}
+
+	r.value = r.value[:0]
+	r.recording = true
+	err = r.skipValue(typ)
+	r.recording = false
+	if err != nil {
+		return nil, fmt.Errorf("failed to read value of key %q: %w", key, r.unexpected(err))
+	}
+
+	return &Entry{
+		DB:       r.db,
+		Key:      key,
+		Type:     typ,
+		Value:    append([]byte(nil), r.value...),
+		ExpireAt: expireAt,
+		Offset:   offset,
+	}, nil
+}
+
+// skipValue reads a value of type typ, which is recorded by the caller.
+func (r *Reader) skipValue(typ byte) error {
+	switch typ {
+	case TypeString, TypeHashZipmap, TypeListZiplist, TypeSetIntset, TypeZSetZiplist,
+		TypeHashZiplist, TypeHashListpack, TypeZSetListpack, TypeSetListpack, TypeHashListpackExPreGA:
+		return r.skipString()
+
+	case TypeHashListpackEx:
+		if _, err := r.readMillis(); err != nil {
+			return err
+		}
+		return r.skipString()
+
+	case TypeList, TypeSet:
+		return r.skipStrings(1)
+
+	case TypeHash:
+		return r.skipStrings(2)
+
+	case TypeListQuicklist:
+		return r.skipStrings(1)
+
+	case TypeListQuicklist2:
+		n, err := r.readLength()
+		if err != nil {
+			return err
+		}
+		for range n {
+			if _, err := r.readLength(); err != nil {
+				return err
+			}
+			if err := r.skipString(); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case TypeZSet, TypeZSet2:
+		n, err := r.readLength()
+		if err != nil {
+			return err
+		}
+		for range n {
+			if err := r.skipString(); err != nil {
+				return err
+			}
+			if err := r.skipScore(typ); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case TypeHashMetadata, TypeHashMetadataPreGA:
+		return r.skipHashMetadata(typ)
+
+	case TypeStreamListpacks, TypeStreamListpacks2, TypeStreamListpacks3:
+		return r.skipStream(typ)
+
+	case TypeModule2:
+		if _, err := r.readLength(); err != nil {
+			return err
+		}
+		return r.skipModuleValue()
+
+	case TypeModule:
+		return errors.New("values of modules serialized before RDB version 8 are not supported")
+
+	default:
+		return fmt.Errorf("unknown value type %d", typ)
+	}
+}
+
+// skipStrings reads a length followed by that many groups of n strings.
+func (r *Reader) skipStrings(n int) error {
+	count, err := r.readLength()
+	if err != nil {
+		return err
+	}
+	for range count * uint64(n) {
+		if err := r.skipString(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipScore reads the score of a sorted set member, which is a binary double
+// in [TypeZSet2] and a length-prefixed string otherwise.
+func (r *Reader) skipScore(typ byte) error {
+	if typ == TypeZSet2 {
+		return r.skip(8)
+	}
+
+	n, err := r.readByte()
+	if err != nil {
+		return err
+	}
+	// 253, 254 and 255 encode NaN and the infinities without any bytes.
+	if n >= 253 {
+		return nil
+	}
+	return r.skip(int(n))
+}
+
+func (r *Reader) skipHashMetadata(typ byte) error {
+	if typ == TypeHashMetadata {
+		// The minimum expire time of the fields, which are stored relative to it.
+		if _, err := r.readMillis(); err != nil {
+			return err
+		}
+	}
+
+	n, err := r.readLength()
+	if err != nil {
+		return err
+	}
+	for range n {
+		if typ == TypeHashMetadata {
+			_, err = r.readLength()
+		} else {
+			_, err = r.readMillis()
+		}
+		if err != nil {
+			return err
+		}
+		if err := r.skipString(); err != nil {
+			return err
+		}
+		if err := r.skipString(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reader) skipStream(typ byte) error {
+	// Listpacks, each keyed by the master ID of its entries.
+	n, err := r.readLength()
+	if err != nil {
+		return err
+	}
+	for range 2 * n {
+		if err := r.skipString(); err != nil {
+			return err
+		}
+	}
+
+	// Length and last ID, and since version 10 the first ID, the maximum
+	// deleted ID and the number of entries ever added.
+	fields := 3
+	if typ >= TypeStreamListpacks2 {
+		fields += 5
+	}
+	if err := r.skipLengths(fields); err != nil {
+		return err
+	}
+
+	groups, err := r.readLength()
+	if err != nil {
+		return err
+	}
+	for range groups {
+		if err := r.skipString(); err != nil {
+			return err
+		}
+
+		// Last delivered ID, and since version 10 the entries read.
+		fields := 2
+		if typ >= TypeStreamListpacks2 {
+			fields++
+		}
+		if err := r.skipLengths(fields); err != nil {
+			return err
+		}
+
+		// Pending entries: raw ID, delivery time and count.
+		pending, err := r.readLength()
+		if err != nil {
+			return err
+		}
+		for range pending {
+			if err := r.skip(16 + 8); err != nil {
+				return err
+			}
+			if _, err := r.readLength(); err != nil {
+				return err
+			}
+		}
+
+		consumers, err := r.readLength()
+		if err != nil {
+			return err
+		}
+		for range consumers {
+			if err := r.skipString(); err != nil {
+				return err
+			}
+
+			// Seen time, and since version 12 the active time.
+			times := 8
+			if typ >= TypeStreamListpacks3 {
+				times += 8
+			}
+			if err := r.skip(times); err != nil {
+				return err
+			}
+
+			// Raw IDs of the pending entries of the consumer.
+			pending, err := r.readLength()
+			if err != nil {
+				return err
+			}
+			if err := r.skip(int(pending) * 16); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// skipModuleValue reads the typed fields a module serialized, up to the
+// closing EOF opcode.
+func (r *Reader) skipModuleValue() error {
+	for {
+		opcode, err := r.readLength()
+		if err != nil {
+			return err
+		}
+
+		switch opcode {
+		case moduleOpcodeEOF:
+			return nil
+		case 1, 2: // signed and unsigned integers
+			_, err = r.readLength()
+		case 3: // float
+			err = r.skip(4)
+		case 4: // double
+			err = r.skip(8)
+		case 5: // string
+			err = r.skipString()
+		default:
+			err = fmt.Errorf("unknown module opcode %d", opcode)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (r *Reader) skipLengths(n int) error {
+	for range n {
+		if _, err := r.readLength(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyChecksum reads the checksum at the end of the file, which is zero if
+// checksums are disabled, and compares it with the content.
+func (r *Reader) verifyChecksum() error {
+	if r.version < 5 {
+		return nil
+	}
+
+	want := r.crc
+	buf := make([]byte, 8)
+	if _, err := io.ReadFull(r.r, buf); err != nil {
+		return r.unexpected(err)
+	}
+	r.offset += int64(len(buf))
+
+	got := binary.LittleEndian.Uint64(buf)
+	if got != 0 && got != want {
+		return ErrChecksum
+	}
+	return nil
+}
+
+// readLength reads a length, failing for the special string encodings.
+func (r *Reader) readLength() (uint64, error) {
+	n, encoded, err := r.readEncodedLength()
+	if err != nil {
+		return 0, err
+	}
+	if encoded {
+		return 0, fmt.Errorf("unexpected string encoding %d", n)
+	}
+	return n, nil
+}
+
+// readEncodedLength reads a length, or the special encoding of a string if
+// encoded is set.
+func (r *Reader) readEncodedLength() (n uint64, encoded bool, err error) {
+	b, err := r.readByte()
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch b >> 6 {
+	case 0:
+		return uint64(b & 0x3f), false, nil
+	case 1:
+		next, err := r.readByte()
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(b&0x3f)<<8 | uint64(next), false, nil
+	case 2:
+		switch b {
+		case 0x80:
+			buf := make([]byte, 4)
+			if err := r.read(buf); err != nil {
+				return 0, false, err
+			}
+			return uint64(binary.BigEndian.Uint32(buf)), false, nil
+		case 0x81:
+			buf := make([]byte, 8)
+			if err := r.read(buf); err != nil {
+				return 0, false, err
+			}
+			return binary.BigEndian.Uint64(buf), false, nil
+		default:
+			return 0, false, fmt.Errorf("invalid length encoding %#x", b)
+		}
+	default:
+		return uint64(b & 0x3f), true, nil
+	}
+}
+
+// readString reads and decodes a string.
+func (r *Reader) readString() (string, error) {
+	n, encoded, err := r.readEncodedLength()
+	if err != nil {
+		return "", err
+	}
+
+	if !encoded {
+		buf := make([]byte, n)
+		if err := r.read(buf); err != nil {
+			return "", err
+		}
+		return string(buf), nil
+	}
+
+	switch n {
+	case encInt8, encInt16, encInt32:
+		buf := make([]byte, 1<<n)
+		if err := r.read(buf); err != nil {
+			return "", err
+		}
+		var v int64
+		switch n {
+		case encInt8:
+			v = int64(int8(buf[0]))
+		case encInt16:
+			v = int64(int16(binary.LittleEndian.Uint16(buf)))
+		default:
+			v = int64(int32(binary.LittleEndian.Uint32(buf)))
+		}
+		return strconv.FormatInt(v, 10), nil
+
+	case encLZF:
+		compressed, err := r.readLength()
+		if err != nil {
+			return "", err
+		}
+		length, err := r.readLength()
+		if err != nil {
+			return "", err
+		}
+		buf := make([]byte, compressed)
+		if err := r.read(buf); err != nil {
+			return "", err
+		}
+		out, err := lzfDecompress(buf, int(length))
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+
+	default:
+		return "", fmt.Errorf("unknown string encoding %d", n)
+	}
+}
+
+// skipString reads a string without decoding it.
+func (r *Reader) skipString() error {
+	n, encoded, err := r.readEncodedLength()
+	if err != nil {
+		return err
+	}
+
+	if !encoded {
+		return r.skip(int(n))
+	}
+
+	switch n {
+	case encInt8, encInt16, encInt32:
+		return r.skip(1 << n)
+	case encLZF:
+		compressed, err := r.readLength()
+		if err != nil {
+			return err
+		}
+		if _, err := r.readLength(); err != nil {
+			return err
+		}
+		return r.skip(int(compressed))
+	default:
+		return fmt.Errorf("unknown string encoding %d", n)
+	}
+}
+
+// readMillis reads a little-endian Unix time in milliseconds.
+func (r *Reader) readMillis() (int64, error) {
+	buf := make([]byte, 8)
+	if err := r.read(buf); err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint64(buf)), nil
+}
+
+func (r *Reader) readByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	r.consumed([]byte{b})
+	return b, nil
+}
+
+func (r *Reader) read(buf []byte) error {
+	if _, err := io.ReadFull(r.r, buf); err != nil {
+		return err
+	}
+	r.consumed(buf)
+	return nil
+}
+
+// skip reads n bytes, in chunks so that a corrupt length doesn't allocate
+// more than the file holds.
+func (r *Reader) skip(n int) error {
+	if n < 0 {
+		return errors.New("invalid length")
+	}
+
+	buf := make([]byte, min(n, 64*1024))
+	for n > 0 {
+		chunk := buf[:min(n, len(buf))]
+		if err := r.read(chunk); err != nil {
+			return err
+		}
+		n -= len(chunk)
+	}
+	return nil
+}
+
+// consumed adds bytes read from the file to the checksum, and to the value
+// being recorded.
+func (r *Reader) consumed(p []byte) {
+	r.crc = CRC64(r.crc, p)
+	r.offset += int64(len(p))
+	if r.recording {
+		r.value = append(r.value, p...)
+	}
+}
+
+// unexpected turns the end of the input within the file into an error.
+func (r *Reader) unexpected(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// lzfDecompress decompresses LZF data into a buffer of length n.
+func lzfDecompress(in []byte, n int) ([]byte, error) {
+	out := make([]byte, 0, n)
+	for i := 0; i < len(in); {
+		ctrl := int(in[i])
+		i++
+
+		if ctrl < 32 {
+			// A literal run of ctrl+1 bytes.
+			end := i + ctrl + 1
+			if end > len(in) {
+				return nil, errors.New("corrupt LZF data")
+			}
+			out = append(out, in[i:end]...)
+			i = end
+			continue
+		}
+
+		// A back reference of length ctrl>>5 (+ an extra byte if 7) + 2.
+		length := ctrl >> 5
+		if length == 7 {
+			if i >= len(in) {
+				return nil, errors.New("corrupt LZF data")
+			}
+			length += int(in[i])
+			i++
+		}
+		if i >= len(in) {
+			return nil, errors.New("corrupt LZF data")
+		}
+		ref := len(out) - (ctrl&0x1f)<<8 - int(in[i]) - 1
+		i++
+		if ref < 0 {
+			return nil, errors.New("corrupt LZF data")
+		}
+		for j := range length + 2 {
+			out = append(out, out[ref+j])
+		}
+	}
+
+	if len(out) != n {
+		return nil, fmt.Errorf("corrupt LZF data: got %d bytes, want %d", len(out), n)
+	}
+	return out, nil
+}
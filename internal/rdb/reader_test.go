@@ -0,0 +1,265 @@
+package rdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rdbBuilder writes RDB files for tests.
+type rdbBuilder struct {
+	bytes.Buffer
+}
+
+func newRDB(version string) *rdbBuilder {
+	b := &rdbBuilder{}
+	b.WriteString("REDIS" + version)
+	return b
+}
+
+func (b *rdbBuilder) length(n int) *rdbBuilder {
+	switch {
+	case n < 1<<6:
+		b.WriteByte(byte(n))
+	case n < 1<<14:
+		b.WriteByte(byte(n>>8) | 0x40)
+		b.WriteByte(byte(n))
+	default:
+		b.WriteByte(0x80)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+	return b
+}
+
+func (b *rdbBuilder) str(s string) *rdbBuilder {
+	b.length(len(s))
+	b.WriteString(s)
+	return b
+}
+
+func (b *rdbBuilder) raw(p ...byte) *rdbBuilder {
+	b.Write(p)
+	return b
+}
+
+// end writes the EOF opcode and the checksum of the file.
+func (b *rdbBuilder) end() []byte {
+	b.WriteByte(opEOF)
+	return binary.LittleEndian.AppendUint64(b.Bytes(), CRC64(0, b.Bytes()))
+}
+
+func TestCRC64(t *testing.T) {
+	assert.Equal(t, uint64(0xe9c6d914c4b8d9ca), CRC64(0, []byte("123456789")))
+	assert.Equal(t, CRC64(0, []byte("123456789")), CRC64(CRC64(0, []byte("1234")), []byte("56789")))
+}
+
+func TestReader(t *testing.T) {
+	expireAt := time.UnixMilli(1893456000000)
+
+	b := newRDB("0011")
+	b.raw(opAux).str("redis-ver").str("7.2.4")
+	b.raw(opAux).str("ctime").raw(0xc2).raw(binary.LittleEndian.AppendUint32(nil, 1700000000)...)
+	b.raw(opSelectDB).length(0)
+	b.raw(opResizeDB).length(4).length(1)
+	b.raw(opExpireTimeMS).raw(binary.LittleEndian.AppendUint64(nil, uint64(expireAt.UnixMilli()))...)
+	b.raw(TypeString).str("session").str("token")
+	b.raw(opIdle).length(120)
+	b.raw(TypeList).str("queue").length(2).str("a").raw(0xc0, 7)
+	b.raw(opFreq).raw(5)
+	b.raw(TypeZSet).str("scores").length(2).str("x").str("1.5").str("y").raw(254)
+	// An LZF-compressed key: a literal "a" repeated by a back reference.
+	b.raw(TypeString).raw(0xc3).length(4).length(6).raw(0x00, 'a', 0x60, 0x00).str("lzf")
+	b.raw(opSelectDB).length(2)
+	b.raw(TypeStreamListpacks3).str("events").
+		length(1).str("0123456789abcdef").str("listpack").
+		length(1).length(1).length(0).length(1).length(0).length(0).length(0).length(1).
+		length(1).str("group").length(1).length(0).length(1).
+		length(1).raw(make([]byte, 24)...).length(1).
+		length(1).str("consumer").raw(make([]byte, 16)...).length(1).raw(make([]byte, 16)...)
+	b.raw(TypeModule2).str("module").length(42).length(2).length(7).length(5).str("data").length(0)
+	data := b.end()
+
+	reader, err := NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 11, reader.Version())
+
+	var entries []*Entry
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		entries = append(entries, entry)
+	}
+
+	assert.Equal(t, "7.2.4", reader.Aux()["redis-ver"])
+	assert.Equal(t, "1700000000", reader.Aux()["ctime"])
+	size, ok := reader.DBSize(0)
+	assert.True(t, ok)
+	assert.Equal(t, int64(4), size)
+
+	require.Len(t, entries, 6)
+
+	sessionOffset := int64(bytes.Index(data, []byte("\x07session")) - 1)
+	queueOffset := int64(bytes.Index(data, []byte("\x05queue")) - 1)
+	assert.Equal(t, &Entry{DB: 0, Key: "session", Type: TypeString, Value: []byte("\x05token"), ExpireAt: expireAt, Offset: sessionOffset}, entries[0])
+	assert.Equal(t, &Entry{DB: 0, Key: "queue", Type: TypeList, Value: []byte("\x02\x01a\xc0\x07"), Offset: queueOffset}, entries[1])
+	assert.Equal(t, "scores", entries[2].Key)
+	assert.Equal(t, []byte("\x02\x01x\x031.5\x01y\xfe"), entries[2].Value)
+	assert.Equal(t, "aaaaaa", entries[3].Key)
+	assert.True(t, entries[3].ExpireAt.IsZero(), "expire times only apply to the next key")
+	assert.Equal(t, 2, entries[4].DB)
+	assert.Equal(t, "events", entries[4].Key)
+	assert.Equal(t, "module", entries[5].Key)
+
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReadEntryAt(t *testing.T) {
+	b := newRDB("0011")
+	b.raw(opSelectDB).length(3)
+	b.raw(opExpireTimeMS).raw(binary.LittleEndian.AppendUint64(nil, 1893456000000)...)
+	b.raw(TypeString).str("session").str("token")
+	b.raw(TypeSet).str("tags").length(2).str("a").str("b")
+	data := b.end()
+
+	reader, err := NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	var entries []*Entry
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		entries = append(entries, entry)
+	}
+	assert.Equal(t, int64(len(data)), reader.Offset())
+	require.Len(t, entries, 2)
+
+	for _, want := range entries {
+		got, err := ReadEntryAt(bytes.NewReader(data), want.Offset, reader.Version())
+		require.NoError(t, err)
+		assert.Equal(t, want.Key, got.Key)
+		assert.Equal(t, want.Type, got.Type)
+		assert.Equal(t, want.Value, got.Value)
+		assert.Equal(t, want.Offset, got.Offset)
+		assert.True(t, got.ExpireAt.IsZero())
+	}
+
+	_, err = ReadEntryAt(bytes.NewReader(data), int64(len(data))-4, reader.Version())
+	assert.Error(t, err)
+}
+
+func TestReader_Errors(t *testing.T) {
+	file := newRDB("0011").raw(TypeString).str("key").str("value").end()
+
+	corrupt := bytes.Clone(file)
+	corrupt[len(corrupt)-1] ^= 0xff
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"checksum", corrupt, ErrChecksum},
+		{"truncated", file[:len(file)-12], io.ErrUnexpectedEOF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewReader(bytes.NewReader(tt.data))
+			require.NoError(t, err)
+
+			var last error
+			for last == nil {
+				_, last = reader.Next()
+			}
+			assert.ErrorIs(t, last, tt.want)
+		})
+	}
+
+	_, err := NewReader(bytes.NewReader([]byte("NOTREDIS0")))
+	assert.EqualError(t, err, "not an RDB file")
+
+	reader, err := NewReader(bytes.NewReader(newRDB("0011").raw(0x42).str("key").end()))
+	require.NoError(t, err)
+	_, err = reader.Next()
+	assert.ErrorContains(t, err, "unknown value type 66")
+}
+
+func TestReader_DisabledChecksum(t *testing.T) {
+	b := newRDB("0011").raw(TypeString).str("key").str("value").raw(opEOF)
+	b.Write(make([]byte, 8))
+
+	reader, err := NewReader(bytes.NewReader(b.Bytes()))
+	require.NoError(t, err)
+
+	_, err = reader.Next()
+	require.NoError(t, err)
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReader_LeavesTrailingData(t *testing.T) {
+	input := bytes.NewBuffer(newRDB("0011").raw(TypeString).str("key").str("value").end())
+	input.WriteString("*1\r\n$4\r\nPING\r\n")
+
+	buffered := bufio.NewReader(input)
+	reader, err := NewReader(buffered)
+	require.NoError(t, err)
+
+	for {
+		if _, err := reader.Next(); err != nil {
+			require.Equal(t, io.EOF, err)
+			break
+		}
+	}
+
+	rest, err := io.ReadAll(buffered)
+	require.NoError(t, err)
+	assert.Equal(t, "*1\r\n$4\r\nPING\r\n", string(rest))
+}
+
+func TestDumpPayload(t *testing.T) {
+	payload := DumpPayload(TypeString, []byte("\x03bar"), 11)
+
+	assert.Equal(t, []byte("\x00\x03bar\x0b\x00"), payload[:len(payload)-8])
+	assert.Equal(t, CRC64(0, payload[:len(payload)-8]), binary.LittleEndian.Uint64(payload[len(payload)-8:]))
+}
+
+func TestParsePayload(t *testing.T) {
+	typ, value, version, err := ParsePayload(DumpPayload(TypeSet, []byte("\x01\x01a"), 12))
+	require.NoError(t, err)
+	assert.Equal(t, TypeSet, typ)
+	assert.Equal(t, []byte("\x01\x01a"), value)
+	assert.Equal(t, 12, version)
+
+	payload := DumpPayload(TypeString, []byte("\x03bar"), 11)
+	payload[1] ^= 0xff
+	_, _, _, err = ParsePayload(payload)
+	assert.ErrorContains(t, err, "checksum")
+
+	_, _, _, err = ParsePayload([]byte("short"))
+	assert.ErrorContains(t, err, "too short")
+}
+
+func TestTypeName(t *testing.T) {
+	assert.Equal(t, "string", TypeName(TypeString))
+	assert.Equal(t, "list", TypeName(TypeListQuicklist2))
+	assert.Equal(t, "set", TypeName(TypeSetListpack))
+	assert.Equal(t, "zset", TypeName(TypeZSetListpack))
+	assert.Equal(t, "hash", TypeName(TypeHashListpackEx))
+	assert.Equal(t, "stream", TypeName(TypeStreamListpacks3))
+	assert.Equal(t, "module", TypeName(TypeModule2))
+	assert.Equal(t, "", TypeName(0x42))
+}
@@ -0,0 +1,183 @@
+package rdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+// AllDBs selects the keys of every database of an RDB file in [Open].
+const AllDBs = -1
+
+// Source reads the keys of an RDB file. It implements [migrate.RedisClient]
+// as the source of a migration, and [migrate.Inspector] for dry runs. A key
+// found in several databases is only migrated from the first of them.
+type Source struct {
+	*migrate.FileKeys[location]
+
+	f       *os.File
+	version int
+	db      int
+}
+
+// location is where the value of a key was found in the file.
+type location struct {
+	offset   int64
+	expireAt time.Time
+}
+
+// Open opens an RDB file and reads its header. Only the keys of database db
+// are read, unless it's [AllDBs].
+func Open(path string, db int) (*Source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open RDB file: %w", err)
+	}
+
+	reader, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &Source{FileKeys: migrate.NewFileKeys[location](), f: f, version: reader.Version(), db: db}, nil
+}
+
+// Version returns the RDB version of the file.
+func (s *Source) Version() int {
+	return s.version
+}
+
+// read returns a reader of the keys of the file matching a scan, in the
+// selected databases, which verifies the checksum once the end has been read.
+func (s *Source) read(ctx context.Context, opts migrate.ScanOptions) migrate.FileReader[location] {
+	return func(yield func(key string, loc location, offset, next int64) error) error {
+		reader, err := NewReader(io.NewSectionReader(s.f, 0, math.MaxInt64))
+		if err != nil {
+			return err
+		}
+
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			entry, err := reader.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read RDB file: %w", err)
+			}
+
+			if (s.db != AllDBs && entry.DB != s.db) || !matches(entry, opts) {
+				continue
+			}
+			loc := location{offset: entry.Offset, expireAt: entry.ExpireAt}
+			if err := yield(entry.Key, loc, entry.Offset, reader.Offset()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ScanKeys reads the keys of the file in order.
+func (s *Source) ScanKeys(ctx context.Context, opts migrate.ScanOptions, batches chan<- migrate.KeyBatch) error {
+	return s.Scan(ctx, opts, batches, s.read(ctx, opts))
+}
+
+// matches reports whether a key matches the pattern and types of a scan.
+func matches(entry *Entry, opts migrate.ScanOptions) bool {
+	if !migrate.MatchPattern(opts.Pattern, entry.Key) {
+		return false
+	}
+	return len(opts.Types) == 0 || slices.Contains(opts.Types, TypeName(entry.Type))
+}
+
+// CountKeys reads the file to count the keys matching the scan.
+func (s *Source) CountKeys(ctx context.Context, opts migrate.ScanOptions) (int64, error) {
+	return s.Count(s.read(ctx, opts))
+}
+
+// entry reads the key scanned before, if any.
+func (s *Source) entry(key string) (*Entry, location, error) {
+	loc, ok := s.Location(key)
+	if !ok {
+		return nil, loc, nil
+	}
+
+	entry, err := ReadEntryAt(s.f, loc.offset, s.version)
+	if err != nil {
+		return nil, loc, fmt.Errorf("failed to read RDB file: %w", err)
+	}
+	return entry, loc, nil
+}
+
+// DumpKeys returns the values of keys scanned before as DUMP payloads of the
+// file's RDB version. Keys whose expire time has passed are returned with
+// [migrate.ExpiredTTL].
+func (s *Source) DumpKeys(_ context.Context, keys []string) ([]migrate.KeyData, error) {
+	var data []migrate.KeyData
+	for _, key := range keys {
+		entry, loc, err := s.entry(key)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			continue
+		}
+
+		data = append(data, migrate.KeyData{
+			Key:  key,
+			Data: string(DumpPayload(entry.Type, entry.Value, s.version)),
+			TTL:  RemainingTTL(loc.expireAt),
+		})
+	}
+	return data, nil
+}
+
+// RemainingTTL returns the time left until expireAt like
+// [migrate.KeyData.TTL], or [migrate.ExpiredTTL] if it has passed.
+func RemainingTTL(expireAt time.Time) time.Duration {
+	if expireAt.IsZero() {
+		return 0
+	}
+	if ttl := time.Until(expireAt); ttl > 0 {
+		return ttl
+	}
+	return migrate.ExpiredTTL
+}
+
+// InspectKeys returns the type and TTL of keys scanned before, and the size
+// of their serialized value as their memory usage.
+func (s *Source) InspectKeys(_ context.Context, keys []string) ([]migrate.KeyInfo, error) {
+	var infos []migrate.KeyInfo
+	for _, key := range keys {
+		entry, loc, err := s.entry(key)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			continue
+		}
+
+		infos = append(infos, migrate.KeyInfo{
+			Key:  key,
+			Type: TypeName(entry.Type),
+			Size: int64(len(entry.Value)),
+			TTL:  RemainingTTL(loc.expireAt),
+		})
+	}
+	return infos, nil
+}
+
+// Close closes the RDB file.
+func (s *Source) Close() error {
+	return s.f.Close()
+}
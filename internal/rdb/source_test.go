@@ -0,0 +1,190 @@
+package rdb
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+// writeRDB writes an RDB file with keys in databases 0 and 1, and returns its
+// path.
+func writeRDB(t *testing.T) string {
+	t.Helper()
+
+	expires := func(at time.Time) []byte {
+		return append([]byte{opExpireTimeMS}, binary.LittleEndian.AppendUint64(nil, uint64(at.UnixMilli()))...)
+	}
+
+	b := newRDB("0011")
+	b.raw(opAux).str("redis-ver").str("7.2.4")
+	b.raw(opSelectDB).length(0)
+	b.raw(opResizeDB).length(4).length(2)
+	b.raw(TypeString).str("user:1").str("one")
+	b.raw(expires(time.Now().Add(time.Hour))...)
+	b.raw(TypeSet).str("user:2").length(2).str("a").str("b")
+	b.raw(TypeString).str("order:1").str("three")
+	b.raw(expires(time.Now().Add(-time.Minute))...)
+	b.raw(TypeString).str("user:3").str("four")
+	b.raw(opSelectDB).length(1)
+	b.raw(TypeString).str("user:1").str("other")
+	b.raw(TypeString).str("user:4").str("five")
+
+	path := filepath.Join(t.TempDir(), "dump.rdb")
+	require.NoError(t, os.WriteFile(path, b.end(), 0o644))
+	return path
+}
+
+// scanAll collects the batches of a scan.
+func scanAll(t *testing.T, source *Source, opts migrate.ScanOptions) ([]migrate.KeyBatch, error) {
+	t.Helper()
+
+	batches := make(chan migrate.KeyBatch, 100)
+	err := source.ScanKeys(context.Background(), opts, batches)
+	close(batches)
+
+	var all []migrate.KeyBatch
+	for batch := range batches {
+		all = append(all, batch)
+	}
+	return all, err
+}
+
+func TestSource_ScanAndDump(t *testing.T) {
+	source, err := Open(writeRDB(t), 0)
+	require.NoError(t, err)
+	defer source.Close()
+
+	count, err := source.CountKeys(context.Background(), migrate.ScanOptions{Pattern: "user:*"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	batches, err := scanAll(t, source, migrate.ScanOptions{Pattern: "user:*", BatchSize: 2})
+	require.NoError(t, err)
+	require.Len(t, batches, 2)
+	assert.Equal(t, []string{"user:1", "user:2"}, batches[0].Keys)
+	assert.Equal(t, batches[0].Next, batches[1].Cursor)
+	assert.Equal(t, []string{"user:3"}, batches[1].Keys)
+	assert.Empty(t, batches[1].Next)
+
+	data, err := source.DumpKeys(context.Background(), []string{"user:1", "user:2", "user:3", "order:1"})
+	require.NoError(t, err)
+	require.Len(t, data, 3, "keys not scanned are missing")
+
+	assert.Equal(t, migrate.KeyData{Key: "user:1", Data: string(DumpPayload(TypeString, []byte("\x03one"), 11))}, data[0])
+	assert.Equal(t, "user:2", data[1].Key)
+	assert.InDelta(t, time.Hour, data[1].TTL, float64(time.Minute))
+	assert.Equal(t, migrate.ExpiredTTL, data[2].TTL, "keys expired before the file is read")
+
+	infos, err := source.InspectKeys(context.Background(), []string{"user:2"})
+	require.NoError(t, err)
+	if assert.Len(t, infos, 1) {
+		assert.Equal(t, migrate.KeyInfo{Key: "user:2", Type: "set", Size: 5, TTL: infos[0].TTL}, infos[0])
+		assert.InDelta(t, time.Hour, infos[0].TTL, float64(time.Minute))
+	}
+
+	exists, err := source.ExistingKeys(context.Background(), []string{"user:1", "order:1"})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, exists)
+}
+
+func TestSource_Databases(t *testing.T) {
+	source, err := Open(writeRDB(t), 1)
+	require.NoError(t, err)
+	defer source.Close()
+
+	batches, err := scanAll(t, source, migrate.ScanOptions{Pattern: "*", BatchSize: 10})
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	assert.Equal(t, []string{"user:1", "user:4"}, batches[0].Keys)
+
+	data, err := source.DumpKeys(context.Background(), []string{"user:1"})
+	require.NoError(t, err)
+	assert.Equal(t, string(DumpPayload(TypeString, []byte("\x05other"), 11)), data[0].Data)
+
+	all, err := Open(writeRDB(t), AllDBs)
+	require.NoError(t, err)
+	defer all.Close()
+
+	count, err := all.CountKeys(context.Background(), migrate.ScanOptions{Pattern: "*"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), count, "keys in several databases are counted once")
+
+	batches, err = scanAll(t, all, migrate.ScanOptions{Pattern: "*", BatchSize: 10})
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	assert.Equal(t, []string{"user:1", "user:2", "order:1", "user:3", "user:4"}, batches[0].Keys, "keys in several databases are scanned once")
+
+	data, err = all.DumpKeys(context.Background(), []string{"user:1"})
+	require.NoError(t, err)
+	assert.Equal(t, string(DumpPayload(TypeString, []byte("\x03one"), 11)), data[0].Data, "keys are read from the first database")
+}
+
+func TestSource_Types(t *testing.T) {
+	source, err := Open(writeRDB(t), 0)
+	require.NoError(t, err)
+	defer source.Close()
+
+	opts := migrate.ScanOptions{Pattern: "*", BatchSize: 10, Types: []string{"set"}}
+
+	count, err := source.CountKeys(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	batches, err := scanAll(t, source, opts)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	assert.Equal(t, []string{"user:2"}, batches[0].Keys)
+}
+
+func TestSource_Resume(t *testing.T) {
+	source, err := Open(writeRDB(t), 0)
+	require.NoError(t, err)
+	defer source.Close()
+
+	first, err := scanAll(t, source, migrate.ScanOptions{Pattern: "*", BatchSize: 1})
+	require.NoError(t, err)
+	require.Len(t, first, 5)
+
+	resumed, err := scanAll(t, source, migrate.ScanOptions{
+		Pattern:   "*",
+		BatchSize: 1,
+		Resume:    map[string]string{"": first[1].Next},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, first[2:], resumed)
+
+	done, err := scanAll(t, source, migrate.ScanOptions{Pattern: "*", BatchSize: 1, Resume: map[string]string{"": ""}})
+	require.NoError(t, err)
+	assert.Empty(t, done)
+}
+
+func TestSource_Errors(t *testing.T) {
+	path := writeRDB(t)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[len(data)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	source, err := Open(path, AllDBs)
+	require.NoError(t, err)
+	defer source.Close()
+
+	_, err = scanAll(t, source, migrate.ScanOptions{Pattern: "*", BatchSize: 10})
+	assert.ErrorIs(t, err, ErrChecksum)
+
+	_, err = Open(filepath.Join(t.TempDir(), "missing.rdb"), AllDBs)
+	assert.ErrorContains(t, err, "failed to open RDB file")
+
+	notRDB := filepath.Join(t.TempDir(), "keys.txt")
+	require.NoError(t, os.WriteFile(notRDB, []byte("not a snapshot"), 0o644))
+	_, err = Open(notRDB, AllDBs)
+	assert.ErrorContains(t, err, "not an RDB file")
+}
@@ -0,0 +1,26 @@
+package rdb
+
+// TypeName returns the name of the data type of a value type as reported by
+// the TYPE command, e.g. "hash" for [TypeHashListpack], "module" for values of
+// modules, or an empty string for unknown types.
+func TypeName(typ byte) string {
+	switch typ {
+	case TypeString:
+		return "string"
+	case TypeList, TypeListZiplist, TypeListQuicklist, TypeListQuicklist2:
+		return "list"
+	case TypeSet, TypeSetIntset, TypeSetListpack:
+		return "set"
+	case TypeZSet, TypeZSet2, TypeZSetZiplist, TypeZSetListpack:
+		return "zset"
+	case TypeHash, TypeHashZipmap, TypeHashZiplist, TypeHashListpack,
+		TypeHashMetadataPreGA, TypeHashListpackExPreGA, TypeHashMetadata, TypeHashListpackEx:
+		return "hash"
+	case TypeStreamListpacks, TypeStreamListpacks2, TypeStreamListpacks3:
+		return "stream"
+	case TypeModule, TypeModule2:
+		return "module"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,245 @@
+package rdb
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+// emptyVersion is the RDB version of a file closed without keys, which any
+// Redis since 5.0 loads.
+const emptyVersion = 9
+
+// Writer writes an RDB file. It implements [migrate.RedisClient] as the
+// destination of an export: keys restored are appended to the file, in
+// database 0.
+//
+// The file takes the RDB version of its first key, so that it loads into the
+// servers its keys could be restored into.
+type Writer struct {
+	migrate.WriteOnly
+
+	w   *bufio.Writer
+	crc uint64
+
+	// version is the RDB version of the file, or 0 until its header has
+	// been written.
+	version int
+	db      int
+
+	mu      sync.Mutex
+	written map[string]struct{}
+	count   int64
+	err     error
+}
+
+// NewWriter returns a writer of an RDB file to out. The header is written
+// along with the first key.
+func NewWriter(out io.Writer) *Writer {
+	return &Writer{
+		WriteOnly: migrate.WriteOnly{Format: "RDB file", Out: out},
+		w:         bufio.NewWriter(out),
+		db:        -1,
+		written:   make(map[string]struct{}),
+	}
+}
+
+// Write appends a key to the file, whose value is serialized for RDB version
+// version. Keys of a newer version than the file's are rejected.
+func (w *Writer) Write(entry *Entry, version int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.write(entry, version)
+}
+
+func (w *Writer) write(entry *Entry, version int) error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.fits(version); err != nil {
+		return err
+	}
+
+	var buf []byte
+	if w.version == 0 {
+		w.version = version
+		buf = w.header()
+	}
+	if entry.DB != w.db {
+		w.db = entry.DB
+		buf = append(buf, opSelectDB)
+		buf = appendLength(buf, uint64(entry.DB))
+	}
+	if !entry.ExpireAt.IsZero() {
+		buf = append(buf, opExpireTimeMS)
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(max(entry.ExpireAt.UnixMilli(), 1)))
+	}
+	buf = append(buf, entry.Type)
+	buf = appendString(buf, entry.Key)
+	buf = append(buf, entry.Value...)
+
+	if err := w.emit(buf); err != nil {
+		return err
+	}
+
+	w.written[entry.Key] = struct{}{}
+	w.count++
+	return nil
+}
+
+// fits returns an error if a value of RDB version version can't be written to
+// the file.
+func (w *Writer) fits(version int) error {
+	if w.version != 0 && version > w.version {
+		return fmt.Errorf("value of RDB version %d can't be written to a file of version %d", version, w.version)
+	}
+	return nil
+}
+
+// header returns the header of the file.
+func (w *Writer) header() []byte {
+	return fmt.Appendf(nil, "REDIS%04d", w.version)
+}
+
+// emit writes bytes of the file and adds them to its checksum.
+func (w *Writer) emit(p []byte) error {
+	w.crc = CRC64(w.crc, p)
+	if _, err := w.w.Write(p); err != nil {
+		// The file is unusable after a partial write.
+		w.err = fmt.Errorf("failed to write RDB file: %w", err)
+		return w.err
+	}
+	return nil
+}
+
+// RestoreKeys appends the keys to the file. Keys written before are
+// conflicts, which can't be overwritten in an RDB file.
+func (w *Writer) RestoreKeys(_ context.Context, data []migrate.KeyData, behavior migrate.ConflictBehavior) ([]migrate.RestoreResult, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	results := make([]migrate.RestoreResult, len(data))
+
+	for i, d := range data {
+		results[i].Key = d.Key
+
+		if _, ok := w.written[d.Key]; ok {
+			switch behavior {
+			case migrate.SkipOnConflict:
+				results[i].Outcome = migrate.OutcomeSkipped
+			case migrate.OverwriteOnConflict:
+				results[i].Outcome = migrate.OutcomeFailed
+				results[i].Err = errors.New("key has already been written to the RDB file, and can't be overwritten")
+			default:
+				results[i].Outcome = migrate.OutcomeConflict
+			}
+			continue
+		}
+
+		if d.Data == "" {
+			results[i].Outcome = migrate.OutcomeFailed
+			results[i].Err = errors.New("only keys read with DUMP can be written to an RDB file")
+			continue
+		}
+
+		typ, value, version, err := ParsePayload([]byte(d.Data))
+		if err != nil {
+			results[i].Outcome = migrate.OutcomeFailed
+			results[i].Err = err
+			continue
+		}
+		if err := w.fits(version); err != nil {
+			results[i].Outcome = migrate.OutcomeFailed
+			results[i].Err = err
+			continue
+		}
+
+		entry := &Entry{Key: d.Key, Type: typ, Value: value}
+		if d.TTL > 0 {
+			entry.ExpireAt = now.Add(d.TTL)
+		}
+
+		if err := w.write(entry, version); err != nil {
+			return nil, err
+		}
+		results[i].Outcome = migrate.OutcomeCreated
+	}
+
+	return results, nil
+}
+
+// Count returns the number of keys written so far.
+func (w *Writer) Count() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.count
+}
+
+// Close writes the end of the file and its checksum, and closes its output.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer func() { w.err = w.ErrClosed() }()
+
+	if w.err != nil {
+		return w.CloseOutput(w.err)
+	}
+
+	var buf []byte
+	if w.version == 0 {
+		w.version = emptyVersion
+		buf = w.header()
+	}
+	if err := w.emit(append(buf, opEOF)); err != nil {
+		return w.CloseOutput(err)
+	}
+	if _, err := w.w.Write(binary.LittleEndian.AppendUint64(nil, w.crc)); err != nil {
+		return w.CloseOutput(fmt.Errorf("failed to write RDB file: %w", err))
+	}
+	if err := w.w.Flush(); err != nil {
+		return w.CloseOutput(fmt.Errorf("failed to write RDB file: %w", err))
+	}
+
+	return w.CloseOutput(nil)
+}
+
+// Abort flushes the keys written so far without ending the file, which marks
+// it as incomplete, and closes its output.
+func (w *Writer) Abort() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer func() { w.err = w.ErrClosed() }()
+
+	var err error
+	if w.err == nil {
+		err = w.w.Flush()
+	}
+	return w.CloseOutput(err)
+}
+
+// appendLength appends a length in the RDB length encoding.
+func appendLength(buf []byte, n uint64) []byte {
+	switch {
+	case n < 1<<6:
+		return append(buf, byte(n))
+	case n < 1<<14:
+		return append(buf, byte(n>>8)|0x40, byte(n))
+	case n <= 1<<32-1:
+		return binary.BigEndian.AppendUint32(append(buf, 0x80), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0x81), n)
+	}
+}
+
+// appendString appends a length-prefixed string.
+func appendString(buf []byte, s string) []byte {
+	return append(appendLength(buf, uint64(len(s))), s...)
+}
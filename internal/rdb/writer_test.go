@@ -0,0 +1,141 @@
+package rdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+// readEntries reads the keys of an RDB file.
+func readEntries(t *testing.T, data []byte) (*Reader, []*Entry, error) {
+	t.Helper()
+
+	reader, err := NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	var entries []*Entry
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			return reader, entries, nil
+		}
+		if err != nil {
+			return reader, entries, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewWriter(&buf)
+
+	results, err := writer.RestoreKeys(context.Background(), []migrate.KeyData{
+		{Key: "user:1", Data: string(DumpPayload(TypeString, []byte("\x03one"), 11))},
+		{Key: "user:2", Data: string(DumpPayload(TypeSet, []byte("\x02\x01a\x01b"), 11)), TTL: time.Hour},
+		{Key: "user:3", Data: string(DumpPayload(TypeString, []byte("\x03new"), 12))},
+		{Key: "user:4", Data: string(DumpPayload(TypeString, []byte("\x03old"), 9))},
+	}, migrate.ErrorOnConflict)
+	require.NoError(t, err)
+	assert.Equal(t, migrate.OutcomeCreated, results[0].Outcome)
+	assert.Equal(t, migrate.OutcomeCreated, results[1].Outcome)
+	assert.Equal(t, migrate.OutcomeFailed, results[2].Outcome, "values newer than the file")
+	assert.ErrorContains(t, results[2].Err, "RDB version 12")
+	assert.Equal(t, migrate.OutcomeCreated, results[3].Outcome, "values older than the file")
+	assert.Equal(t, int64(3), writer.Count())
+
+	require.NoError(t, writer.Close())
+
+	reader, entries, err := readEntries(t, buf.Bytes())
+	require.NoError(t, err, "the checksum matches")
+	assert.Equal(t, 11, reader.Version(), "the version of the first key")
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "user:1", entries[0].Key)
+	assert.Equal(t, TypeString, entries[0].Type)
+	assert.Equal(t, []byte("\x03one"), entries[0].Value)
+	assert.True(t, entries[0].ExpireAt.IsZero())
+
+	assert.Equal(t, "user:2", entries[1].Key)
+	assert.Equal(t, TypeSet, entries[1].Type)
+	assert.Equal(t, []byte("\x02\x01a\x01b"), entries[1].Value)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), entries[1].ExpireAt, time.Minute)
+
+	assert.Equal(t, "user:4", entries[2].Key)
+	assert.Equal(t, 0, entries[2].DB)
+}
+
+func TestWriter_Conflicts(t *testing.T) {
+	payload := string(DumpPayload(TypeString, []byte("\x03one"), 11))
+
+	for _, tt := range []struct {
+		behavior migrate.ConflictBehavior
+		outcome  migrate.Outcome
+	}{
+		{migrate.ErrorOnConflict, migrate.OutcomeConflict},
+		{migrate.SkipOnConflict, migrate.OutcomeSkipped},
+		{migrate.OverwriteOnConflict, migrate.OutcomeFailed},
+	} {
+		t.Run(tt.behavior.String(), func(t *testing.T) {
+			writer := NewWriter(io.Discard)
+			_, err := writer.RestoreKeys(context.Background(), []migrate.KeyData{{Key: "user:1", Data: payload}}, tt.behavior)
+			require.NoError(t, err)
+
+			results, err := writer.RestoreKeys(context.Background(), []migrate.KeyData{
+				{Key: "user:1", Data: payload},
+				{Key: "user:2"},
+				{Key: "user:3", Data: "corrupt payload"},
+			}, tt.behavior)
+			require.NoError(t, err)
+			assert.Equal(t, tt.outcome, results[0].Outcome)
+			assert.Equal(t, migrate.OutcomeFailed, results[1].Outcome, "values read with native commands")
+			assert.Equal(t, migrate.OutcomeFailed, results[2].Outcome)
+			assert.Equal(t, int64(1), writer.Count())
+		})
+	}
+}
+
+func TestWriter_Databases(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewWriter(&buf)
+	require.NoError(t, writer.Write(&Entry{DB: 0, Key: "a", Type: TypeString, Value: []byte("\x01a")}, 11))
+	require.NoError(t, writer.Write(&Entry{DB: 3, Key: "b", Type: TypeString, Value: []byte("\x01b")}, 11))
+	require.NoError(t, writer.Write(&Entry{DB: 3, Key: "c", Type: TypeString, Value: []byte("\x01c")}, 11))
+	require.NoError(t, writer.Close())
+
+	_, entries, err := readEntries(t, buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, []int{0, 3, 3}, []int{entries[0].DB, entries[1].DB, entries[2].DB})
+}
+
+func TestWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewWriter(&buf).Close())
+
+	reader, entries, err := readEntries(t, buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, emptyVersion, reader.Version())
+	assert.Empty(t, entries)
+}
+
+func TestWriter_Abort(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewWriter(&buf)
+	require.NoError(t, writer.Write(&Entry{Key: "a", Type: TypeString, Value: []byte("\x01a")}, 11))
+	require.NoError(t, writer.Abort())
+
+	_, entries, err := readEntries(t, buf.Bytes())
+	assert.Len(t, entries, 1)
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF), "an aborted file has no end")
+
+	assert.Error(t, writer.Write(&Entry{Key: "b", Type: TypeString, Value: []byte("\x01b")}, 11))
+}
@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+// IdleTimes reads the idle times of keys with a pipelined OBJECT IDLETIME,
+// which doesn't count as an access itself.
+func (c *Client) IdleTimes(ctx context.Context, keys []string) (map[string]time.Duration, error) {
+	cmds, err := c.readObject(ctx, keys, func(pipe redis.Pipeliner, key string) redis.Cmder {
+		return pipe.ObjectIdleTime(ctx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	idleTimes := make(map[string]time.Duration, len(keys))
+	for i, key := range keys {
+		if cmd := cmds[i].(*redis.DurationCmd); cmd.Err() == nil {
+			idleTimes[key] = cmd.Val()
+		}
+	}
+	return idleTimes, nil
+}
+
+// AccessFrequencies reads the access counters of keys with a pipelined
+// OBJECT FREQ, which doesn't count as an access itself.
+func (c *Client) AccessFrequencies(ctx context.Context, keys []string) (map[string]int64, error) {
+	cmds, err := c.readObject(ctx, keys, func(pipe redis.Pipeliner, key string) redis.Cmder {
+		return pipe.ObjectFreq(ctx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	frequencies := make(map[string]int64, len(keys))
+	for i, key := range keys {
+		if cmd := cmds[i].(*redis.IntCmd); cmd.Err() == nil {
+			frequencies[key] = cmd.Val()
+		}
+	}
+	return frequencies, nil
+}
+
+// readObject queues an OBJECT subcommand for every key and returns the
+// commands in input order. Keys that don't exist fail with [redis.Nil], while
+// errors telling that the server can't report what was asked for abort the
+// whole read.
+func (c *Client) readObject(ctx context.Context, keys []string, queue func(pipe redis.Pipeliner, key string) redis.Cmder) ([]redis.Cmder, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	var cmds []redis.Cmder
+	err := c.retryOnFailover(ctx, func() error {
+		pipe := c.client.Pipeline()
+		cmds = make([]redis.Cmder, len(keys))
+		for i, key := range keys {
+			cmds[i] = queue(pipe, key)
+		}
+
+		_, err := pipe.Exec(ctx)
+
+		var redisErr redis.Error
+		if err != nil && (isFailoverError(err) || !errors.As(err, &redisErr)) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil && !errors.Is(err, redis.Nil) {
+			return nil, accessError(err)
+		}
+	}
+
+	return cmds, nil
+}
+
+// accessError explains the replies of OBJECT IDLETIME and OBJECT FREQ under
+// an eviction policy that doesn't track what was asked for, and the replies
+// to OBJECT being disabled or denied.
+func accessError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "LFU maxmemory policy is selected"):
+		return fmt.Errorf("%w: idle times are not tracked under an LFU maxmemory-policy, filter by access frequency instead", migrate.ErrAccessUnavailable)
+	case strings.Contains(msg, "LFU maxmemory policy is not selected"):
+		return fmt.Errorf("%w: access frequencies are only tracked under an LFU maxmemory-policy, filter by idle time instead", migrate.ErrAccessUnavailable)
+	case strings.HasPrefix(msg, "NOPERM"), strings.Contains(msg, "unknown command"), strings.Contains(msg, "unknown subcommand"):
+		return fmt.Errorf("%w: OBJECT is not available: %w", migrate.ErrAccessUnavailable, err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,269 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+// stringChunkSize is the number of bytes of a string read at once, as strings
+// have no elements to count.
+const stringChunkSize = 1 << 20
+
+// ReadChunks reads the value of a big key in chunks: hashes, sets and sorted
+// sets with HSCAN, SSCAN and ZSCAN, lists with LRANGE, streams with XRANGE and
+// strings with GETRANGE. Only the entries of streams are read, consumer groups
+// are not.
+//
+// The chunks are not a consistent snapshot of the value. Elements changed
+// while the key is read may or may not be part of the copy.
+func (c *Client) ReadChunks(ctx context.Context, key, typ string, count int, fn func(chunk *migrate.Value) error) (time.Duration, error) {
+	var err error
+	switch typ {
+	case "string":
+		err = c.readStringChunks(ctx, key, fn)
+	case "hash", "set", "zset":
+		err = c.scanChunks(ctx, key, typ, count, fn)
+	case "list":
+		err = c.readListChunks(ctx, key, count, fn)
+	case "stream":
+		err = c.readStreamChunks(ctx, key, count, fn)
+	default:
+		err = fmt.Errorf("type %q can't be read in chunks", typ)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var ttl time.Duration
+	err = c.retryOnFailover(ctx, func() (err error) {
+		ttl, err = c.client.PTTL(ctx, key).Result()
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read TTL: %w", err)
+	}
+
+	return remainingTTL(ttl), nil
+}
+
+func (c *Client) readStringChunks(ctx context.Context, key string, fn func(chunk *migrate.Value) error) error {
+	for offset := int64(0); ; offset += stringChunkSize {
+		var chunk string
+		err := c.retryOnFailover(ctx, func() (err error) {
+			chunk, err = c.client.GetRange(ctx, key, offset, offset+stringChunkSize-1).Result()
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read string: %w", err)
+		}
+
+		if chunk == "" {
+			return nil
+		}
+
+		if err := fn(&migrate.Value{Type: "string", String: chunk}); err != nil {
+			return err
+		}
+
+		if len(chunk) < stringChunkSize {
+			return nil
+		}
+	}
+}
+
+func (c *Client) scanChunks(ctx context.Context, key, typ string, count int, fn func(chunk *migrate.Value) error) error {
+	var cursor uint64
+	for {
+		var elements []string
+		err := c.retryOnFailover(ctx, func() (err error) {
+			switch typ {
+			case "hash":
+				elements, cursor, err = c.client.HScan(ctx, key, cursor, "", int64(count)).Result()
+			case "set":
+				elements, cursor, err = c.client.SScan(ctx, key, cursor, "", int64(count)).Result()
+			case "zset":
+				elements, cursor, err = c.client.ZScan(ctx, key, cursor, "", int64(count)).Result()
+			}
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scan %s: %w", typ, err)
+		}
+
+		if len(elements) > 0 {
+			chunk, err := scannedValue(typ, elements)
+			if err != nil {
+				return err
+			}
+			if err := fn(chunk); err != nil {
+				return err
+			}
+		}
+
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// scannedValue converts the elements returned by HSCAN, SSCAN or ZSCAN into a
+// value.
+func scannedValue(typ string, elements []string) (*migrate.Value, error) {
+	value := &migrate.Value{Type: typ}
+
+	switch typ {
+	case "hash":
+		value.Hash = make(map[string]string, len(elements)/2)
+		for i := 0; i+1 < len(elements); i += 2 {
+			value.Hash[elements[i]] = elements[i+1]
+		}
+	case "set":
+		value.Set = elements
+	case "zset":
+		for i := 0; i+1 < len(elements); i += 2 {
+			score, err := strconv.ParseFloat(elements[i+1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid score %q of member %q: %w", elements[i+1], elements[i], err)
+			}
+			value.ZSet = append(value.ZSet, migrate.ScoredMember{Member: elements[i], Score: score})
+		}
+	}
+
+	return value, nil
+}
+
+func (c *Client) readListChunks(ctx context.Context, key string, count int, fn func(chunk *migrate.Value) error) error {
+	for start := int64(0); ; start += int64(count) {
+		var elements []string
+		err := c.retryOnFailover(ctx, func() (err error) {
+			elements, err = c.client.LRange(ctx, key, start, start+int64(count)-1).Result()
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read list: %w", err)
+		}
+
+		if len(elements) == 0 {
+			return nil
+		}
+
+		if err := fn(&migrate.Value{Type: "list", List: elements}); err != nil {
+			return err
+		}
+
+		if len(elements) < count {
+			return nil
+		}
+	}
+}
+
+func (c *Client) readStreamChunks(ctx context.Context, key string, count int, fn func(chunk *migrate.Value) error) error {
+	start := "-"
+	for {
+		var reply any
+		err := c.retryOnFailover(ctx, func() (err error) {
+			reply, err = c.client.Do(ctx, "XRANGE", key, start, "+", "COUNT", count).Result()
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read stream: %w", err)
+		}
+
+		entries, err := parseStreamEntries(reply)
+		if err != nil {
+			return fmt.Errorf("failed to read stream: %w", err)
+		}
+
+		if len(entries) == 0 {
+			return nil
+		}
+
+		if err := fn(&migrate.Value{Type: "stream", Stream: entries}); err != nil {
+			return err
+		}
+
+		if len(entries) < count {
+			return nil
+		}
+
+		// Continues after the last entry read.
+		start = "(" + entries[len(entries)-1].ID
+	}
+}
+
+// WriteChunk adds the elements of chunk to the temporary key of key.
+//
+// Chunks setting elements of hashes, sets and sorted sets are retried during
+// a failover. Chunks appended to strings, lists and streams are not, as an
+// interrupted attempt may have appended them already. Their error wraps
+// [migrate.ErrPartialChunk] instead, so that the copy is restarted.
+func (c *Client) WriteChunk(ctx context.Context, key string, chunk *migrate.Value) error {
+	tmp, err := c.tempKeyFor(key)
+	if err != nil {
+		return err
+	}
+
+	write := func() error {
+		pipe := c.client.Pipeline()
+		queueValue(ctx, pipe, tmp, chunk)
+		_, err := pipe.Exec(ctx)
+		return err
+	}
+
+	if !appendsElements(chunk.Type) {
+		err = c.retryOnFailover(ctx, write)
+	} else if err = write(); isFailoverError(err) {
+		err = fmt.Errorf("%w: %w", migrate.ErrPartialChunk, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write chunk: %w", err)
+	}
+
+	return nil
+}
+
+// appendsElements reports whether chunks of type typ are appended to the
+// value written so far, so that writing them twice duplicates elements.
+func appendsElements(typ string) bool {
+	return typ == "string" || typ == "list" || typ == "stream"
+}
+
+// CommitChunks renames the temporary key of key into place with RENAMENX, or
+// RENAME when overwriting, and sets its TTL. It isn't retried during a
+// failover, as the temporary key may have been renamed already.
+func (c *Client) CommitChunks(ctx context.Context, key string, ttl time.Duration, behavior migrate.ConflictBehavior) (migrate.RestoreResult, error) {
+	tmp, err := c.tempKeyFor(key)
+	if err != nil {
+		return migrate.RestoreResult{}, err
+	}
+
+	pipe := c.client.Pipeline()
+	queued := queueCommit(ctx, pipe, tmp, key, ttl, behavior)
+	_, err = pipe.Exec(ctx)
+
+	var redisErr redis.Error
+	if err != nil && (isFailoverError(err) || !errors.As(err, &redisErr)) {
+		return migrate.RestoreResult{}, fmt.Errorf("failed to rename chunks into place: %w", err)
+	}
+
+	return queued.result(key, behavior), nil
+}
+
+// DiscardChunks deletes the temporary key of key.
+func (c *Client) DiscardChunks(ctx context.Context, key string) error {
+	tmp, err := c.tempKeyFor(key)
+	if err != nil {
+		return err
+	}
+
+	return c.retryOnFailover(ctx, func() error {
+		return c.client.Del(ctx, tmp).Err()
+	})
+}
@@ -2,8 +2,14 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -11,47 +17,183 @@ import (
 	"github.com/pucke-dev/go-redismigrate/internal/migrate"
 )
 
+const (
+	// clusterScheme selects a Redis Cluster deployment instead of a single node.
+	clusterScheme = "redis+cluster"
+
+	// clusterTLSScheme selects a Redis Cluster deployment reached over TLS.
+	clusterTLSScheme = "rediss+cluster"
+)
+
 type Client struct {
-	client *redis.Client
+	// client is either a single-node or Sentinel-managed *redis.Client, or a
+	// *redis.ClusterClient.
+	client redis.UniversalClient
+
+	// failover is set for Sentinel-managed clients, whose operations are
+	// retried while a failover is in progress.
+	failover bool
+
+	// scanTypeOnce guards the detection of scanType, which is set if the
+	// server supports the TYPE option of SCAN.
+	scanTypeOnce sync.Once
+	scanType     bool
+}
+
+// Option configures optional behaviour of a [Client].
+type Option func(*options)
+
+type options struct {
+	// tlsConfig replaces the TLS configuration derived from the connection string.
+	tlsConfig *tls.Config
+}
+
+// WithTLSConfig connects over TLS using the given configuration, regardless of
+// whether the connection string uses a TLS scheme. A nil config is ignored.
+//
+// If config.ServerName is empty, it is derived from the address of each node.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *options) {
+		if config != nil {
+			o.tlsConfig = config
+		}
+	}
 }
 
-func NewClient(connStr string) (*Client, error) {
-	opts, err := redis.ParseURL(connStr)
+// NewClient connects to the Redis deployment described by connStr.
+//
+// redis:// and rediss:// URLs connect to a single node. redis+cluster:// and
+// rediss+cluster:// URLs connect to a Redis Cluster, seeded from the URL host
+// and any additional addr query parameters
+// (e.g. redis+cluster://host1:7000?addr=host2:7000&addr=host3:7000).
+// redis+sentinel:// and rediss+sentinel:// URLs connect to the master of a
+// Sentinel-managed deployment, see [parseSentinelURL] for details.
+func NewClient(connStr string, opts ...Option) (*Client, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	client, err := newUniversalClient(connStr, o)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse connection string: %w", err)
 	}
 
-	client := redis.NewClient(opts)
-
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
+	_, failover := client.(*redis.Client)
+	failover = failover && isSentinelURL(connStr)
+
 	return &Client{
-		client: client,
+		client:   client,
+		failover: failover,
 	}, nil
 }
 
+func isSentinelURL(connStr string) bool {
+	return strings.HasPrefix(connStr, sentinelScheme+"://") || strings.HasPrefix(connStr, sentinelTLSScheme+"://")
+}
+
+func newUniversalClient(connStr string, o options) (redis.UniversalClient, error) {
+	scheme, rest, ok := strings.Cut(connStr, "://")
+	if !ok {
+		return nil, fmt.Errorf("missing URL scheme in %q", connStr)
+	}
+
+	switch scheme {
+	case clusterScheme, clusterTLSScheme:
+		opts, err := redis.ParseClusterURL(strings.TrimSuffix(scheme, "+cluster") + "://" + rest)
+		if err != nil {
+			return nil, err
+		}
+		if o.tlsConfig != nil {
+			opts.TLSConfig = o.tlsConfig
+		}
+		return redis.NewClusterClient(opts), nil
+
+	case sentinelScheme, sentinelTLSScheme:
+		opts, err := parseSentinelURL(connStr)
+		if err != nil {
+			return nil, err
+		}
+		if o.tlsConfig != nil {
+			opts.TLSConfig = o.tlsConfig
+		}
+		return redis.NewFailoverClient(opts), nil
+
+	default:
+		opts, err := redis.ParseURL(connStr)
+		if err != nil {
+			return nil, err
+		}
+		if o.tlsConfig != nil {
+			opts.TLSConfig = o.tlsConfig
+		}
+		return redis.NewClient(opts), nil
+	}
+}
+
 func (c *Client) Close() error {
 	return c.client.Close()
 }
 
-// ScanKeys streams keys matching a pattern to a channel in batches.
-func (c *Client) ScanKeys(ctx context.Context, pattern string, batchSize int, keysChan chan<- []string) error {
-	var cursor uint64
+// forEachNode calls fn for every node holding a part of the keyspace: the
+// server itself for single-node deployments, or every master in a cluster.
+func (c *Client) forEachNode(ctx context.Context, fn func(ctx context.Context, node *redis.Client) error) error {
+	switch client := c.client.(type) {
+	case *redis.ClusterClient:
+		return client.ForEachMaster(ctx, fn)
+	case *redis.Client:
+		return fn(ctx, client)
+	default:
+		return fmt.Errorf("unsupported Redis client type %T", c.client)
+	}
+}
+
+// ScanKeys streams keys matching the scan options to a channel in batches.
+//
+// In a cluster every master is scanned concurrently, so batches of different
+// nodes may interleave on the channel. Each master is a separate shard
+// identified by its address. The last batch of every shard is sent even if it
+// is empty, to mark the shard as scanned completely.
+func (c *Client) ScanKeys(ctx context.Context, opts migrate.ScanOptions, batches chan<- migrate.KeyBatch) error {
+	return c.forEachNode(ctx, func(ctx context.Context, node *redis.Client) error {
+		shard := c.shardID(node)
+
+		cursor, ok, err := resumeCursor(opts.Resume, shard)
+		if err != nil || !ok {
+			return err
+		}
 
+		return c.scanNode(ctx, node, shard, cursor, opts, batches)
+	})
+}
+
+func (c *Client) scanNode(ctx context.Context, node *redis.Client, shard string, cursor uint64, opts migrate.ScanOptions, batches chan<- migrate.KeyBatch) error {
 	for {
-		keys, newCursor, err := c.client.Scan(ctx, cursor, pattern, int64(batchSize)).Result()
+		keys, newCursor, err := c.scan(ctx, node, cursor, opts)
 		if err != nil {
 			return err
 		}
 
-		if len(keys) > 0 {
+		if len(keys) > 0 || newCursor == 0 {
+			batch := migrate.KeyBatch{
+				Keys:   keys,
+				Shard:  shard,
+				Cursor: strconv.FormatUint(cursor, 10),
+			}
+			if newCursor != 0 {
+				batch.Next = strconv.FormatUint(newCursor, 10)
+			}
+
 			select {
-			case keysChan <- keys:
+			case batches <- batch:
 			case <-ctx.Done():
 				return ctx.Err()
 			}
@@ -66,41 +208,160 @@ func (c *Client) ScanKeys(ctx context.Context, pattern string, batchSize int, ke
 	return nil
 }
 
-func (c *Client) CountKeys(ctx context.Context, pattern string, batchSize int) (int64, error) {
-	var count int64
-	var cursor uint64
+// shardID identifies node as a shard of the keyspace, see [migrate.KeyBatch].
+func (c *Client) shardID(node *redis.Client) string {
+	if _, ok := c.client.(*redis.ClusterClient); ok {
+		return node.Options().Addr
+	}
+	return ""
+}
 
-	for {
-		keys, newCursor, err := c.client.Scan(ctx, cursor, pattern, int64(batchSize)).Result()
+// resumeCursor returns the cursor to start the scan of shard from, or false
+// if the shard has been scanned completely before.
+func resumeCursor(resume map[string]string, shard string) (uint64, bool, error) {
+	cursor, ok := resume[shard]
+	switch {
+	case !ok:
+		return 0, true, nil
+	case cursor == "":
+		return 0, false, nil
+	}
+
+	n, err := strconv.ParseUint(cursor, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid resume cursor %q for shard %q: %w", cursor, shard, err)
+	}
+
+	return n, true, nil
+}
+
+// CountKeys counts keys matching the scan options. The count always covers
+// the whole keyspace, regardless of [migrate.ScanOptions.Resume].
+func (c *Client) CountKeys(ctx context.Context, opts migrate.ScanOptions) (int64, error) {
+	var count atomic.Int64
+
+	err := c.forEachNode(ctx, func(ctx context.Context, node *redis.Client) error {
+		var cursor uint64
+
+		for {
+			keys, newCursor, err := c.scan(ctx, node, cursor, opts)
+			if err != nil {
+				return err
+			}
+
+			count.Add(int64(len(keys)))
+			cursor = newCursor
+
+			if cursor == 0 {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count.Load(), nil
+}
+
+// scan fetches a single SCAN page from node.
+//
+// A single type in [migrate.ScanOptions.Types] is matched by the TYPE option
+// of SCAN if the server supports it. Otherwise, the keys of the page are
+// filtered with a pipelined TYPE check.
+func (c *Client) scan(ctx context.Context, node *redis.Client, cursor uint64, opts migrate.ScanOptions) ([]string, uint64, error) {
+	var keys []string
+	var next uint64
+
+	typed := len(opts.Types) == 1 && c.supportsScanType(ctx, node)
+
+	err := c.retryOnFailover(ctx, func() (err error) {
+		if typed {
+			keys, next, err = node.ScanType(ctx, cursor, opts.Pattern, int64(opts.BatchSize), opts.Types[0]).Result()
+		} else {
+			keys, next, err = node.Scan(ctx, cursor, opts.Pattern, int64(opts.BatchSize)).Result()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(opts.Types) > 0 && !typed {
+		keys, err = c.filterTypes(ctx, node, keys, opts.Types)
 		if err != nil {
-			return 0, err
+			return nil, 0, err
 		}
+	}
 
-		count += int64(len(keys))
-		cursor = newCursor
+	return keys, next, nil
+}
 
-		if cursor == 0 {
-			break
+// supportsScanType reports whether the server supports SCAN with the TYPE
+// option, which was added in Redis 6.0. It is detected once per client.
+func (c *Client) supportsScanType(ctx context.Context, node *redis.Client) bool {
+	c.scanTypeOnce.Do(func() {
+		err := node.Do(ctx, "SCAN", 0, "COUNT", 1, "TYPE", "string").Err()
+		c.scanType = err == nil
+	})
+	return c.scanType
+}
+
+// filterTypes returns the keys of node whose type is one of types. Keys that
+// have been deleted since the scan are removed as well.
+func (c *Client) filterTypes(ctx context.Context, node *redis.Client, keys []string, types []string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	var cmds []redis.Cmder
+	err := c.retryOnFailover(ctx, func() (err error) {
+		pipe := node.Pipeline()
+		for _, key := range keys {
+			pipe.Type(ctx, key)
+		}
+		cmds, err = pipe.Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key types: %w", err)
+	}
+
+	var matched []string
+	for i, key := range keys {
+		if slices.Contains(types, cmds[i].(*redis.StatusCmd).Val()) {
+			matched = append(matched, key)
 		}
 	}
 
-	return count, nil
+	return matched, nil
 }
 
+// DumpKeys dumps multiple keys and returns their data and TTL.
+//
+// Against a cluster the pipeline is split per hash slot owner, and MOVED/ASK
+// redirections caused by resharding are followed transparently.
 func (c *Client) DumpKeys(ctx context.Context, keys []string) ([]migrate.KeyData, error) {
 	if len(keys) == 0 {
 		return nil, nil
 	}
 
-	pipe := c.client.Pipeline()
+	var results []redis.Cmder
+	err := c.retryOnFailover(ctx, func() (err error) {
+		pipe := c.client.Pipeline()
 
-	for _, key := range keys {
-		pipe.Dump(ctx, key)
-		pipe.PTTL(ctx, key)
-	}
+		for _, key := range keys {
+			pipe.Dump(ctx, key)
+			pipe.PTTL(ctx, key)
+		}
 
-	results, err := pipe.Exec(ctx)
-	if err != nil && !errors.Is(err, redis.Nil) {
+		results, err = pipe.Exec(ctx)
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to dump keys: %w", err)
 	}
 
@@ -117,59 +378,221 @@ func (c *Client) DumpKeys(ctx context.Context, keys []string) ([]migrate.KeyData
 		keyData = append(keyData, migrate.KeyData{
 			Key:  key,
 			Data: dumpCmd.Val(),
-			TTL:  ttlCmd.Val(),
+			TTL:  remainingTTL(ttlCmd.Val()),
 		})
 	}
 
 	return keyData, nil
 }
 
-func (c *Client) RestoreKeys(ctx context.Context, data []migrate.KeyData, behavior migrate.ConflictBehavior) ([]string, error) {
+// remainingTTL converts a PTTL reply into a TTL as documented for
+// [migrate.KeyData]: -1 for keys without expiry becomes 0, and -2 for keys
+// that no longer exist becomes [migrate.ExpiredTTL].
+func remainingTTL(ttl time.Duration) time.Duration {
+	switch ttl {
+	case -1:
+		return 0
+	case -2:
+		return migrate.ExpiredTTL
+	default:
+		return ttl
+	}
+}
+
+// RestoreKeys restores multiple keys with conflict handling. Payloads the
+// server can't load fail with [migrate.ErrIncompatiblePayload]; keys carrying
+// a [migrate.Value] instead of a payload are written with native commands.
+//
+// Like [Client.DumpKeys], the pipeline is routed per hash slot in a cluster.
+func (c *Client) RestoreKeys(ctx context.Context, data []migrate.KeyData, behavior migrate.ConflictBehavior) ([]migrate.RestoreResult, error) {
 	if len(data) == 0 {
 		return nil, nil
 	}
 
-	pipe := c.client.Pipeline()
-	var keysToRestore []migrate.KeyData
-
+	// Keys read by ReadValues carry a value instead of a payload, and are
+	// written with native commands.
+	var payloads, values []migrate.KeyData
+	var isValue []bool
 	for _, info := range data {
-		switch behavior {
-		case migrate.SkipOnConflict:
-			if c.client.Exists(ctx, info.Key).Val() > 0 {
-				continue
-			}
-			pipe.Restore(ctx, info.Key, info.TTL, info.Data)
+		native := info.Data == "" && info.Value != nil
+		if native {
+			values = append(values, info)
+		} else {
+			payloads = append(payloads, info)
+		}
+		isValue = append(isValue, native)
+	}
 
-		case migrate.OverwriteOnConflict:
-			pipe.RestoreReplace(ctx, info.Key, info.TTL, info.Data)
+	payloadResults, err := c.restorePayloads(ctx, payloads, behavior)
+	if err != nil {
+		return nil, err
+	}
+
+	var valueResults []migrate.RestoreResult
+	if len(values) > 0 {
+		valueResults, err = c.restoreValues(ctx, values, behavior)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-		case migrate.ErrorOnConflict:
-			pipe.Restore(ctx, info.Key, info.TTL, info.Data)
+	results := make([]migrate.RestoreResult, 0, len(data))
+	for _, native := range isValue {
+		if native {
+			results, valueResults = append(results, valueResults[0]), valueResults[1:]
+		} else {
+			results, payloadResults = append(results, payloadResults[0]), payloadResults[1:]
 		}
+	}
 
-		keysToRestore = append(keysToRestore, info)
+	return results, nil
+}
+
+// restorePayloads restores DUMP payloads with RESTORE.
+func (c *Client) restorePayloads(ctx context.Context, data []migrate.KeyData, behavior migrate.ConflictBehavior) ([]migrate.RestoreResult, error) {
+	if len(data) == 0 {
+		return nil, nil
 	}
 
-	results, err := pipe.Exec(ctx)
+	var results []redis.Cmder
+	var attempts int
+
+	err := c.retryOnFailover(ctx, func() (err error) {
+		attempts++
+		pipe := c.client.Pipeline()
+
+		// Conflicts are detected from BUSYKEY replies rather than by checking
+		// for existing keys first, which keeps the batch in a single round-trip.
+		// When overwriting, an EXISTS is queued in front of every RESTORE to
+		// tell created and overwritten keys apart.
+		for _, info := range data {
+			if behavior == migrate.OverwriteOnConflict {
+				pipe.Exists(ctx, info.Key)
+				pipe.RestoreReplace(ctx, info.Key, info.TTL, info.Data)
+			} else {
+				pipe.Restore(ctx, info.Key, info.TTL, info.Data)
+			}
+		}
+
+		results, err = pipe.Exec(ctx)
+
+		// Errors of individual commands are classified per key below. Only
+		// failures of the pipeline as a whole abort the batch.
+		var redisErr redis.Error
+		if err != nil && (isFailoverError(err) || !errors.As(err, &redisErr)) {
+			return err
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to restore keys: %w", err)
 	}
 
-	var successfulKeys []string
+	restoreResults := make([]migrate.RestoreResult, len(data))
+	for i, info := range data {
+		result := migrate.RestoreResult{Key: info.Key, Outcome: migrate.OutcomeCreated}
+
+		var restoreErr error
+		if behavior == migrate.OverwriteOnConflict {
+			restoreErr = results[i*2+1].Err()
+			if restoreErr == nil && results[i*2].(*redis.IntCmd).Val() > 0 {
+				result.Outcome = migrate.OutcomeOverwritten
+			}
+		} else {
+			restoreErr = results[i].Err()
+		}
+
+		switch {
+		case restoreErr == nil:
+		case isBusyKeyError(restoreErr) && behavior == migrate.SkipOnConflict:
+			result.Outcome = migrate.OutcomeSkipped
+		case isBusyKeyError(restoreErr):
+			result.Outcome = migrate.OutcomeConflict
+		case isIncompatiblePayloadError(restoreErr):
+			result.Outcome = migrate.OutcomeFailed
+			result.Err = fmt.Errorf("%w: %w", migrate.ErrIncompatiblePayload, restoreErr)
+		default:
+			result.Outcome = migrate.OutcomeFailed
+			result.Err = restoreErr
+		}
+
+		restoreResults[i] = result
+	}
+
+	// RESTORE without REPLACE is only safe to retry with the conflicts of a
+	// retried pipeline resolved. Keys restored by an interrupted attempt hold
+	// the same payload, while a retried RESTORE REPLACE at most reports keys
+	// it created as overwritten.
+	if attempts > 1 && behavior != migrate.OverwriteOnConflict {
+		if err := c.resolveRetriedConflicts(ctx, data, restoreResults); err != nil {
+			return nil, err
+		}
+	}
+
+	return restoreResults, nil
+}
+
+// resolveRetriedConflicts marks the conflicting keys of a retried restore as
+// created if their DUMP payload equals the one restored, as they have been
+// restored by the interrupted attempt. Keys whose payload the destination
+// serializes differently remain conflicts.
+func (c *Client) resolveRetriedConflicts(ctx context.Context, data []migrate.KeyData, results []migrate.RestoreResult) error {
+	payloads := make(map[string]string)
+	var keys []string
 	for i, result := range results {
-		if result.Err() != nil {
-			continue
+		if result.Outcome == migrate.OutcomeConflict || result.Outcome == migrate.OutcomeSkipped {
+			payloads[data[i].Key] = data[i].Data
+			keys = append(keys, data[i].Key)
 		}
-		successfulKeys = append(successfulKeys, keysToRestore[i].Key)
 	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	existing, err := c.DumpKeys(ctx, keys)
+	if err != nil {
+		return fmt.Errorf("failed to check keys restored before a failover: %w", err)
+	}
+
+	restored := make(map[string]bool)
+	for _, d := range existing {
+		restored[d.Key] = d.Data == payloads[d.Key]
+	}
+	for i, result := range results {
+		if restored[result.Key] {
+			results[i] = migrate.RestoreResult{Key: result.Key, Outcome: migrate.OutcomeCreated}
+		}
+	}
+
+	return nil
+}
 
-	return successfulKeys, nil
+// isBusyKeyError reports whether err is the reply to a RESTORE of a key that
+// already exists.
+func isBusyKeyError(err error) bool {
+	return strings.HasPrefix(err.Error(), "BUSYKEY ")
 }
 
+// DeleteKeys deletes multiple keys.
+//
+// A cluster rejects multi-key commands spanning several hash slots, so keys
+// are grouped by slot and deleted with one DEL per slot.
 func (c *Client) DeleteKeys(ctx context.Context, keys []string) error {
 	if len(keys) == 0 {
 		return nil
 	}
 
-	return c.client.Del(ctx, keys...).Err()
+	return c.retryOnFailover(ctx, func() error {
+		if _, ok := c.client.(*redis.ClusterClient); !ok {
+			return c.client.Del(ctx, keys...).Err()
+		}
+
+		pipe := c.client.Pipeline()
+		for _, group := range groupBySlot(keys) {
+			pipe.Del(ctx, group...)
+		}
+
+		_, err := pipe.Exec(ctx)
+		return err
+	})
 }
@@ -2,9 +2,12 @@ package redis
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	goredis "github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"github.com/testcontainers/testcontainers-go/modules/redis"
@@ -93,17 +96,17 @@ func (s *RedisClientTestSuite) TestNewClient_Success() {
 func (s *RedisClientTestSuite) TestScanKeys_EmptyDatabase() {
 	t := s.T()
 
-	keysChan := make(chan []string, 10)
+	keysChan := make(chan migrate.KeyBatch, 10)
 	var allKeys []string
 
 	go func() {
 		defer close(keysChan)
-		err := s.client.ScanKeys(s.ctx, "*", 100, keysChan)
+		err := s.client.ScanKeys(s.ctx, migrate.ScanOptions{Pattern: "*", BatchSize: 100}, keysChan)
 		assert.NoError(t, err)
 	}()
 
-	for keys := range keysChan {
-		allKeys = append(allKeys, keys...)
+	for batch := range keysChan {
+		allKeys = append(allKeys, batch.Keys...)
 	}
 
 	assert.Empty(t, allKeys, "Expected empty database")
@@ -128,17 +131,17 @@ func (s *RedisClientTestSuite) TestScanKeys_WithData() {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			keysChan := make(chan []string, 10)
+			keysChan := make(chan migrate.KeyBatch, 10)
 			var allKeys []string
 
 			go func() {
 				defer close(keysChan)
-				err := s.client.ScanKeys(s.ctx, tt.pattern, 100, keysChan)
+				err := s.client.ScanKeys(s.ctx, migrate.ScanOptions{Pattern: tt.pattern, BatchSize: 100}, keysChan)
 				assert.NoError(t, err)
 			}()
 
-			for keys := range keysChan {
-				allKeys = append(allKeys, keys...)
+			for batch := range keysChan {
+				allKeys = append(allKeys, batch.Keys...)
 			}
 
 			assert.Len(t, allKeys, tt.expected, "Pattern %s should match %d keys", tt.pattern, tt.expected)
@@ -146,6 +149,237 @@ func (s *RedisClientTestSuite) TestScanKeys_WithData() {
 	}
 }
 
+func (s *RedisClientTestSuite) TestScanKeys_Resume() {
+	t := s.T()
+
+	for i := range 100 {
+		err := s.client.client.Set(s.ctx, fmt.Sprintf("key:%d", i), i, 0).Err()
+		assert.NoError(t, err)
+	}
+
+	scan := func(resume map[string]string) []migrate.KeyBatch {
+		keysChan := make(chan migrate.KeyBatch, 100)
+		err := s.client.ScanKeys(s.ctx, migrate.ScanOptions{Pattern: "*", BatchSize: 10, Resume: resume}, keysChan)
+		assert.NoError(t, err)
+		close(keysChan)
+
+		var batches []migrate.KeyBatch
+		for batch := range keysChan {
+			batches = append(batches, batch)
+		}
+		return batches
+	}
+
+	batches := scan(nil)
+	if !assert.Greater(t, len(batches), 2) {
+		return
+	}
+	assert.Equal(t, "0", batches[0].Cursor)
+	assert.Empty(t, batches[len(batches)-1].Next, "last batch should complete the shard")
+
+	// Resuming at the cursor of the second batch repeats the scan from there.
+	resumed := scan(map[string]string{"": batches[1].Cursor})
+	assert.Equal(t, batches[1:], resumed)
+
+	assert.Empty(t, scan(map[string]string{"": ""}), "completed shards should be skipped")
+}
+
+func (s *RedisClientTestSuite) TestInspectKeys() {
+	t := s.T()
+
+	s.setupTestData()
+	err := s.client.client.HSet(s.ctx, "profile:1", "name", "alice", "age", "30").Err()
+	assert.NoError(t, err)
+	assert.NoError(t, s.client.client.Expire(s.ctx, "profile:1", time.Hour).Err())
+
+	infos, err := s.client.InspectKeys(s.ctx, []string{"user:1", "missing", "profile:1"})
+	assert.NoError(t, err)
+	if assert.Len(t, infos, 2) {
+		assert.Equal(t, "user:1", infos[0].Key)
+		assert.Equal(t, "string", infos[0].Type)
+		assert.Positive(t, infos[0].Size)
+		assert.Equal(t, "profile:1", infos[1].Key)
+		assert.Equal(t, "hash", infos[1].Type)
+		assert.Positive(t, infos[1].Size)
+		assert.Zero(t, infos[0].TTL)
+		assert.InDelta(t, time.Hour, infos[1].TTL, float64(time.Minute))
+	}
+
+	exists, err := s.client.ExistingKeys(s.ctx, []string{"user:1", "missing", "profile:1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{true, false, true}, exists)
+}
+
+func (s *RedisClientTestSuite) TestDigestKeys() {
+	t := s.T()
+
+	// Equal values written in a different order have equal digests.
+	assert.NoError(t, s.client.client.HSet(s.ctx, "hash:1", "a", "1", "b", "2").Err())
+	assert.NoError(t, s.client.client.HSet(s.ctx, "hash:2", "b", "2", "a", "1").Err())
+	assert.NoError(t, s.client.client.HSet(s.ctx, "hash:3", "a", "1", "b", "3").Err())
+	assert.NoError(t, s.client.client.SAdd(s.ctx, "set:1", "x", "y").Err())
+	assert.NoError(t, s.client.client.SAdd(s.ctx, "set:2", "y", "x").Err())
+	assert.NoError(t, s.client.client.RPush(s.ctx, "list:1", "x", "y").Err())
+	assert.NoError(t, s.client.client.RPush(s.ctx, "list:2", "y", "x").Err())
+
+	keys := []string{"hash:1", "hash:2", "hash:3", "set:1", "set:2", "list:1", "list:2", "missing"}
+	digests, err := s.client.DigestKeys(s.ctx, keys)
+	assert.NoError(t, err)
+
+	assert.Len(t, digests, 7)
+	assert.Equal(t, digests["hash:1"], digests["hash:2"])
+	assert.NotEqual(t, digests["hash:1"], digests["hash:3"])
+	assert.Equal(t, digests["set:1"], digests["set:2"])
+	assert.NotEqual(t, digests["list:1"], digests["list:2"])
+	assert.NotContains(t, digests, "missing")
+}
+
+func (s *RedisClientTestSuite) TestReadValues_NativeRoundTrip() {
+	t := s.T()
+
+	assert.NoError(t, s.client.client.Set(s.ctx, "native:string", "value", time.Hour).Err())
+	assert.NoError(t, s.client.client.HSet(s.ctx, "native:hash", "a", "1", "b", "2").Err())
+	assert.NoError(t, s.client.client.RPush(s.ctx, "native:list", "x", "y", "x").Err())
+	assert.NoError(t, s.client.client.SAdd(s.ctx, "native:set", "x", "y").Err())
+	assert.NoError(t, s.client.client.ZAdd(s.ctx, "native:zset", goredis.Z{Member: "x", Score: 1.5}, goredis.Z{Member: "y", Score: -2}).Err())
+	assert.NoError(t, s.client.client.XAdd(s.ctx, &goredis.XAddArgs{Stream: "native:stream", ID: "1-1", Values: []string{"b", "2", "a", "1"}}).Err())
+
+	keys := []string{"native:string", "native:hash", "native:list", "native:set", "native:zset", "native:stream", "native:missing"}
+	values, err := s.client.ReadValues(s.ctx, keys)
+	assert.NoError(t, err)
+	assert.Len(t, values, 6)
+	assert.Equal(t, "value", values[0].Value.String)
+	assert.Greater(t, values[0].TTL, time.Minute)
+	assert.Equal(t, []migrate.StreamEntry{{ID: "1-1", Fields: []string{"b", "2", "a", "1"}}}, values[5].Value.Stream)
+
+	digests, err := s.client.DigestKeys(s.ctx, keys)
+	assert.NoError(t, err)
+
+	// Written back under other names, every value has the same digest.
+	renamed := make([]migrate.KeyData, len(values))
+	for i, data := range values {
+		data.Key = "copy:" + data.Key
+		renamed[i] = data
+	}
+
+	results, err := s.client.RestoreKeys(s.ctx, renamed, migrate.ErrorOnConflict)
+	assert.NoError(t, err)
+	for _, result := range results {
+		assert.Equal(t, migrate.OutcomeCreated, result.Outcome, result.Key)
+		assert.NoError(t, result.Err)
+	}
+
+	for _, key := range keys[:6] {
+		copied, err := s.client.DigestKeys(s.ctx, []string{"copy:" + key})
+		assert.NoError(t, err)
+		assert.Equal(t, digests[key], copied["copy:"+key], key)
+	}
+	assert.Greater(t, s.client.client.PTTL(s.ctx, "copy:native:string").Val(), time.Minute)
+
+	// Existing keys are detected like with RESTORE, and no temporary keys
+	// are left behind.
+	results, err = s.client.RestoreKeys(s.ctx, renamed[:2], migrate.SkipOnConflict)
+	assert.NoError(t, err)
+	assert.Equal(t, migrate.OutcomeSkipped, results[0].Outcome)
+	assert.Equal(t, migrate.OutcomeSkipped, results[1].Outcome)
+
+	results, err = s.client.RestoreKeys(s.ctx, renamed[:1], migrate.OverwriteOnConflict)
+	assert.NoError(t, err)
+	assert.Equal(t, migrate.OutcomeOverwritten, results[0].Outcome)
+
+	leftover, err := s.client.client.Keys(s.ctx, "*"+tempKeySuffix).Result()
+	assert.NoError(t, err)
+	assert.Empty(t, leftover)
+}
+
+func (s *RedisClientTestSuite) TestChunks_RoundTrip() {
+	t := s.T()
+
+	members := make([]any, 0, 5000)
+	for i := range 2500 {
+		members = append(members, fmt.Sprintf("field:%d", i), i)
+	}
+	assert.NoError(t, s.client.client.HSet(s.ctx, "big:hash", members...).Err())
+	assert.NoError(t, s.client.client.PExpire(s.ctx, "big:hash", time.Hour).Err())
+	for i := range 250 {
+		assert.NoError(t, s.client.client.XAdd(s.ctx, &goredis.XAddArgs{Stream: "big:stream", Values: []string{"n", fmt.Sprint(i)}}).Err())
+	}
+	assert.NoError(t, s.client.client.Set(s.ctx, "big:string", strings.Repeat("x", stringChunkSize+10), 0).Err())
+
+	for _, key := range []string{"big:hash", "big:stream", "big:string"} {
+		typ, err := s.client.client.Type(s.ctx, key).Result()
+		assert.NoError(t, err)
+
+		copyKey := "copy:" + key
+		var chunks int
+		ttl, err := s.client.ReadChunks(s.ctx, key, typ, 100, func(chunk *migrate.Value) error {
+			chunks++
+			return s.client.WriteChunk(s.ctx, copyKey, chunk)
+		})
+		assert.NoError(t, err)
+		assert.Greater(t, chunks, 1, key)
+
+		result, err := s.client.CommitChunks(s.ctx, copyKey, ttl, migrate.ErrorOnConflict)
+		assert.NoError(t, err)
+		assert.Equal(t, migrate.OutcomeCreated, result.Outcome, key)
+
+		digests, err := s.client.DigestKeys(s.ctx, []string{key, copyKey})
+		assert.NoError(t, err)
+		assert.Equal(t, digests[key], digests[copyKey], key)
+	}
+	assert.Greater(t, s.client.client.PTTL(s.ctx, "copy:big:hash").Val(), time.Minute)
+
+	// Committing onto an existing key is a conflict, and removes the chunks.
+	assert.NoError(t, s.client.WriteChunk(s.ctx, "copy:big:string", &migrate.Value{Type: "string", String: "y"}))
+	result, err := s.client.CommitChunks(s.ctx, "copy:big:string", 0, migrate.ErrorOnConflict)
+	assert.NoError(t, err)
+	assert.Equal(t, migrate.OutcomeConflict, result.Outcome)
+
+	leftover, err := s.client.client.Keys(s.ctx, "*"+tempKeySuffix).Result()
+	assert.NoError(t, err)
+	assert.Empty(t, leftover)
+}
+
+func (s *RedisClientTestSuite) TestScanKeys_Types() {
+	t := s.T()
+
+	assert.NoError(t, s.client.client.Set(s.ctx, "string:1", "value", 0).Err())
+	assert.NoError(t, s.client.client.HSet(s.ctx, "hash:1", "field", "value").Err())
+	assert.NoError(t, s.client.client.ZAdd(s.ctx, "zset:1", goredis.Z{Score: 1, Member: "a"}).Err())
+	assert.NoError(t, s.client.client.XAdd(s.ctx, &goredis.XAddArgs{Stream: "stream:1", Values: []string{"field", "value"}}).Err())
+
+	tests := []struct {
+		name     string
+		types    []string
+		expected []string
+	}{
+		{"single type", []string{"stream"}, []string{"stream:1"}},
+		{"several types", []string{"hash", "zset"}, []string{"hash:1", "zset:1"}},
+		{"no match", []string{"list"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := migrate.ScanOptions{Pattern: "*", BatchSize: 100, Types: tt.types}
+
+			keysChan := make(chan migrate.KeyBatch, 10)
+			err := s.client.ScanKeys(s.ctx, opts, keysChan)
+			assert.NoError(t, err)
+			close(keysChan)
+
+			var allKeys []string
+			for batch := range keysChan {
+				allKeys = append(allKeys, batch.Keys...)
+			}
+			assert.ElementsMatch(t, tt.expected, allKeys)
+
+			count, err := s.client.CountKeys(s.ctx, opts)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(tt.expected)), count)
+		})
+	}
+}
+
 func (s *RedisClientTestSuite) TestCountKeys_WithPatterns() {
 	t := s.T()
 
@@ -165,7 +399,7 @@ func (s *RedisClientTestSuite) TestCountKeys_WithPatterns() {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			count, err := s.client.CountKeys(s.ctx, tt.pattern, 100)
+			count, err := s.client.CountKeys(s.ctx, migrate.ScanOptions{Pattern: tt.pattern, BatchSize: 100})
 			assert.NoError(t, err)
 			assert.Equal(t, tt.expected, count, "Pattern %s should count %d keys", tt.pattern, tt.expected)
 		})
@@ -199,6 +433,149 @@ func (s *RedisClientTestSuite) TestDumpKeys_Operations() {
 	assert.Len(t, keyData, 2, "Should dump 2 existing keys, skip nonexistent")
 }
 
+func (s *RedisClientTestSuite) TestDumpKeys_TTL() {
+	t := s.T()
+
+	assert.NoError(t, s.client.client.Set(s.ctx, "ttl:persistent", "value", 0).Err())
+	assert.NoError(t, s.client.client.Set(s.ctx, "ttl:expiring", "value", time.Hour).Err())
+
+	keyData, err := s.client.DumpKeys(s.ctx, []string{"ttl:persistent", "ttl:expiring"})
+	assert.NoError(t, err)
+	if !assert.Len(t, keyData, 2) {
+		return
+	}
+
+	assert.Equal(t, time.Duration(0), keyData[0].TTL, "keys without expiry should have a zero TTL")
+	assert.Greater(t, keyData[1].TTL, 59*time.Minute)
+}
+
+func (s *RedisClientTestSuite) TestAccess() {
+	t := s.T()
+
+	assert.NoError(t, s.client.client.Set(s.ctx, "access:1", "value", 0).Err())
+
+	idleTimes, err := s.client.IdleTimes(s.ctx, []string{"access:1", "access:missing"})
+	assert.NoError(t, err)
+	assert.Contains(t, idleTimes, "access:1")
+	assert.NotContains(t, idleTimes, "access:missing")
+	assert.Less(t, idleTimes["access:1"], time.Minute)
+
+	// The default eviction policy doesn't track access frequencies.
+	_, err = s.client.AccessFrequencies(s.ctx, []string{"access:1"})
+	assert.ErrorIs(t, err, migrate.ErrAccessUnavailable)
+}
+
+func (s *RedisClientTestSuite) TestFollow_KeyspaceNotifications() {
+	t := s.T()
+
+	restore, err := s.client.EnableNotifications(s.ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	events, err := s.client.SubscribeKeyspace(ctx)
+	if !assert.NoError(t, err) {
+		cancel()
+		return
+	}
+
+	assert.NoError(t, s.client.client.Set(s.ctx, "follow:1", "value", 0).Err())
+	assert.NoError(t, s.client.client.Del(s.ctx, "follow:1").Err())
+
+	for _, want := range []string{"set", "del"} {
+		select {
+		case event := <-events:
+			assert.Equal(t, "follow:1", event.Key)
+			assert.Equal(t, want, event.Event)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s notification received", want)
+		}
+	}
+
+	cancel()
+	for range events {
+	}
+
+	assert.NoError(t, restore(s.ctx))
+	flags, err := s.client.client.ConfigGet(s.ctx, notifyConfig).Result()
+	assert.NoError(t, err)
+	assert.Empty(t, flags[notifyConfig], "notifications are disabled again")
+}
+
+func (s *RedisClientTestSuite) TestReplicate() {
+	t := s.T()
+
+	assert.NoError(t, s.client.client.Set(s.ctx, "replica:1", "value", time.Hour).Err())
+	dump, err := s.client.client.Dump(s.ctx, "replica:1").Result()
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	handler := &replicationRecorder{}
+	done := make(chan error, 1)
+	go func() {
+		done <- s.client.Replicate(ctx, handler)
+	}()
+
+	loaded := func() bool {
+		handler.mu.Lock()
+		defer handler.mu.Unlock()
+		return handler.loaded
+	}
+	if !assert.Eventually(t, loaded, 10*time.Second, 10*time.Millisecond) {
+		cancel()
+		return
+	}
+
+	assert.NoError(t, s.client.client.Set(s.ctx, "replica:2", "value", 0).Err())
+	assert.NoError(t, s.client.client.ZAdd(s.ctx, "replica:4", goredis.Z{Score: 1, Member: "a"}).Err())
+	assert.NoError(t, s.client.client.ZUnionStore(s.ctx, "replica:3", &goredis.ZStore{Keys: []string{"replica:4"}}).Err())
+
+	assert.Eventually(t, func() bool {
+		handler.mu.Lock()
+		defer handler.mu.Unlock()
+		return len(handler.cmds) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+
+	if assert.Len(t, handler.snapshot, 1) {
+		assert.Equal(t, "replica:1", handler.snapshot[0].Key)
+		assert.Equal(t, dump, handler.snapshot[0].Data, "snapshot values are DUMP payloads")
+		assert.InDelta(t, time.Hour, handler.snapshot[0].TTL, float64(time.Minute))
+	}
+	if assert.Len(t, handler.cmds, 3) {
+		assert.Equal(t, []string{"SET", "replica:2", "value"}, handler.cmds[0].Args)
+		assert.Equal(t, []int{1}, handler.cmds[0].Keys)
+		assert.Equal(t, "ZUNIONSTORE", strings.ToUpper(handler.cmds[2].Args[0]))
+		assert.Equal(t, []int{1, 3}, handler.cmds[2].Keys, "movable keys are resolved")
+	}
+}
+
+func (s *RedisClientTestSuite) TestExecCommands() {
+	t := s.T()
+
+	errs, err := s.client.ExecCommands(s.ctx, []migrate.Command{
+		{Args: []string{"SET", "exec:1", "value"}, Keys: []int{1}},
+		{Args: []string{"INCR", "exec:1"}, Keys: []int{1}},
+		{Args: []string{"GET", "exec:2"}, Keys: []int{1}},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, errs, 3) {
+		assert.NoError(t, errs[0])
+		assert.ErrorContains(t, errs[1], "not an integer")
+		assert.NoError(t, errs[2], "missing keys are not an error")
+	}
+
+	value, err := s.client.client.Get(s.ctx, "exec:1").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+}
+
 func (s *RedisClientTestSuite) TestRestoreKeys_ConflictBehaviors() {
 	t := s.T()
 
@@ -225,32 +602,34 @@ func (s *RedisClientTestSuite) TestRestoreKeys_ConflictBehaviors() {
 	}
 
 	tests := []struct {
-		name               string
-		behavior           migrate.ConflictBehavior
-		expectedSuccessful []string
-		expectError        bool
-		setupCleanup       bool
+		name             string
+		behavior         migrate.ConflictBehavior
+		expectedOutcomes []migrate.Outcome
+		setupCleanup     bool
 	}{
 		{
-			name:               "ErrorOnConflict",
-			behavior:           migrate.ErrorOnConflict,
-			expectedSuccessful: nil, // Should fail entirely on conflict
-			expectError:        true,
-			setupCleanup:       true,
+			name:             "ErrorOnConflict",
+			behavior:         migrate.ErrorOnConflict,
+			expectedOutcomes: []migrate.Outcome{migrate.OutcomeConflict, migrate.OutcomeCreated},
+			setupCleanup:     true,
 		},
 		{
-			name:               "SkipOnConflict",
-			behavior:           migrate.SkipOnConflict,
-			expectedSuccessful: []string{"new:key"}, // Should skip conflict:key
-			expectError:        false,
-			setupCleanup:       true,
+			name:             "SkipOnConflict",
+			behavior:         migrate.SkipOnConflict,
+			expectedOutcomes: []migrate.Outcome{migrate.OutcomeSkipped, migrate.OutcomeCreated},
+			setupCleanup:     true,
 		},
 		{
-			name:               "OverwriteOnConflict",
-			behavior:           migrate.OverwriteOnConflict,
-			expectedSuccessful: []string{"conflict:key", "new:key"}, // Should restore both
-			expectError:        false,
-			setupCleanup:       false,
+			name:             "OverwriteOnConflict",
+			behavior:         migrate.OverwriteOnConflict,
+			expectedOutcomes: []migrate.Outcome{migrate.OutcomeOverwritten, migrate.OutcomeCreated},
+			setupCleanup:     true,
+		},
+		{
+			name:             "OverwriteOnConflict_BothExisting",
+			behavior:         migrate.OverwriteOnConflict,
+			expectedOutcomes: []migrate.Outcome{migrate.OutcomeOverwritten, migrate.OutcomeOverwritten},
+			setupCleanup:     false,
 		},
 	}
 
@@ -260,20 +639,33 @@ func (s *RedisClientTestSuite) TestRestoreKeys_ConflictBehaviors() {
 				s.client.client.Del(s.ctx, "new:key")
 			}
 
-			successfulKeys, err := s.client.RestoreKeys(s.ctx, testData, tt.behavior)
+			results, err := s.client.RestoreKeys(s.ctx, testData, tt.behavior)
+			assert.NoError(t, err, "Behavior %v should not return error", tt.behavior)
+			assert.Len(t, results, len(testData))
 
-			if tt.expectError {
-				assert.Error(t, err, "Behavior %v should return error on conflict", tt.behavior)
-				assert.Nil(t, successfulKeys, "Should return nil keys on error")
-			} else {
-				assert.NoError(t, err, "Behavior %v should not return error", tt.behavior)
-				assert.ElementsMatch(t, tt.expectedSuccessful, successfulKeys,
-					"Behavior %v should restore expected keys", tt.behavior)
+			for i, result := range results {
+				assert.Equal(t, testData[i].Key, result.Key, "Results should follow input order")
+				assert.Equal(t, tt.expectedOutcomes[i], result.Outcome,
+					"Behavior %v should classify %s as %v", tt.behavior, result.Key, tt.expectedOutcomes[i])
+				assert.NoError(t, result.Err)
 			}
 		})
 	}
 }
 
+func (s *RedisClientTestSuite) TestRestoreKeys_InvalidPayload() {
+	t := s.T()
+
+	results, err := s.client.RestoreKeys(s.ctx, []migrate.KeyData{
+		{Key: "broken:key", Data: "not a dump payload"},
+	}, migrate.ErrorOnConflict)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, migrate.OutcomeFailed, results[0].Outcome)
+	assert.Error(t, results[0].Err, "Failed keys should carry the reason")
+	assert.ErrorIs(t, results[0].Err, migrate.ErrIncompatiblePayload)
+}
+
 func (s *RedisClientTestSuite) TestDeleteKeys_Operations() {
 	t := s.T()
 
@@ -295,7 +687,7 @@ func (s *RedisClientTestSuite) TestDeleteKeys_Operations() {
 	err = s.client.DeleteKeys(s.ctx, keysToDelete)
 	assert.NoError(t, err)
 
-	count, err := s.client.CountKeys(s.ctx, "user:*", 100)
+	count, err := s.client.CountKeys(s.ctx, migrate.ScanOptions{Pattern: "user:*", BatchSize: 100})
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), count, "Should have 1 remaining user key")
 
@@ -319,21 +711,21 @@ func (s *RedisClientTestSuite) TestCompleteWorkflow() {
 		assert.NoError(t, err)
 	}
 
-	count, err := s.client.CountKeys(s.ctx, "app:config:*", 100)
+	count, err := s.client.CountKeys(s.ctx, migrate.ScanOptions{Pattern: "app:config:*", BatchSize: 100})
 	assert.NoError(t, err)
 	assert.Equal(t, int64(2), count)
 
-	keysChan := make(chan []string, 10)
+	keysChan := make(chan migrate.KeyBatch, 10)
 	var scannedKeys []string
 
 	go func() {
 		defer close(keysChan)
-		err = s.client.ScanKeys(s.ctx, "app:config:*", 100, keysChan)
+		err = s.client.ScanKeys(s.ctx, migrate.ScanOptions{Pattern: "app:config:*", BatchSize: 100}, keysChan)
 		assert.NoError(t, err)
 	}()
 
-	for keys := range keysChan {
-		scannedKeys = append(scannedKeys, keys...)
+	for batch := range keysChan {
+		scannedKeys = append(scannedKeys, batch.Keys...)
 	}
 
 	assert.Len(t, scannedKeys, 2)
@@ -345,15 +737,18 @@ func (s *RedisClientTestSuite) TestCompleteWorkflow() {
 	err = s.client.DeleteKeys(s.ctx, scannedKeys)
 	assert.NoError(t, err)
 
-	count, err = s.client.CountKeys(s.ctx, "app:config:*", 100)
+	count, err = s.client.CountKeys(s.ctx, migrate.ScanOptions{Pattern: "app:config:*", BatchSize: 100})
 	assert.NoError(t, err)
 	assert.Equal(t, int64(0), count)
 
-	successfulKeys, err := s.client.RestoreKeys(s.ctx, keyData, migrate.ErrorOnConflict)
+	results, err := s.client.RestoreKeys(s.ctx, keyData, migrate.ErrorOnConflict)
 	assert.NoError(t, err)
-	assert.Len(t, successfulKeys, 2)
+	assert.Len(t, results, 2)
+	for _, result := range results {
+		assert.Equal(t, migrate.OutcomeCreated, result.Outcome)
+	}
 
-	count, err = s.client.CountKeys(s.ctx, "app:config:*", 100)
+	count, err = s.client.CountKeys(s.ctx, migrate.ScanOptions{Pattern: "app:config:*", BatchSize: 100})
 	assert.NoError(t, err)
 	assert.Equal(t, int64(2), count)
 
@@ -369,3 +764,99 @@ func (s *RedisClientTestSuite) TestCompleteWorkflow() {
 func TestRedisClientTestSuite(t *testing.T) {
 	suite.Run(t, new(RedisClientTestSuite))
 }
+
+// restoreWithExistsPerKey restores keys the way SkipOnConflict used to work,
+// checking every key with a synchronous EXISTS before queueing its RESTORE.
+// It serves as the baseline for BenchmarkRestoreKeys_SkipOnConflict.
+func restoreWithExistsPerKey(ctx context.Context, c *Client, data []migrate.KeyData) error {
+	pipe := c.client.Pipeline()
+
+	for _, info := range data {
+		if c.client.Exists(ctx, info.Key).Val() > 0 {
+			continue
+		}
+		pipe.Restore(ctx, info.Key, info.TTL, info.Data)
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func BenchmarkRestoreKeys_SkipOnConflict(b *testing.B) {
+	ctx := context.Background()
+
+	redisContainer, err := redis.Run(ctx, "redis:7-alpine")
+	if err != nil {
+		b.Fatalf("Failed to start Redis container: %v", err)
+	}
+	b.Cleanup(func() {
+		if err := redisContainer.Terminate(ctx); err != nil {
+			b.Logf("Failed to terminate container: %v", err)
+		}
+	})
+
+	connectionString, err := redisContainer.ConnectionString(ctx)
+	if err != nil {
+		b.Fatalf("Failed to get connection string: %v", err)
+	}
+
+	client, err := NewClient(connectionString)
+	if err != nil {
+		b.Fatalf("Failed to create Redis client: %v", err)
+	}
+	b.Cleanup(func() { client.Close() })
+
+	if err := client.client.Set(ctx, "template", "value", 0).Err(); err != nil {
+		b.Fatalf("Failed to set template key: %v", err)
+	}
+	payload, err := client.client.Dump(ctx, "template").Result()
+	if err != nil {
+		b.Fatalf("Failed to dump template key: %v", err)
+	}
+
+	// Half of the batch already exists in the destination and is skipped.
+	const batchSize = 100
+	data := make([]migrate.KeyData, batchSize)
+	var newKeys []string
+	for i := range data {
+		key := fmt.Sprintf("bench:%d", i)
+		data[i] = migrate.KeyData{Key: key, Data: payload}
+
+		if i%2 == 0 {
+			if err := client.client.Set(ctx, key, "existing", 0).Err(); err != nil {
+				b.Fatalf("Failed to set conflicting key: %v", err)
+			}
+		} else {
+			newKeys = append(newKeys, key)
+		}
+	}
+
+	benchmarks := []struct {
+		name    string
+		restore func() error
+	}{
+		{"Pipelined", func() error {
+			_, err := client.RestoreKeys(ctx, data, migrate.SkipOnConflict)
+			return err
+		}},
+		{"ExistsPerKey", func() error {
+			return restoreWithExistsPerKey(ctx, client, data)
+		}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			for range b.N {
+				if err := bm.restore(); err != nil {
+					b.Fatalf("Failed to restore keys: %v", err)
+				}
+
+				b.StopTimer()
+				if err := client.client.Del(ctx, newKeys...).Err(); err != nil {
+					b.Fatalf("Failed to reset keys: %v", err)
+				}
+				b.StartTimer()
+			}
+		})
+	}
+}
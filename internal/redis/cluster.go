@@ -0,0 +1,91 @@
+package redis
+
+import "strings"
+
+const (
+	// clusterSlots is the number of hash slots a Redis Cluster keyspace is split into.
+	clusterSlots = 16384
+
+	// tempKeySuffix marks temporary keys a value is written to before it is
+	// renamed into place.
+	tempKeySuffix = ":__redismigrate_tmp__"
+)
+
+// hashSlot returns the cluster hash slot of a key.
+//
+// If the key contains a non-empty hash tag ("{...}"), only the tag is hashed,
+// so that related keys can be forced into the same slot.
+func hashSlot(key string) int {
+	if tag, ok := hashTag(key); ok {
+		key = tag
+	}
+
+	return int(crc16(key) % clusterSlots)
+}
+
+// hashTag returns the hash tag of a key, if it has a non-empty one.
+func hashTag(key string) (string, bool) {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			return key[start+1 : start+1+end], true
+		}
+	}
+
+	return "", false
+}
+
+// tempKey returns a temporary key in the same hash slot as key. There is none
+// if key is empty, or contains a '}' without having a hash tag.
+func tempKey(key string) (string, bool) {
+	if _, ok := hashTag(key); ok {
+		return key + tempKeySuffix, true
+	}
+
+	if key == "" || strings.Contains(key, "}") {
+		return "", false
+	}
+
+	// Without a hash tag the whole key is hashed, so using it as the tag of
+	// the temporary key yields the same slot.
+	return "{" + key + "}" + tempKeySuffix, true
+}
+
+// groupBySlot splits keys into groups sharing the same hash slot. Groups are
+// returned in order of first appearance and keep the relative key order.
+func groupBySlot(keys []string) [][]string {
+	var groups [][]string
+	index := make(map[int]int)
+
+	for _, key := range keys {
+		slot := hashSlot(key)
+
+		i, ok := index[slot]
+		if !ok {
+			i = len(groups)
+			index[slot] = i
+			groups = append(groups, nil)
+		}
+
+		groups[i] = append(groups[i], key)
+	}
+
+	return groups
+}
+
+// crc16 implements the CRC16-CCITT (XMODEM) checksum used by Redis Cluster.
+func crc16(s string) uint16 {
+	var crc uint16
+
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
@@ -0,0 +1,103 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashSlot(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want int
+	}{
+		{"empty key", "", 0},
+		{"plain key", "123456789", 12739},
+		{"leading empty hash tag", "{}foo", 9500},
+		{"trailing empty hash tag", "foo{}", 5542},
+		{"empty hash tag is not a tag", "foo{}{bar}", 8363},
+		{"hash tag", "foo{bar}", hashSlot("bar")},
+		{"same hash tag", "{user1000}.following", hashSlot("{user1000}.followers")},
+		{"only first hash tag counts", "foo{{bar}}zap", hashSlot("{bar")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, hashSlot(tt.key))
+		})
+	}
+}
+
+func TestGroupBySlot(t *testing.T) {
+	keys := []string{"{a}:1", "{b}:1", "{a}:2", "{c}:1", "{b}:2"}
+
+	groups := groupBySlot(keys)
+
+	assert.Equal(t, [][]string{
+		{"{a}:1", "{a}:2"},
+		{"{b}:1", "{b}:2"},
+		{"{c}:1"},
+	}, groups)
+	assert.Empty(t, groupBySlot(nil))
+}
+
+func TestTempKey(t *testing.T) {
+	tests := []struct {
+		key    string
+		wantOK bool
+	}{
+		{"user:1", true},
+		{"{user}:1", true},
+		{"a{b", true},
+		{"{}foo", false},
+		{"foo}", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			tmp, ok := tempKey(tt.key)
+
+			assert.Equal(t, tt.wantOK, ok)
+			if ok {
+				assert.NotEqual(t, tt.key, tmp)
+				assert.Equal(t, hashSlot(tt.key), hashSlot(tmp), "temporary key must share the slot of %q", tt.key)
+			}
+		})
+	}
+}
+
+func TestNewUniversalClient_Schemes(t *testing.T) {
+	tests := []struct {
+		name        string
+		connStr     string
+		wantCluster bool
+		wantErr     bool
+	}{
+		{"single node", "redis://localhost:6379/0", false, false},
+		{"single node tls", "rediss://localhost:6379/0", false, false},
+		{"cluster", "redis+cluster://localhost:7000?addr=localhost:7001", true, false},
+		{"cluster tls", "rediss+cluster://localhost:7000", true, false},
+		{"sentinel", "redis+sentinel://localhost:26379/mymaster", false, false},
+		{"missing scheme", "localhost:6379", false, true},
+		{"unknown scheme", "http://localhost:6379", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := newUniversalClient(tt.connStr, options{})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			defer client.Close()
+
+			_, isCluster := client.(*redis.ClusterClient)
+			assert.Equal(t, tt.wantCluster, isCluster)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+// DigestKeys returns a digest of the type and value of multiple keys, read
+// with [Client.ReadValues] so that it doesn't depend on the encoding of the
+// value. Hash fields and set members are digested in sorted order. Streams are
+// digested by their entries only, consumer groups are not part of the digest.
+//
+// Keys that don't exist, and types other than string, hash, list, set, zset
+// and stream, are omitted.
+func (c *Client) DigestKeys(ctx context.Context, keys []string) (map[string]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	values, err := c.ReadValues(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	digests := make(map[string]string, len(values))
+	for _, data := range values {
+		digests[data.Key] = digestValue(data.Value)
+	}
+
+	return digests, nil
+}
+
+// digestValue returns the hex-encoded SHA-256 digest of value.
+func digestValue(value *migrate.Value) string {
+	h := sha256.New()
+	writeField(h, value.Type)
+
+	switch value.Type {
+	case "string":
+		writeField(h, value.String)
+	case "hash":
+		for _, field := range slices.Sorted(maps.Keys(value.Hash)) {
+			writeField(h, field)
+			writeField(h, value.Hash[field])
+		}
+	case "list":
+		for _, element := range value.List {
+			writeField(h, element)
+		}
+	case "set":
+		for _, member := range slices.Sorted(slices.Values(value.Set)) {
+			writeField(h, member)
+		}
+	case "zset":
+		for _, m := range value.ZSet {
+			writeField(h, m.Member)
+			writeField(h, strconv.FormatFloat(m.Score, 'g', -1, 64))
+		}
+	case "stream":
+		for _, entry := range value.Stream {
+			writeField(h, entry.ID)
+			for _, field := range entry.Fields {
+				writeField(h, field)
+			}
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeField writes a length-prefixed field to h, so that the boundaries of
+// fields are part of the digest.
+func writeField(h hash.Hash, field string) {
+	h.Write(binary.BigEndian.AppendUint64(nil, uint64(len(field))))
+	h.Write([]byte(field))
+}
@@ -0,0 +1,177 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+const (
+	// notifyConfig is the server setting selecting the published keyspace
+	// notifications.
+	notifyConfig = "notify-keyspace-events"
+
+	// notifyClasses are the notification classes covering every change of a
+	// key: generic commands, all data types, expirations and evictions.
+	notifyClasses = "g$lshzxet"
+
+	// notifyChannelSize is the number of notifications buffered per node
+	// before go-redis starts dropping them.
+	notifyChannelSize = 10000
+)
+
+// EnableNotifications makes sure that every node publishes keyspace
+// notifications for all changes, by adding the missing classes to
+// notify-keyspace-events. The returned function restores the previous settings
+// of the nodes that have been changed.
+func (c *Client) EnableNotifications(ctx context.Context) (func(ctx context.Context) error, error) {
+	var mu sync.Mutex
+	previous := make(map[*redis.Client]string)
+
+	restore := func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var errs []error
+		for node, flags := range previous {
+			if err := node.ConfigSet(ctx, notifyConfig, flags).Err(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", node.Options().Addr, err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	err := c.forEachNode(ctx, func(ctx context.Context, node *redis.Client) error {
+		config, err := node.ConfigGet(ctx, notifyConfig).Result()
+		if err != nil {
+			return notificationsError(node, err)
+		}
+
+		flags := config[notifyConfig]
+		if notifiesChanges(flags) {
+			return nil
+		}
+
+		if err := node.ConfigSet(ctx, notifyConfig, flags+"KA").Err(); err != nil {
+			return notificationsError(node, err)
+		}
+
+		mu.Lock()
+		previous[node] = flags
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		// Nodes enabled before the failure are not left behind changed.
+		return nil, errors.Join(err, restore(ctx))
+	}
+
+	return restore, nil
+}
+
+// notifiesChanges reports whether flags of notify-keyspace-events publish
+// keyspace notifications for every change of a key.
+func notifiesChanges(flags string) bool {
+	if !strings.Contains(flags, "K") {
+		return false
+	}
+	if strings.Contains(flags, "A") {
+		return true
+	}
+	for _, class := range notifyClasses {
+		if !strings.ContainsRune(flags, class) {
+			return false
+		}
+	}
+	return true
+}
+
+// notificationsError explains that keyspace notifications could not be
+// enabled, e.g. because CONFIG is disabled by a managed service.
+func notificationsError(node *redis.Client, err error) error {
+	return fmt.Errorf("keyspace notifications are not enabled on %s and could not be enabled: %w (run CONFIG SET %s KA on the source, or enable them in its configuration)", node.Options().Addr, err, notifyConfig)
+}
+
+// SubscribeKeyspace subscribes to the keyspace notifications of the database
+// of every node, and forwards them until ctx is done. In a cluster every
+// master publishes the notifications of its own keys.
+func (c *Client) SubscribeKeyspace(ctx context.Context) (<-chan migrate.KeyEvent, error) {
+	type subscription struct {
+		pubsub *redis.PubSub
+		prefix string
+	}
+
+	var mu sync.Mutex
+	var subscriptions []subscription
+
+	err := c.forEachNode(ctx, func(ctx context.Context, node *redis.Client) error {
+		prefix := fmt.Sprintf("__keyspace@%d__:", node.Options().DB)
+		pubsub := node.PSubscribe(ctx, prefix+"*")
+
+		// The subscription is confirmed before any notification is received,
+		// so that no change made afterwards is missed.
+		if _, err := pubsub.Receive(ctx); err != nil {
+			pubsub.Close()
+			return fmt.Errorf("%s: %w", node.Options().Addr, err)
+		}
+
+		mu.Lock()
+		subscriptions = append(subscriptions, subscription{pubsub: pubsub, prefix: prefix})
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		for _, sub := range subscriptions {
+			sub.pubsub.Close()
+		}
+		return nil, err
+	}
+
+	events := make(chan migrate.KeyEvent, notifyChannelSize)
+
+	var wg sync.WaitGroup
+	for _, sub := range subscriptions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			messages := sub.pubsub.Channel(redis.WithChannelSize(notifyChannelSize))
+			for {
+				select {
+				case msg, ok := <-messages:
+					if !ok {
+						return
+					}
+					key, ok := strings.CutPrefix(msg.Channel, sub.prefix)
+					if !ok {
+						continue
+					}
+					select {
+					case events <- migrate.KeyEvent{Key: key, Event: msg.Payload, Received: time.Now()}:
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		<-ctx.Done()
+		for _, sub := range subscriptions {
+			sub.pubsub.Close()
+		}
+		wg.Wait()
+		close(events)
+	}()
+
+	return events, nil
+}
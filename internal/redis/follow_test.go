@@ -0,0 +1,29 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifiesChanges(t *testing.T) {
+	tests := []struct {
+		flags string
+		want  bool
+	}{
+		{"", false},
+		{"KA", true},
+		{"AK", true},
+		{"EA", false},
+		{"Kg$lshzxet", true},
+		{"Kg$lshzxt", false},
+		{"Kg$", false},
+		{"KEA", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flags, func(t *testing.T) {
+			assert.Equal(t, tt.want, notifiesChanges(tt.flags))
+		})
+	}
+}
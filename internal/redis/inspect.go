@@ -0,0 +1,93 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+// InspectKeys returns the type, memory usage and TTL of multiple keys using
+// TYPE, MEMORY USAGE and PTTL, which unlike DUMP don't serialize the values.
+// Keys that don't exist are omitted.
+func (c *Client) InspectKeys(ctx context.Context, keys []string) ([]migrate.KeyInfo, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	var results []redis.Cmder
+	err := c.retryOnFailover(ctx, func() (err error) {
+		pipe := c.client.Pipeline()
+
+		for _, key := range keys {
+			pipe.Type(ctx, key)
+			pipe.MemoryUsage(ctx, key)
+			pipe.PTTL(ctx, key)
+		}
+
+		results, err = pipe.Exec(ctx)
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect keys: %w", err)
+	}
+
+	var infos []migrate.KeyInfo
+	for i, key := range keys {
+		typeCmd := results[i*3].(*redis.StatusCmd)
+		usageCmd := results[i*3+1].(*redis.IntCmd)
+		ttlCmd := results[i*3+2].(*redis.DurationCmd)
+
+		// Skip keys that have been deleted or expired since the scan.
+		if typeCmd.Err() != nil || usageCmd.Err() != nil || typeCmd.Val() == "none" {
+			continue
+		}
+
+		infos = append(infos, migrate.KeyInfo{
+			Key:  key,
+			Type: typeCmd.Val(),
+			Size: usageCmd.Val(),
+			TTL:  remainingTTL(ttlCmd.Val()),
+		})
+	}
+
+	return infos, nil
+}
+
+// ExistingKeys reports for every key whether it exists, in input order.
+//
+// One EXISTS is queued per key, so that the pipeline can be routed per hash
+// slot in a cluster.
+func (c *Client) ExistingKeys(ctx context.Context, keys []string) ([]bool, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	var results []redis.Cmder
+	err := c.retryOnFailover(ctx, func() (err error) {
+		pipe := c.client.Pipeline()
+
+		for _, key := range keys {
+			pipe.Exists(ctx, key)
+		}
+
+		results, err = pipe.Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check keys: %w", err)
+	}
+
+	exists := make([]bool, len(keys))
+	for i := range keys {
+		exists[i] = results[i].(*redis.IntCmd).Val() > 0
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,367 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+// ReadValues reads the value and TTL of multiple keys with native commands
+// (GET, HGETALL, LRANGE, SMEMBERS, ZRANGE WITHSCORES and XRANGE), which unlike
+// DUMP don't depend on the RDB version of the server. Keys that don't exist,
+// and types other than [migrate.DataTypes], are omitted. So are keys whose
+// read fails on their own, e.g. with WRONGTYPE because their type changed
+// since it was read, while the other keys are still returned.
+//
+// Only the entries of streams are read, consumer groups are not.
+func (c *Client) ReadValues(ctx context.Context, keys []string) ([]migrate.KeyData, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	var meta []redis.Cmder
+	err := c.retryOnFailover(ctx, func() (err error) {
+		pipe := c.client.Pipeline()
+
+		for _, key := range keys {
+			pipe.Type(ctx, key)
+			pipe.PTTL(ctx, key)
+		}
+
+		meta, err = pipe.Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key types: %w", err)
+	}
+
+	reads := make([]redis.Cmder, len(keys))
+	err = c.retryOnFailover(ctx, func() error {
+		pipe := c.client.Pipeline()
+
+		for i, key := range keys {
+			switch meta[i*2].(*redis.StatusCmd).Val() {
+			case "string":
+				reads[i] = pipe.Get(ctx, key)
+			case "hash":
+				reads[i] = pipe.HGetAll(ctx, key)
+			case "list":
+				reads[i] = pipe.LRange(ctx, key, 0, -1)
+			case "set":
+				reads[i] = pipe.SMembers(ctx, key)
+			case "zset":
+				reads[i] = pipe.ZRangeWithScores(ctx, key, 0, -1)
+			case "stream":
+				// XRANGE is sent as is, as the parsed reply of go-redis loses
+				// the order of the fields.
+				reads[i] = pipe.Do(ctx, "XRANGE", key, "-", "+")
+			}
+		}
+
+		if pipe.Len() == 0 {
+			return nil
+		}
+
+		// Error replies only fail their key, including redis.Nil for a
+		// string deleted since TYPE.
+		_, err := pipe.Exec(ctx)
+		var redisErr redis.Error
+		if err != nil && (isFailoverError(err) || !errors.As(err, &redisErr)) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values: %w", err)
+	}
+
+	var keyData []migrate.KeyData
+	for i, key := range keys {
+		// Skip keys of unsupported types, and keys that have been deleted or
+		// changed type since reading it.
+		if reads[i] == nil || reads[i].Err() != nil {
+			continue
+		}
+
+		value := &migrate.Value{Type: meta[i*2].(*redis.StatusCmd).Val()}
+
+		switch cmd := reads[i].(type) {
+		case *redis.StringCmd:
+			value.String = cmd.Val()
+		case *redis.MapStringStringCmd:
+			value.Hash = cmd.Val()
+		case *redis.StringSliceCmd:
+			if value.Type == "set" {
+				value.Set = cmd.Val()
+			} else {
+				value.List = cmd.Val()
+			}
+		case *redis.ZSliceCmd:
+			for _, z := range cmd.Val() {
+				value.ZSet = append(value.ZSet, migrate.ScoredMember{Member: fmt.Sprint(z.Member), Score: z.Score})
+			}
+		case *redis.Cmd:
+			entries, err := parseStreamEntries(cmd.Val())
+			if err != nil {
+				return nil, fmt.Errorf("failed to read stream %q: %w", key, err)
+			}
+			value.Stream = entries
+		}
+
+		keyData = append(keyData, migrate.KeyData{
+			Key:   key,
+			TTL:   remainingTTL(meta[i*2+1].(*redis.DurationCmd).Val()),
+			Value: value,
+		})
+	}
+
+	return keyData, nil
+}
+
+// parseStreamEntries parses an XRANGE reply.
+func parseStreamEntries(reply any) ([]migrate.StreamEntry, error) {
+	items, ok := reply.([]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected reply %T", reply)
+	}
+
+	entries := make([]migrate.StreamEntry, 0, len(items))
+	for _, item := range items {
+		pair, ok := item.([]any)
+		if !ok || len(pair) != 2 {
+			return nil, fmt.Errorf("unexpected entry %v", item)
+		}
+
+		id, ok := pair[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected entry ID %v", pair[0])
+		}
+
+		values, ok := pair[1].([]any)
+		if !ok {
+			return nil, fmt.Errorf("unexpected fields of entry %s", id)
+		}
+
+		entry := migrate.StreamEntry{ID: id, Fields: make([]string, len(values))}
+		for i, v := range values {
+			entry.Fields[i] = fmt.Sprint(v)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// isIncompatiblePayloadError reports whether err is the reply to a RESTORE of
+// a payload the server can't load.
+func isIncompatiblePayloadError(err error) bool {
+	return strings.Contains(err.Error(), "payload version or checksum are wrong")
+}
+
+// restoreValues writes keys read by [Client.ReadValues] with native commands.
+//
+// Every value is written to a temporary key in the same hash slot first, and
+// then renamed into place with RENAMENX, or RENAME when overwriting. That way
+// a key never exists partially written, and conflicts are detected the same
+// way as with RESTORE.
+func (c *Client) restoreValues(ctx context.Context, data []migrate.KeyData, behavior migrate.ConflictBehavior) ([]migrate.RestoreResult, error) {
+	results := make([]migrate.RestoreResult, len(data))
+	cmds := make([][]redis.Cmder, len(data))
+	commits := make([]commit, len(data))
+
+	write := func() error {
+		pipe := c.client.Pipeline()
+
+		for i, info := range data {
+			results[i] = migrate.RestoreResult{Key: info.Key, Outcome: migrate.OutcomeCreated}
+
+			if !migrate.DataTypes[info.Value.Type] {
+				results[i].Outcome = migrate.OutcomeFailed
+				results[i].Err = fmt.Errorf("type %q can't be written natively", info.Value.Type)
+				continue
+			}
+
+			tmp, err := c.tempKeyFor(info.Key)
+			if err != nil {
+				results[i].Outcome = migrate.OutcomeFailed
+				results[i].Err = err
+				continue
+			}
+
+			cmds[i] = append([]redis.Cmder{pipe.Del(ctx, tmp)}, queueValue(ctx, pipe, tmp, info.Value)...)
+			commits[i] = queueCommit(ctx, pipe, tmp, info.Key, info.TTL, behavior)
+		}
+
+		_, err := pipe.Exec(ctx)
+
+		var redisErr redis.Error
+		if err != nil && (isFailoverError(err) || !errors.As(err, &redisErr)) {
+			return err
+		}
+		return nil
+	}
+
+	// Rewriting the temporary key and renaming it with RENAME can be
+	// repeated, while a repeated RENAMENX would conflict with the key renamed
+	// by the interrupted attempt.
+	var err error
+	if behavior == migrate.OverwriteOnConflict {
+		err = c.retryOnFailover(ctx, write)
+	} else {
+		err = write()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write values: %w", err)
+	}
+
+	for i := range data {
+		if cmds[i] == nil {
+			continue
+		}
+
+		for _, cmd := range cmds[i] {
+			if err := cmd.Err(); err != nil {
+				results[i].Outcome = migrate.OutcomeFailed
+				results[i].Err = err
+				break
+			}
+		}
+
+		if results[i].Outcome != migrate.OutcomeFailed {
+			results[i] = commits[i].result(data[i].Key, behavior)
+		}
+	}
+
+	return results, nil
+}
+
+// tempKeyFor returns the temporary key a value of key is written to before it
+// is renamed into place. In a cluster, it must hash to the same slot as key.
+func (c *Client) tempKeyFor(key string) (string, error) {
+	tmp, ok := tempKey(key)
+	if ok {
+		return tmp, nil
+	}
+
+	if _, cluster := c.client.(*redis.ClusterClient); cluster {
+		return "", fmt.Errorf("key %q can't be written natively in a cluster", key)
+	}
+	return key + tempKeySuffix, nil
+}
+
+// commit holds the commands queued by queueCommit.
+type commit struct {
+	cmds   []redis.Cmder
+	exists *redis.IntCmd
+	rename redis.Cmder
+}
+
+// queueCommit queues the commands moving the temporary key tmp into place as
+// key with conflict handling, and setting its TTL.
+func queueCommit(ctx context.Context, pipe redis.Pipeliner, tmp, key string, ttl time.Duration, behavior migrate.ConflictBehavior) commit {
+	var c commit
+
+	if ttl > 0 {
+		c.cmds = append(c.cmds, pipe.PExpire(ctx, tmp, ttl))
+	}
+
+	if behavior == migrate.OverwriteOnConflict {
+		c.exists = pipe.Exists(ctx, key)
+		c.rename = pipe.Rename(ctx, tmp, key)
+	} else {
+		c.rename = pipe.RenameNX(ctx, tmp, key)
+	}
+	c.cmds = append(c.cmds, c.rename)
+
+	// Removes the temporary key if it hasn't been renamed.
+	pipe.Del(ctx, tmp)
+
+	return c
+}
+
+// result classifies the outcome of a commit of key.
+func (c commit) result(key string, behavior migrate.ConflictBehavior) migrate.RestoreResult {
+	result := migrate.RestoreResult{Key: key, Outcome: migrate.OutcomeCreated}
+
+	for _, cmd := range c.cmds {
+		if err := cmd.Err(); err != nil {
+			result.Outcome = migrate.OutcomeFailed
+			result.Err = err
+			return result
+		}
+	}
+
+	switch {
+	case c.exists != nil && c.exists.Val() > 0:
+		result.Outcome = migrate.OutcomeOverwritten
+	case c.exists == nil && !c.rename.(*redis.BoolCmd).Val():
+		if behavior == migrate.SkipOnConflict {
+			result.Outcome = migrate.OutcomeSkipped
+		} else {
+			result.Outcome = migrate.OutcomeConflict
+		}
+	}
+
+	return result
+}
+
+// queueValue queues the commands adding the elements of value to key.
+func queueValue(ctx context.Context, pipe redis.Pipeliner, key string, value *migrate.Value) []redis.Cmder {
+	var cmds []redis.Cmder
+
+	switch value.Type {
+	case "string":
+		// APPEND writes strings copied in chunks, and equals SET for a
+		// missing key.
+		cmds = append(cmds, pipe.Append(ctx, key, value.String))
+
+	case "hash":
+		args := make([]any, 0, len(value.Hash)*2)
+		for field, v := range value.Hash {
+			args = append(args, field, v)
+		}
+		cmds = append(cmds, pipe.HSet(ctx, key, args...))
+
+	case "list":
+		cmds = append(cmds, pipe.RPush(ctx, key, toArgs(value.List)...))
+
+	case "set":
+		cmds = append(cmds, pipe.SAdd(ctx, key, toArgs(value.Set)...))
+
+	case "zset":
+		members := make([]redis.Z, len(value.ZSet))
+		for i, m := range value.ZSet {
+			members[i] = redis.Z{Member: m.Member, Score: m.Score}
+		}
+		cmds = append(cmds, pipe.ZAdd(ctx, key, members...))
+
+	case "stream":
+		for _, entry := range value.Stream {
+			args := []any{"XADD", key, entry.ID}
+			args = append(args, toArgs(entry.Fields)...)
+			cmds = append(cmds, pipe.Do(ctx, args...))
+		}
+
+		// Streams can exist without entries. Adding an entry and trimming it
+		// right away creates an empty one.
+		if len(value.Stream) == 0 {
+			cmds = append(cmds, pipe.Do(ctx, "XADD", key, "MAXLEN", "0", "*", "_", "_"))
+		}
+	}
+
+	return cmds
+}
+
+func toArgs(values []string) []any {
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
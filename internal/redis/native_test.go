@@ -0,0 +1,103 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+)
+
+// fakeServer returns a client of a server answering every command with the
+// RESP reply returned by reply.
+func fakeServer(t *testing.T, reply func(args []string) string) *Client {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveFake(conn, reply)
+		}
+	}()
+
+	client := redis.NewClient(&redis.Options{
+		Addr:            listener.Addr().String(),
+		Protocol:        2,
+		DisableIdentity: true,
+	})
+	t.Cleanup(func() { client.Close() })
+
+	return &Client{client: client}
+}
+
+func serveFake(conn net.Conn, reply func(args []string) string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	for {
+		var n int
+		if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+			return
+		}
+
+		args := make([]string, n)
+		for i := range args {
+			var size int
+			if _, err := fmt.Fscanf(r, "$%d\r\n", &size); err != nil {
+				return
+			}
+			arg := make([]byte, size+2)
+			if _, err := io.ReadFull(r, arg); err != nil {
+				return
+			}
+			args[i] = string(arg[:size])
+		}
+
+		if _, err := conn.Write([]byte(reply(args))); err != nil {
+			return
+		}
+	}
+}
+
+func TestReadValues_KeyErrors(t *testing.T) {
+	client := fakeServer(t, func(args []string) string {
+		switch strings.ToUpper(args[0]) {
+		case "HELLO":
+			return "-ERR unknown command 'HELLO'\r\n"
+		case "TYPE":
+			return "+string\r\n"
+		case "PTTL":
+			return ":-1\r\n"
+		case "GET":
+			switch args[1] {
+			case "changed":
+				return "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"
+			case "deleted":
+				return "$-1\r\n"
+			}
+			return fmt.Sprintf("$%d\r\n%s\r\n", len("value:"+args[1]), "value:"+args[1])
+		}
+		return "-ERR unexpected command\r\n"
+	})
+
+	values, err := client.ReadValues(context.Background(), []string{"first", "changed", "deleted", "last"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []migrate.KeyData{
+		{Key: "first", Value: &migrate.Value{Type: "string", String: "value:first"}},
+		{Key: "last", Value: &migrate.Value{Type: "string", String: "value:last"}},
+	}, values)
+}
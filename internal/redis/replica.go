@@ -0,0 +1,618 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+	"github.com/pucke-dev/go-redismigrate/internal/rdb"
+)
+
+const (
+	// snapshotBatchSize is the number of keys of a snapshot passed to the
+	// handler at once.
+	snapshotBatchSize = 100
+
+	// streamBatchSize is the maximum number of replicated commands passed to
+	// the handler at once, if more have been received already.
+	streamBatchSize = 1000
+
+	// ackInterval is the interval of the acknowledgements a replica sends to
+	// its master, which drops replicas that stay silent for repl-timeout.
+	ackInterval = time.Second
+)
+
+// Replicate follows every master of the source as a replica: it requests a
+// full synchronization with PSYNC, passes the keys of the database of the
+// connection from the RDB snapshot to handler as DUMP payloads, and then the
+// write commands of the replication stream. Transactions are passed as their
+// individual commands.
+//
+// The source is not changed, but it has to allow the connection to run
+// REPLCONF and PSYNC, and it forks to create the snapshot unless
+// repl-diskless-sync is enabled.
+func (c *Client) Replicate(parent context.Context, handler migrate.ReplicationHandler) error {
+	// The first node failing stops the others.
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	var nodes atomic.Int64
+	if err := c.forEachNode(ctx, func(context.Context, *redis.Client) error {
+		nodes.Add(1)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	snapshotLoaded := func() {
+		if nodes.Add(-1) == 0 {
+			handler.SnapshotLoaded()
+		}
+	}
+
+	err := c.forEachNode(ctx, func(ctx context.Context, node *redis.Client) error {
+		err := replicateNode(ctx, node, handler, snapshotLoaded)
+		if err != nil && ctx.Err() == nil {
+			err = fmt.Errorf("replication of %s failed: %w", node.Options().Addr, err)
+			cancel(err)
+		}
+		return err
+	})
+	if parent.Err() == nil && context.Cause(ctx) != nil {
+		return context.Cause(ctx)
+	}
+	return err
+}
+
+// replicateNode follows a single node, see [Client.Replicate].
+func replicateNode(ctx context.Context, node *redis.Client, handler migrate.ReplicationHandler, snapshotLoaded func()) error {
+	opts := node.Options()
+
+	resolver := &keyResolver{node: node, keyless: make(map[string]bool)}
+	if err := resolver.load(ctx); err != nil {
+		return err
+	}
+
+	conn, err := opts.Dialer(ctx, opts.Network, opts.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	replica := newReplicaConn(conn)
+	return replica.run(ctx, replicaOptions{
+		username: opts.Username,
+		password: opts.Password,
+		db:       opts.DB,
+		keys:     resolver.keys,
+	}, handler, snapshotLoaded)
+}
+
+// replicaOptions configures a [replicaConn].
+type replicaOptions struct {
+	username string
+	password string
+
+	// db is the database whose keys and commands are replicated.
+	db int
+
+	// keys returns the indexes of the key arguments of a command.
+	keys func(ctx context.Context, args []string) ([]int, error)
+}
+
+// replicaConn is a connection to a master, speaking the replication protocol.
+type replicaConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	// mu serializes writes, as acknowledgements are sent concurrently.
+	mu sync.Mutex
+
+	// offset is the replication offset of the commands applied so far.
+	offset atomic.Int64
+}
+
+func newReplicaConn(conn net.Conn) *replicaConn {
+	return &replicaConn{conn: conn, r: bufio.NewReader(conn)}
+}
+
+// run synchronizes with the master and streams its commands until ctx is done
+// or the connection fails. It closes the connection.
+func (c *replicaConn) run(ctx context.Context, opts replicaOptions, handler migrate.ReplicationHandler, snapshotLoaded func()) error {
+	defer c.conn.Close()
+
+	// Blocking reads are interrupted by closing the connection.
+	stop := context.AfterFunc(ctx, func() { c.conn.Close() })
+	defer stop()
+
+	err := c.replicate(ctx, opts, handler, snapshotLoaded)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func (c *replicaConn) replicate(ctx context.Context, opts replicaOptions, handler migrate.ReplicationHandler, snapshotLoaded func()) error {
+	offset, err := c.handshake(opts.username, opts.password)
+	if err != nil {
+		return err
+	}
+	c.offset.Store(offset)
+
+	if err := c.loadSnapshot(ctx, opts.db, handler); err != nil {
+		return err
+	}
+	snapshotLoaded()
+
+	go c.acknowledge(ctx)
+
+	return c.stream(ctx, opts, handler)
+}
+
+// handshake authenticates and requests a full synchronization, and returns the
+// replication offset the snapshot corresponds to.
+func (c *replicaConn) handshake(username, password string) (int64, error) {
+	if password != "" {
+		args := []string{"AUTH"}
+		if username != "" {
+			args = append(args, username)
+		}
+		if _, err := c.call(append(args, password)...); err != nil {
+			return 0, fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	// Snapshots streamed without being written to disk first are delimited
+	// by a marker instead of their length.
+	if _, err := c.call("REPLCONF", "capa", "eof", "capa", "psync2"); err != nil {
+		return 0, fmt.Errorf("failed to announce replica capabilities: %w", err)
+	}
+
+	reply, err := c.call("PSYNC", "?", "-1")
+	if err != nil {
+		return 0, fmt.Errorf("failed to request a full synchronization: %w", err)
+	}
+
+	fields := strings.Fields(reply)
+	if len(fields) != 3 || fields[0] != "FULLRESYNC" {
+		return 0, fmt.Errorf("unexpected reply to PSYNC: %q", reply)
+	}
+
+	offset, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected replication offset %q", fields[2])
+	}
+	return offset, nil
+}
+
+// loadSnapshot reads the RDB snapshot sent by the master and passes the keys
+// of database db to handler in batches.
+func (c *replicaConn) loadSnapshot(ctx context.Context, db int, handler migrate.ReplicationHandler) error {
+	header, err := c.readSnapshotHeader()
+	if err != nil {
+		return err
+	}
+
+	var src io.Reader = c.r
+	var limited *io.LimitedReader
+	mark, diskless := strings.CutPrefix(header, "EOF:")
+	if !diskless {
+		size, err := strconv.ParseInt(header, 10, 64)
+		if err != nil {
+			return fmt.Errorf("unexpected snapshot header %q", header)
+		}
+		limited = &io.LimitedReader{R: c.r, N: size}
+		src = limited
+	}
+
+	reader, err := rdb.NewReader(src)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var batch []migrate.KeyData
+	sized := false
+
+	load := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		// Without a size announced by the snapshot, the total grows with
+		// every batch.
+		if !sized {
+			handler.AddSnapshotSize(int64(len(batch)))
+		}
+		err := handler.LoadSnapshot(ctx, batch)
+		batch = nil
+		return err
+	}
+
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read snapshot: %w", err)
+		}
+
+		if !sized {
+			if size, ok := reader.DBSize(db); ok {
+				handler.AddSnapshotSize(size)
+				sized = true
+			}
+		}
+
+		if entry.DB != db {
+			continue
+		}
+
+		batch = append(batch, migrate.KeyData{
+			Key:  entry.Key,
+			Data: string(rdb.DumpPayload(entry.Type, entry.Value, reader.Version())),
+			TTL:  ttlUntil(entry.ExpireAt),
+		})
+		if len(batch) >= snapshotBatchSize {
+			if err := load(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := load(); err != nil {
+		return err
+	}
+
+	if diskless {
+		end := make([]byte, len(mark))
+		if _, err := io.ReadFull(c.r, end); err != nil {
+			return fmt.Errorf("failed to read end of snapshot: %w", err)
+		}
+		if string(end) != mark {
+			return errors.New("snapshot is not followed by its end marker")
+		}
+	} else if _, err := io.Copy(io.Discard, limited); err != nil {
+		return fmt.Errorf("failed to read end of snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// readSnapshotHeader skips the newlines a master sends while it prepares the
+// snapshot, and returns the size or the end marker announced for it.
+func (c *replicaConn) readSnapshotHeader() (string, error) {
+	for {
+		line, _, err := c.readLine()
+		if err != nil {
+			return "", fmt.Errorf("failed to read snapshot: %w", err)
+		}
+		if line == "" {
+			continue
+		}
+		if header, ok := strings.CutPrefix(line, "$"); ok {
+			return header, nil
+		}
+		return "", fmt.Errorf("unexpected reply before snapshot: %q", line)
+	}
+}
+
+// stream reads the commands of the replication stream and passes the ones
+// that change keys of the replicated database to handler.
+func (c *replicaConn) stream(ctx context.Context, opts replicaOptions, handler migrate.ReplicationHandler) error {
+	// The master selects the database before the first command.
+	selected := -1
+
+	for {
+		args, size, err := c.readCommand()
+		if err != nil {
+			return fmt.Errorf("failed to read replication stream: %w", err)
+		}
+		received := time.Now()
+
+		var cmds []migrate.Command
+		offset := c.offset.Load()
+
+		// flush applies the commands read so far and advances the offset
+		// acknowledged to the master.
+		flush := func() error {
+			if len(cmds) > 0 {
+				if err := handler.Apply(ctx, cmds, received); err != nil {
+					return err
+				}
+				cmds = nil
+			}
+			c.offset.Store(offset)
+			return nil
+		}
+
+		// Commands received already are passed along in the same batch.
+		for {
+			switch name := strings.ToUpper(args[0]); {
+			case name == "SELECT" && len(args) == 2:
+				selected, err = strconv.Atoi(args[1])
+				if err != nil {
+					return fmt.Errorf("unexpected database %q selected", args[1])
+				}
+
+			case name == "REPLCONF" && len(args) > 1 && strings.EqualFold(args[1], "GETACK"):
+				// Like a replica, the offset acknowledged excludes GETACK.
+				if err := flush(); err != nil {
+					return err
+				}
+				if err := c.ack(); err != nil {
+					return err
+				}
+
+			case name == "PING", name == "MULTI", name == "EXEC":
+
+			case selected == opts.db:
+				keys, err := opts.keys(ctx, args)
+				if err != nil {
+					return err
+				}
+				cmds = append(cmds, migrate.Command{Args: args, Keys: keys})
+			}
+
+			offset += int64(size)
+			if len(cmds) >= streamBatchSize || c.r.Buffered() == 0 {
+				break
+			}
+
+			args, size, err = c.readCommand()
+			if err != nil {
+				return fmt.Errorf("failed to read replication stream: %w", err)
+			}
+		}
+
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// acknowledge reports the replication offset to the master periodically,
+// until ctx is done.
+func (c *replicaConn) acknowledge(ctx context.Context) {
+	ticker := time.NewTicker(ackInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// A failing connection ends the stream as well.
+			if err := c.ack(); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ack reports the offset of the commands applied so far to the master.
+func (c *replicaConn) ack() error {
+	return c.send("REPLCONF", "ACK", strconv.FormatInt(c.offset.Load(), 10))
+}
+
+// call sends a command and reads its single-line reply.
+func (c *replicaConn) call(args ...string) (string, error) {
+	if err := c.send(args...); err != nil {
+		return "", err
+	}
+
+	line, _, err := c.readLine()
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case strings.HasPrefix(line, "+"):
+		return line[1:], nil
+	case strings.HasPrefix(line, "-"):
+		return "", errors.New(line[1:])
+	default:
+		return "", fmt.Errorf("unexpected reply %q", line)
+	}
+}
+
+// send writes a command as an array of bulk strings.
+func (c *replicaConn) send(args ...string) error {
+	var buf []byte
+	buf = fmt.Appendf(buf, "*%d\r\n", len(args))
+	for _, arg := range args {
+		buf = fmt.Appendf(buf, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+// readLine reads a line without its line ending, and returns the number of
+// bytes read.
+func (c *replicaConn) readLine() (string, int, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", 0, err
+	}
+	return strings.TrimRight(line, "\r\n"), len(line), nil
+}
+
+// readCommand reads a command of the replication stream, and returns the
+// number of bytes read, by which the replication offset advances.
+func (c *replicaConn) readCommand() ([]string, int, error) {
+	line, size, err := c.readLine()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	count, ok := strings.CutPrefix(line, "*")
+	n, err := strconv.Atoi(count)
+	if !ok || err != nil || n < 1 {
+		return nil, 0, fmt.Errorf("unexpected command %q", line)
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		line, read, err := c.readLine()
+		if err != nil {
+			return nil, 0, err
+		}
+		size += read
+
+		length, ok := strings.CutPrefix(line, "$")
+		l, err := strconv.Atoi(length)
+		if !ok || err != nil || l < 0 {
+			return nil, 0, fmt.Errorf("unexpected argument %q", line)
+		}
+
+		buf := make([]byte, l+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, 0, err
+		}
+		size += len(buf)
+		args[i] = string(buf[:l])
+	}
+
+	return args, size, nil
+}
+
+// ttlUntil returns the TTL of a key expiring at expireAt like
+// [migrate.KeyData.TTL].
+func ttlUntil(expireAt time.Time) time.Duration {
+	if expireAt.IsZero() {
+		return 0
+	}
+	if ttl := time.Until(expireAt); ttl > 0 {
+		return ttl
+	}
+	return migrate.ExpiredTTL
+}
+
+// keyResolver finds the key arguments of commands with the COMMAND metadata of
+// a node.
+type keyResolver struct {
+	node     *redis.Client
+	commands map[string]*redis.CommandInfo
+
+	// keyless records commands that COMMAND GETKEYS found no keys in.
+	keyless map[string]bool
+}
+
+func (r *keyResolver) load(ctx context.Context) error {
+	commands, err := r.node.Command(ctx).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read command metadata: %w", err)
+	}
+	r.commands = commands
+	return nil
+}
+
+// keys returns the indexes of the key arguments in args. Commands whose keys
+// are not at fixed positions, like ZUNIONSTORE or subcommands, are resolved
+// with COMMAND GETKEYS.
+func (r *keyResolver) keys(ctx context.Context, args []string) ([]int, error) {
+	name := strings.ToLower(args[0])
+
+	info, ok := r.commands[name]
+	if ok && info.FirstKeyPos > 0 && !slices.Contains(info.Flags, "movablekeys") {
+		return keyPositions(info, len(args)), nil
+	}
+	if r.keyless[name] {
+		return nil, nil
+	}
+
+	cmd := make([]any, len(args))
+	for i, arg := range args {
+		cmd[i] = arg
+	}
+
+	keys, err := r.node.CommandGetKeys(ctx, cmd...).Result()
+	if err != nil {
+		if strings.Contains(err.Error(), "no key arguments") {
+			r.keyless[name] = true
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find the keys of %s: %w", strings.ToUpper(name), err)
+	}
+
+	return keyIndexes(args, keys), nil
+}
+
+// keyPositions returns the indexes of the keys of a command with n arguments,
+// including its name, from the key positions of its metadata.
+func keyPositions(info *redis.CommandInfo, n int) []int {
+	first, last, step := int(info.FirstKeyPos), int(info.LastKeyPos), int(info.StepCount)
+	if last < 0 {
+		last += n
+	}
+	if step < 1 {
+		step = 1
+	}
+
+	var positions []int
+	for i := first; i <= last && i < n; i += step {
+		positions = append(positions, i)
+	}
+	return positions
+}
+
+// keyIndexes returns the indexes of keys in args, which are in the order of
+// the arguments.
+func keyIndexes(args, keys []string) []int {
+	var indexes []int
+	next := 1
+	for _, key := range keys {
+		for i := next; i < len(args); i++ {
+			if args[i] == key {
+				indexes = append(indexes, i)
+				next = i + 1
+				break
+			}
+		}
+	}
+	return indexes
+}
+
+// ExecCommands executes commands of a replication stream in a pipeline. In a
+// cluster, commands are routed by their keys, so the order is kept per hash
+// slot.
+func (c *Client) ExecCommands(ctx context.Context, cmds []migrate.Command) ([]error, error) {
+	pipe := c.client.Pipeline()
+
+	results := make([]*redis.Cmd, len(cmds))
+	for i, cmd := range cmds {
+		args := make([]any, len(cmd.Args))
+		for j, arg := range cmd.Args {
+			args[j] = arg
+		}
+		results[i] = pipe.Do(ctx, args...)
+	}
+
+	_, err := pipe.Exec(ctx)
+
+	var redisErr redis.Error
+	if err != nil && !errors.As(err, &redisErr) {
+		return nil, err
+	}
+
+	errs := make([]error, len(cmds))
+	for i, result := range results {
+		if err := result.Err(); err != nil && !errors.Is(err, redis.Nil) {
+			errs[i] = err
+		}
+	}
+	return errs, nil
+}
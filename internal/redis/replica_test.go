@@ -0,0 +1,246 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
+	"github.com/pucke-dev/go-redismigrate/internal/rdb"
+)
+
+// replicationRecorder records the calls of a replica.
+type replicationRecorder struct {
+	mu       sync.Mutex
+	size     int64
+	snapshot []migrate.KeyData
+	loaded   bool
+	cmds     []migrate.Command
+}
+
+func (r *replicationRecorder) AddSnapshotSize(keys int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.size += keys
+}
+
+func (r *replicationRecorder) LoadSnapshot(_ context.Context, data []migrate.KeyData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snapshot = append(r.snapshot, data...)
+	return nil
+}
+
+func (r *replicationRecorder) SnapshotLoaded() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loaded = true
+}
+
+func (r *replicationRecorder) Apply(_ context.Context, cmds []migrate.Command, _ time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cmds = append(r.cmds, cmds...)
+	return nil
+}
+
+// fakeMaster plays the master side of the replication protocol.
+type fakeMaster struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// expect reads a command from the replica and compares it with want.
+func (m *fakeMaster) expect(want ...string) {
+	line, err := m.r.ReadString('\n')
+	require.NoError(m.t, err)
+
+	var n int
+	_, err = fmt.Sscanf(line, "*%d\r\n", &n)
+	require.NoError(m.t, err)
+
+	args := make([]string, n)
+	for i := range args {
+		line, err := m.r.ReadString('\n')
+		require.NoError(m.t, err)
+		arg, err := m.r.ReadString('\n')
+		require.NoError(m.t, err)
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+		assert.Equal(m.t, fmt.Sprintf("$%d\r\n", len(args[i])), line)
+	}
+	assert.Equal(m.t, want, args)
+}
+
+func (m *fakeMaster) write(s string) int {
+	_, err := m.conn.Write([]byte(s))
+	require.NoError(m.t, err)
+	return len(s)
+}
+
+// command writes a command of the replication stream and returns its size.
+func (m *fakeMaster) command(args ...string) int {
+	s := fmt.Sprintf("*%d\r\n", len(args))
+	for _, arg := range args {
+		s += fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return m.write(s)
+}
+
+// testSnapshot returns an RDB file with keys in databases 0 and 1.
+func testSnapshot() []byte {
+	data := []byte("REDIS0011")
+	data = append(data, 0xfe, 0, 0xfb, 2, 0)
+	data = append(data, 0xfc)
+	data = binary.LittleEndian.AppendUint64(data, uint64(time.Now().Add(time.Hour).UnixMilli()))
+	data = append(data, rdb.TypeString, 3, 'f', 'o', 'o', 3, 'b', 'a', 'r')
+	data = append(data, rdb.TypeString, 3, 'o', 'l', 'd', 1, 'x')
+	data = append(data, 0xfe, 1, rdb.TypeString, 5, 'o', 't', 'h', 'e', 'r', 1, 'y')
+	data = append(data, 0xff)
+	return binary.LittleEndian.AppendUint64(data, rdb.CRC64(0, data))
+}
+
+func TestReplicaConn(t *testing.T) {
+	snapshot := testSnapshot()
+	mark := strings.Repeat("0123456789", 4)
+
+	tests := []struct {
+		name     string
+		transfer string
+	}{
+		{"disk", fmt.Sprintf("$%d\r\n%s", len(snapshot), snapshot)},
+		{"diskless", fmt.Sprintf("$EOF:%s\r\n%s%s", mark, snapshot, mark)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			client, server := net.Pipe()
+			master := &fakeMaster{t: t, conn: server, r: bufio.NewReader(server)}
+			handler := &replicationRecorder{}
+
+			done := make(chan error, 1)
+			go func() {
+				replica := newReplicaConn(client)
+				done <- replica.run(ctx, replicaOptions{
+					username: "user",
+					password: "secret",
+					keys: func(_ context.Context, args []string) ([]int, error) {
+						return []int{1}, nil
+					},
+				}, handler, handler.SnapshotLoaded)
+			}()
+
+			master.expect("AUTH", "user", "secret")
+			master.write("+OK\r\n")
+			master.expect("REPLCONF", "capa", "eof", "capa", "psync2")
+			master.write("+OK\r\n")
+			master.expect("PSYNC", "?", "-1")
+			master.write("+FULLRESYNC 8de1787ba490483314a4d30f1c628bc5025eb761 100\r\n")
+			master.write("\n\n")
+			master.write(tt.transfer)
+
+			offset := 100
+			offset += master.command("SELECT", "0")
+			offset += master.command("SET", "foo", "1")
+			offset += master.command("SELECT", "1")
+			offset += master.command("SET", "other", "2")
+			offset += master.command("SELECT", "0")
+			offset += master.command("MULTI")
+			offset += master.command("INCR", "foo")
+			offset += master.command("EXEC")
+			offset += master.command("PING")
+			master.command("REPLCONF", "GETACK", "*")
+			master.expect("REPLCONF", "ACK", fmt.Sprint(offset))
+
+			cancel()
+			assert.ErrorIs(t, <-done, context.Canceled)
+
+			handler.mu.Lock()
+			defer handler.mu.Unlock()
+
+			assert.Equal(t, int64(2), handler.size)
+			require.Len(t, handler.snapshot, 2)
+			assert.Equal(t, "foo", handler.snapshot[0].Key)
+			assert.Equal(t, string(rdb.DumpPayload(rdb.TypeString, []byte("\x03bar"), 11)), handler.snapshot[0].Data)
+			assert.InDelta(t, time.Hour, handler.snapshot[0].TTL, float64(time.Minute))
+			assert.Equal(t, "old", handler.snapshot[1].Key)
+			assert.Zero(t, handler.snapshot[1].TTL)
+			assert.True(t, handler.loaded)
+
+			assert.Equal(t, []migrate.Command{
+				{Args: []string{"SET", "foo", "1"}, Keys: []int{1}},
+				{Args: []string{"INCR", "foo"}, Keys: []int{1}},
+			}, handler.cmds)
+		})
+	}
+}
+
+func TestReplicaConn_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		want  string
+	}{
+		{"rejected", "-NOPERM this user has no permissions to run the 'psync' command\r\n", "failed to request a full synchronization: NOPERM"},
+		{"partial", "+CONTINUE\r\n", `unexpected reply to PSYNC: "CONTINUE"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			master := &fakeMaster{t: t, conn: server, r: bufio.NewReader(server)}
+
+			done := make(chan error, 1)
+			go func() {
+				handler := &replicationRecorder{}
+				done <- newReplicaConn(client).run(context.Background(), replicaOptions{}, handler, handler.SnapshotLoaded)
+			}()
+
+			master.expect("REPLCONF", "capa", "eof", "capa", "psync2")
+			master.write("+OK\r\n")
+			master.expect("PSYNC", "?", "-1")
+			master.write(tt.reply)
+
+			assert.ErrorContains(t, <-done, tt.want)
+		})
+	}
+}
+
+func TestKeyPositions(t *testing.T) {
+	tests := []struct {
+		name string
+		info redis.CommandInfo
+		args int
+		want []int
+	}{
+		{"single key", redis.CommandInfo{FirstKeyPos: 1, LastKeyPos: 1, StepCount: 1}, 3, []int{1}},
+		{"all keys", redis.CommandInfo{FirstKeyPos: 1, LastKeyPos: -1, StepCount: 1}, 4, []int{1, 2, 3}},
+		{"key value pairs", redis.CommandInfo{FirstKeyPos: 1, LastKeyPos: -1, StepCount: 2}, 5, []int{1, 3}},
+		{"all but last", redis.CommandInfo{FirstKeyPos: 1, LastKeyPos: -2, StepCount: 1}, 4, []int{1, 2}},
+		{"two keys", redis.CommandInfo{FirstKeyPos: 1, LastKeyPos: 2, StepCount: 1}, 3, []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, keyPositions(&tt.info, tt.args))
+		})
+	}
+}
+
+func TestKeyIndexes(t *testing.T) {
+	args := []string{"ZUNIONSTORE", "dest", "2", "a", "dest", "WEIGHTS", "1", "2"}
+	assert.Equal(t, []int{1, 3, 4}, keyIndexes(args, []string{"dest", "a", "dest"}))
+}
@@ -0,0 +1,177 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// sentinelScheme selects a Sentinel-managed deployment.
+	sentinelScheme = "redis+sentinel"
+
+	// sentinelTLSScheme selects a Sentinel-managed deployment reached over TLS.
+	sentinelTLSScheme = "rediss+sentinel"
+
+	// defaultSentinelPort is used for sentinel addresses without an explicit port.
+	defaultSentinelPort = "26379"
+)
+
+const (
+	// failoverRetries is the number of times an operation is retried while a
+	// Sentinel failover is in progress.
+	failoverRetries = 10
+
+	// failoverMinBackoff and failoverMaxBackoff bound the delay between retries.
+	// With the defaults an operation survives roughly half a minute of failover.
+	failoverMinBackoff = 250 * time.Millisecond
+	failoverMaxBackoff = 5 * time.Second
+)
+
+// parseSentinelURL parses a connection string for a Sentinel-managed master:
+//
+//	redis+sentinel://[user:pass@]host:port/master[/db]?addr=host:port&sentinel_password=secret
+//
+// The URL host and any addr query parameters are the sentinel addresses. The
+// user info authenticates against the master, while sentinel_username and
+// sentinel_password authenticate against the sentinels themselves.
+func parseSentinelURL(connStr string) (*redis.FailoverOptions, error) {
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &redis.FailoverOptions{}
+
+	switch u.Scheme {
+	case sentinelTLSScheme:
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	case sentinelScheme:
+	default:
+		return nil, fmt.Errorf("invalid sentinel URL scheme: %s", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return nil, errors.New("no sentinel address provided")
+	}
+	opts.SentinelAddrs = append(opts.SentinelAddrs, withDefaultPort(u.Host, defaultSentinelPort))
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if segments[0] == "" {
+		return nil, errors.New("no sentinel master name provided")
+	}
+	opts.MasterName = segments[0]
+
+	switch len(segments) {
+	case 1:
+	case 2:
+		if opts.DB, err = strconv.Atoi(segments[1]); err != nil {
+			return nil, fmt.Errorf("invalid database number: %q", segments[1])
+		}
+	default:
+		return nil, fmt.Errorf("invalid sentinel URL path: %q", u.Path)
+	}
+
+	if u.User != nil {
+		opts.Username = u.User.Username()
+		opts.Password, _ = u.User.Password()
+	}
+
+	for name, values := range u.Query() {
+		switch name {
+		case "addr":
+			for _, addr := range values {
+				opts.SentinelAddrs = append(opts.SentinelAddrs, withDefaultPort(addr, defaultSentinelPort))
+			}
+		case "sentinel_username":
+			opts.SentinelUsername = values[len(values)-1]
+		case "sentinel_password":
+			opts.SentinelPassword = values[len(values)-1]
+		default:
+			return nil, fmt.Errorf("unexpected option: %s", name)
+		}
+	}
+
+	return opts, nil
+}
+
+func withDefaultPort(addr, port string) string {
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr
+	}
+	return net.JoinHostPort(addr, port)
+}
+
+// retryOnFailover runs op and, for Sentinel-managed clients, retries it with
+// exponential backoff while the error indicates a failover in progress.
+//
+// The failover client closes connections to a demoted master and resolves the
+// new master through the sentinels when it redials, so a retried operation
+// transparently lands on the promoted replica.
+//
+// A failover can interrupt a pipeline after some of its commands have been
+// applied, so op is run again from the start. Only operations that are safe
+// to repeat may be retried: reads, deletes, and writes replacing whole values
+// or setting elements, like RESTORE REPLACE, RENAME, HSET, SADD and ZADD.
+// Appends (APPEND, RPUSH, XADD) would duplicate elements, and conditional
+// writes (RESTORE, RENAMENX) would report the keys written by the
+// interrupted attempt as conflicts, so they are only retried where the caller
+// accounts for that.
+func (c *Client) retryOnFailover(ctx context.Context, op func() error) error {
+	err := op()
+	if !c.failover {
+		return err
+	}
+
+	backoff := failoverMinBackoff
+	for attempt := 0; attempt < failoverRetries && isFailoverError(err); attempt++ {
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		backoff = min(backoff*2, failoverMaxBackoff)
+		err = op()
+	}
+
+	return err
+}
+
+// isFailoverError reports whether err is caused by the master becoming
+// unavailable or being demoted, as happens during a Sentinel failover.
+func isFailoverError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) {
+		msg := redisErr.Error()
+		for _, prefix := range []string{"READONLY ", "LOADING ", "MASTERDOWN ", "TRYAGAIN "} {
+			if strings.HasPrefix(msg, prefix) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -5,6 +5,23 @@ import (
 	"time"
 )
 
+// Snapshot is a point-in-time copy of the counters of [Metrics], used to
+// persist and restore the progress of a migration.
+type Snapshot struct {
+	Total       int64         `json:"total"`
+	Processed   int64         `json:"processed"`
+	Success     int64         `json:"success"`
+	Failed      int64         `json:"failed"`
+	Skipped     int64         `json:"skipped"`
+	Overwritten int64         `json:"overwritten"`
+	Conflicted  int64         `json:"conflicted"`
+	Native      int64         `json:"native"`
+	BigKeys     int64         `json:"big_keys"`
+	Expired     int64         `json:"expired"`
+	Dropped     int64         `json:"dropped"`
+	Elapsed     time.Duration `json:"elapsed"`
+}
+
 // Metrics handles statistics tracking and performance calculations.
 type Metrics struct {
 	// totalKeys tracks the total number of keys in the source db matching the provided pattern.
@@ -25,6 +42,38 @@ type Metrics struct {
 	// overwrittenKeys tracks the number of keys that were overwritten in the destination.
 	overwrittenKeys atomic.Int64
 
+	// conflictedKeys tracks the number of keys that already existed in the destination
+	// while conflicts are treated as errors.
+	conflictedKeys atomic.Int64
+
+	// nativeKeys tracks the number of keys copied with native commands because the
+	// destination rejected their DUMP payload.
+	nativeKeys atomic.Int64
+
+	// bigKeys tracks the number of keys above the big key threshold, which are copied in chunks.
+	bigKeys atomic.Int64
+
+	// expiredKeys tracks the number of keys that expired in the source while they were migrated.
+	expiredKeys atomic.Int64
+
+	// droppedKeys tracks the number of keys left out because their remaining TTL was below the minimum.
+	droppedKeys atomic.Int64
+
+	// followEvents tracks the number of keyspace notifications replicated while following changes.
+	followEvents atomic.Int64
+
+	// pendingKeys tracks the number of changed keys waiting to be replicated while following changes.
+	pendingKeys atomic.Int64
+
+	// followLag tracks the time the oldest change being replicated has waited, in nanoseconds.
+	followLag atomic.Int64
+
+	// following records whether the initial migration has completed and changes are being followed.
+	following atomic.Bool
+
+	// interrupted records whether the migration was stopped before all keys were processed.
+	interrupted atomic.Bool
+
 	// startTime records when the tracking started.
 	startTime time.Time
 }
@@ -39,6 +88,12 @@ func (m *Metrics) SetTotal(total int64) {
 	m.totalKeys.Store(total)
 }
 
+// AddTotal adjusts the total by count, e.g. for keys filtered out after the
+// total has been set.
+func (m *Metrics) AddTotal(count int64) {
+	m.totalKeys.Add(count)
+}
+
 func (m *Metrics) AddProcessed(count int64) {
 	m.processedKeys.Add(count)
 }
@@ -59,6 +114,111 @@ func (m *Metrics) AddOverwritten(count int64) {
 	m.overwrittenKeys.Add(count)
 }
 
+func (m *Metrics) AddConflicted(count int64) {
+	m.conflictedKeys.Add(count)
+}
+
+func (m *Metrics) AddNative(count int64) {
+	m.nativeKeys.Add(count)
+}
+
+func (m *Metrics) AddBigKeys(count int64) {
+	m.bigKeys.Add(count)
+}
+
+func (m *Metrics) AddExpired(count int64) {
+	m.expiredKeys.Add(count)
+}
+
+func (m *Metrics) AddDropped(count int64) {
+	m.droppedKeys.Add(count)
+}
+
+// AddFollowEvents counts keyspace notifications whose changes have been
+// replicated.
+func (m *Metrics) AddFollowEvents(count int64) {
+	m.followEvents.Add(count)
+}
+
+func (m *Metrics) SetPendingKeys(count int64) {
+	m.pendingKeys.Store(count)
+}
+
+// SetFollowLag records the time the oldest change being replicated has waited
+// since it was notified.
+func (m *Metrics) SetFollowLag(lag time.Duration) {
+	m.followLag.Store(int64(lag))
+}
+
+// MarkFollowing records that the initial migration has completed and changes
+// are being followed.
+func (m *Metrics) MarkFollowing() {
+	m.following.Store(true)
+}
+
+// IsFollowing reports whether changes are being followed.
+func (m *Metrics) IsFollowing() bool {
+	return m.following.Load()
+}
+
+func (m *Metrics) GetFollowEvents() int64 {
+	return m.followEvents.Load()
+}
+
+func (m *Metrics) GetPendingKeys() int64 {
+	return m.pendingKeys.Load()
+}
+
+func (m *Metrics) GetFollowLag() time.Duration {
+	return time.Duration(m.followLag.Load())
+}
+
+// Snapshot returns a copy of the current counters and elapsed time.
+func (m *Metrics) Snapshot() Snapshot {
+	return Snapshot{
+		Total:       m.totalKeys.Load(),
+		Processed:   m.processedKeys.Load(),
+		Success:     m.successKeys.Load(),
+		Failed:      m.failedKeys.Load(),
+		Skipped:     m.skippedKeys.Load(),
+		Overwritten: m.overwrittenKeys.Load(),
+		Conflicted:  m.conflictedKeys.Load(),
+		Native:      m.nativeKeys.Load(),
+		BigKeys:     m.bigKeys.Load(),
+		Expired:     m.expiredKeys.Load(),
+		Dropped:     m.droppedKeys.Load(),
+		Elapsed:     m.GetElapsed(),
+	}
+}
+
+// Restore replaces the counters with those of a snapshot and moves the start
+// time back by the elapsed time of the snapshot, so that rates and the ETA
+// continue where the snapshot left off.
+func (m *Metrics) Restore(snapshot Snapshot) {
+	m.totalKeys.Store(snapshot.Total)
+	m.processedKeys.Store(snapshot.Processed)
+	m.successKeys.Store(snapshot.Success)
+	m.failedKeys.Store(snapshot.Failed)
+	m.skippedKeys.Store(snapshot.Skipped)
+	m.overwrittenKeys.Store(snapshot.Overwritten)
+	m.conflictedKeys.Store(snapshot.Conflicted)
+	m.nativeKeys.Store(snapshot.Native)
+	m.bigKeys.Store(snapshot.BigKeys)
+	m.expiredKeys.Store(snapshot.Expired)
+	m.droppedKeys.Store(snapshot.Dropped)
+	m.startTime = time.Now().Add(-snapshot.Elapsed)
+}
+
+// MarkInterrupted records that the migration was stopped before completion.
+func (m *Metrics) MarkInterrupted() {
+	m.interrupted.Store(true)
+}
+
+// IsInterrupted reports whether the migration was stopped before completion.
+func (m *Metrics) IsInterrupted() bool {
+	return m.interrupted.Load()
+}
+
 func (m *Metrics) GetStartTime() time.Time {
 	return m.startTime
 }
@@ -131,6 +291,26 @@ func (m *Metrics) GetOverwrittenKeys() int64 {
 	return m.overwrittenKeys.Load()
 }
 
+func (m *Metrics) GetConflictedKeys() int64 {
+	return m.conflictedKeys.Load()
+}
+
+func (m *Metrics) GetNativeKeys() int64 {
+	return m.nativeKeys.Load()
+}
+
+func (m *Metrics) GetBigKeys() int64 {
+	return m.bigKeys.Load()
+}
+
+func (m *Metrics) GetExpiredKeys() int64 {
+	return m.expiredKeys.Load()
+}
+
+func (m *Metrics) GetDroppedKeys() int64 {
+	return m.droppedKeys.Load()
+}
+
 func (m *Metrics) GetSkippedKeys() int64 {
 	return m.skippedKeys.Load()
 }
@@ -151,3 +331,8 @@ func (m *Metrics) GetTotalKeys() int64 {
 	return m.totalKeys.Load()
 }
 
+// GetRemainingKeys returns the number of keys that have not been processed yet.
+func (m *Metrics) GetRemainingKeys() int64 {
+	return max(0, m.totalKeys.Load()-m.processedKeys.Load())
+}
+
@@ -38,6 +38,13 @@ func TestMetrics_AddCounters(t *testing.T) {
 		values   []int64
 		expected int64
 	}{
+		{
+			name:     "AddTotal",
+			addFunc:  (*Metrics).AddTotal,
+			getFunc:  (*Metrics).GetTotalKeys,
+			values:   []int64{10, -3},
+			expected: 7,
+		},
 		{
 			name:     "AddProcessed",
 			addFunc:  (*Metrics).AddProcessed,
@@ -73,6 +80,48 @@ func TestMetrics_AddCounters(t *testing.T) {
 			values:   []int64{8, 2},
 			expected: 10,
 		},
+		{
+			name:     "AddConflicted",
+			addFunc:  (*Metrics).AddConflicted,
+			getFunc:  (*Metrics).GetConflictedKeys,
+			values:   []int64{1, 2},
+			expected: 3,
+		},
+		{
+			name:     "AddNative",
+			addFunc:  (*Metrics).AddNative,
+			getFunc:  (*Metrics).GetNativeKeys,
+			values:   []int64{5, 1},
+			expected: 6,
+		},
+		{
+			name:     "AddBigKeys",
+			addFunc:  (*Metrics).AddBigKeys,
+			getFunc:  (*Metrics).GetBigKeys,
+			values:   []int64{1, 1},
+			expected: 2,
+		},
+		{
+			name:     "AddExpired",
+			addFunc:  (*Metrics).AddExpired,
+			getFunc:  (*Metrics).GetExpiredKeys,
+			values:   []int64{2, 1},
+			expected: 3,
+		},
+		{
+			name:     "AddDropped",
+			addFunc:  (*Metrics).AddDropped,
+			getFunc:  (*Metrics).GetDroppedKeys,
+			values:   []int64{4, 3},
+			expected: 7,
+		},
+		{
+			name:     "AddFollowEvents",
+			addFunc:  (*Metrics).AddFollowEvents,
+			getFunc:  (*Metrics).GetFollowEvents,
+			values:   []int64{3, 9},
+			expected: 12,
+		},
 	}
 
 	for _, tt := range tests {
@@ -90,6 +139,27 @@ func TestMetrics_AddCounters(t *testing.T) {
 	}
 }
 
+func TestMetrics_Following(t *testing.T) {
+	metrics := NewMetrics()
+	if metrics.IsFollowing() {
+		t.Error("IsFollowing() = true before MarkFollowing()")
+	}
+
+	metrics.MarkFollowing()
+	metrics.SetPendingKeys(4)
+	metrics.SetFollowLag(250 * time.Millisecond)
+
+	if !metrics.IsFollowing() {
+		t.Error("IsFollowing() = false after MarkFollowing()")
+	}
+	if got := metrics.GetPendingKeys(); got != 4 {
+		t.Errorf("GetPendingKeys() = %d, want 4", got)
+	}
+	if got := metrics.GetFollowLag(); got != 250*time.Millisecond {
+		t.Errorf("GetFollowLag() = %v, want 250ms", got)
+	}
+}
+
 func TestMetrics_GetProgress(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -118,7 +188,7 @@ func TestMetrics_GetProgress(t *testing.T) {
 }
 
 func TestMetrics_GetProcessingRate(t *testing.T) {
-	synctest.Run(func() {
+	synctest.Test(t, func(t *testing.T) {
 		metrics := NewMetrics()
 		rate := metrics.GetProcessingRate()
 		if rate != 0.0 {
@@ -157,7 +227,7 @@ func TestMetrics_GetETA(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			synctest.Run(func() {
+			synctest.Test(t, func(t *testing.T) {
 				metrics := NewMetrics()
 				metrics.SetTotal(tt.total)
 
@@ -227,7 +297,7 @@ func TestMetrics_GetFailureRate(t *testing.T) {
 }
 
 func TestMetrics_GetElapsed(t *testing.T) {
-	synctest.Run(func() {
+	synctest.Test(t, func(t *testing.T) {
 		metrics := NewMetrics()
 		time.Sleep(time.Second)
 
@@ -254,6 +324,7 @@ func TestMetrics_ThreadSafety(t *testing.T) {
 				metrics.AddFailed(1)
 				metrics.AddSkipped(1)
 				metrics.AddOverwritten(1)
+				metrics.AddConflicted(1)
 			}
 		}()
 	}
@@ -281,5 +352,58 @@ func TestMetrics_ThreadSafety(t *testing.T) {
 	if got := metrics.GetOverwrittenKeys(); got != expected {
 		t.Errorf("GetOverwrittenKeys() = %d, want %d", got, expected)
 	}
+
+	if got := metrics.GetConflictedKeys(); got != expected {
+		t.Errorf("GetConflictedKeys() = %d, want %d", got, expected)
+	}
 }
 
+func TestMetrics_SnapshotRestore(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		metrics := NewMetrics()
+		metrics.SetTotal(100)
+		metrics.AddProcessed(60)
+		metrics.AddSuccess(50)
+		metrics.AddFailed(4)
+		metrics.AddSkipped(3)
+		metrics.AddOverwritten(2)
+		metrics.AddConflicted(1)
+		metrics.AddNative(5)
+		metrics.AddBigKeys(2)
+		metrics.AddExpired(1)
+		metrics.AddDropped(2)
+		time.Sleep(time.Minute)
+
+		snapshot := metrics.Snapshot()
+		want := Snapshot{
+			Total:       100,
+			Processed:   60,
+			Success:     50,
+			Failed:      4,
+			Skipped:     3,
+			Overwritten: 2,
+			Conflicted:  1,
+			Native:      5,
+			BigKeys:     2,
+			Expired:     1,
+			Dropped:     2,
+			Elapsed:     time.Minute,
+		}
+		if snapshot != want {
+			t.Errorf("Snapshot() = %+v, want %+v", snapshot, want)
+		}
+
+		restored := NewMetrics()
+		restored.Restore(snapshot)
+
+		if got := restored.Snapshot(); got != want {
+			t.Errorf("Snapshot() after Restore() = %+v, want %+v", got, want)
+		}
+
+		time.Sleep(time.Minute)
+
+		if elapsed := restored.GetElapsed(); elapsed != 2*time.Minute {
+			t.Errorf("GetElapsed() after Restore() = %v, want 2m0s", elapsed)
+		}
+	})
+}
@@ -2,10 +2,13 @@ package tui
 
 import (
 	"fmt"
+	"maps"
 	"math"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
 	"github.com/pucke-dev/go-redismigrate/internal/stats"
 )
 
@@ -24,6 +27,28 @@ func FormatDuration(duration time.Duration) string {
 	return duration.Round(time.Second).String()
 }
 
+// FormatLag formats the replication lag while following changes, which is
+// usually well below a second.
+func FormatLag(lag time.Duration) string {
+	return lag.Round(time.Millisecond).String()
+}
+
+// FormatBytes formats a size in bytes for display, using binary units.
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
 // FormatUsage returns a beautifully formatted usage message.
 func FormatUsage() string {
 	usage := strings.Builder{}
@@ -42,6 +67,16 @@ func FormatUsage() string {
 	usage.WriteString(Styles.Program.Render("redismigrate"))
 	usage.WriteString(" ")
 	usage.WriteString(Styles.Argument.Render("[options]"))
+	usage.WriteString("\n")
+	usage.WriteString("       ")
+	usage.WriteString(Styles.Program.Render("redismigrate verify"))
+	usage.WriteString(" ")
+	usage.WriteString(Styles.Argument.Render("[options]"))
+	usage.WriteString("\n")
+	usage.WriteString("       ")
+	usage.WriteString(Styles.Program.Render("redismigrate export"))
+	usage.WriteString(" ")
+	usage.WriteString(Styles.Argument.Render("[options]"))
 	usage.WriteString("\n\n")
 
 	// Options header.
@@ -53,24 +88,66 @@ func FormatUsage() string {
 		name, desc, defaultVal string
 		required               bool
 	}{
-		{"--source", "Source Redis connection string", "", true},
-		{"--dest", "Destination Redis connection string", "", true},
+		{"--source", "Source Redis connection string, or file:// RDB, NDJSON or archive", "", true},
+		{"--dest", "Destination Redis connection string, can be repeated", "", true},
+		{"--output", "File written by export, - for stdout", "", false},
+		{"--format", "Format of the export file: archive, rdb or ndjson", "archive", false},
+		{"--source-tls-ca", "CA bundle to verify the source certificate", "", false},
+		{"--source-tls-cert", "Client certificate for the source (mutual TLS)", "", false},
+		{"--source-tls-key", "Client key for the source (mutual TLS)", "", false},
+		{"--source-tls-server-name", "Server name (SNI) of the source", "", false},
+		{"--source-tls-insecure", "Skip verification of the source certificate", "false", false},
+		{"--dest-tls-ca", "CA bundle to verify the destination certificate", "", false},
+		{"--dest-tls-cert", "Client certificate for the destination (mutual TLS)", "", false},
+		{"--dest-tls-key", "Client key for the destination (mutual TLS)", "", false},
+		{"--dest-tls-server-name", "Server name (SNI) of the destination", "", false},
+		{"--dest-tls-insecure", "Skip verification of the destination certificate", "false", false},
 		{"--pattern", "Key pattern to match (Redis glob pattern)", "*", false},
+		{"--include", "Only keys matching one of these patterns (repeatable)", "", false},
+		{"--exclude", "Skip keys matching this pattern (repeatable)", "", false},
+		{"--regex", "Only keys matching this regular expression", "", false},
+		{"--type", "Only keys of these types, e.g. hash,zset", "", false},
+		{"--idle-min", "Only keys idle for at least this long, e.g. 30d", "", false},
+		{"--idle-max", "Only keys accessed within this duration", "", false},
+		{"--freq-min", "Only keys with at least this LFU access frequency", "0", false},
+		{"--freq-max", "Only keys with at most this LFU access frequency", "0", false},
+		{"--rewrite-prefix", "Replace a key prefix in the destination (from=to)", "", false},
+		{"--rewrite-regex", "Rewrite keys with a regular expression (expression=replacement)", "", false},
+		{"--rewrite-template", "Rename keys with a template, e.g. legacy:{{.Key}}", "", false},
+		{"--ttl-min", "Skip keys expiring sooner than this, e.g. 1m", "", false},
+		{"--ttl-fixed", "Set the TTL of every key, e.g. 24h", "", false},
+		{"--ttl-offset", "Add this to the TTL of expiring keys", "", false},
+		{"--ttl-max", "Cap the TTL of every key", "", false},
+		{"--ttl-persist", "Remove the TTL of every key", "false", false},
 		{"--mode", "Migration mode", "copy", false},
 		{"--conflict", "Key conflict behavior", "error", false},
 		{"--batch-size", "Number of keys to process in each batch", "100", false},
 		{"--concurrency", "Number of concurrent workers", "4", false},
+		{"--big-key-threshold", "Copy keys above this memory usage (e.g. 64MB) in chunks", "", false},
+		{"--checkpoint", "Write progress to a checkpoint file", "", false},
+		{"--resume", "Resume the migration recorded in a checkpoint file", "", false},
+		{"--dry-run", "Show what the migration would do without changing anything", "false", false},
+		{"--follow", "Keep replicating changes after the migration until stopped", "false", false},
+		{"--psync", "Follow the source as a replica with PSYNC, implies --follow", "false", false},
+		{"--verify", "Verify the destination after a copy", "false", false},
+		{"--verify-ttl-tolerance", "Maximum TTL difference accepted by verification", "5s", false},
 		{"--verbose", "Enable verbose logging", "false", false},
 		{"--version", "Show version information", "", false},
 		{"--help", "Show this help message", "", false},
 	}
 
+	// Align descriptions two columns after the longest flag name.
+	width := 0
+	for _, flag := range flags {
+		width = max(width, len(flag.name)+2)
+	}
+
 	for _, flag := range flags {
 		usage.WriteString("  ")
 		usage.WriteString(Styles.Flag.Render(flag.name))
 
 		// Add spacing for alignment.
-		spaces := strings.Repeat(" ", int(math.Max(0, float64(15-len(flag.name)))))
+		spaces := strings.Repeat(" ", int(math.Max(0, float64(width-len(flag.name)))))
 		usage.WriteString(spaces)
 
 		usage.WriteString(Styles.Description.Render(flag.desc))
@@ -104,6 +181,86 @@ func FormatUsage() string {
 			"Move specific pattern:",
 			"redismigrate -source redis://src:6379/0 -dest redis://dst:6379/0 -pattern \"user:*\" -mode move",
 		},
+		{
+			"Copy everything under app:* except caches and locks:",
+			"redismigrate -source redis://src:6379/0 -dest redis://dst:6379/0 -pattern \"app:*\" -exclude \"app:cache:*\" -exclude \"app:lock:*\"",
+		},
+		{
+			"Archive cache entries untouched for 30 days:",
+			"redismigrate -source redis://cache:6379/0 -dest redis://archive:6379/0 -pattern \"cache:*\" -idle-min 30d -mode move",
+		},
+		{
+			"Move only streams:",
+			"redismigrate -source redis://src:6379/0 -dest redis://dst:6379/0 -type stream -mode move",
+		},
+		{
+			"Copy a tenant under a new prefix:",
+			"redismigrate -source redis://src:6379/0 -dest redis://dst:6379/0 -pattern \"tenantA:*\" -rewrite-prefix tenantA:=legacy:tenantA:",
+		},
+		{
+			"Move sessions, dropping those about to expire:",
+			"redismigrate -source redis://src:6379/0 -dest redis://dst:6379/0 -pattern \"session:*\" -mode move -ttl-min 5m",
+		},
+		{
+			"Migrate into a Redis Cluster:",
+			"redismigrate -source redis://src:6379/0 -dest \"redis+cluster://node1:7000?addr=node2:7000&addr=node3:7000\"",
+		},
+		{
+			"Seed two regional instances, moving keys once both have them:",
+			"redismigrate -source redis://src:6379/0 -dest redis://eu:6379/0 -dest redis://us:6379/0 -mode move",
+		},
+		{
+			"Preview a move without changing anything:",
+			"redismigrate -source redis://src:6379/0 -dest redis://dst:6379/0 -pattern \"user:*\" -mode move -dry-run",
+		},
+		{
+			"Copy and verify the destination afterwards:",
+			"redismigrate -source redis://src:6379/0 -dest redis://dst:6379/0 -verify",
+		},
+		{
+			"Copy and keep following changes for a cutover:",
+			"redismigrate -source redis://src:6379/0 -dest redis://dst:6379/0 -follow",
+		},
+		{
+			"Follow the source as a replica for an exact cutover:",
+			"redismigrate -source redis://src:6379/0 -dest redis://dst:6379/0 -psync",
+		},
+		{
+			"Verify a previous copy:",
+			"redismigrate verify -source redis://src:6379/0 -dest redis://dst:6379/0 -pattern \"user:*\"",
+		},
+		{
+			"Export user keys to an archive:",
+			"redismigrate export -source redis://src:6379/0 -pattern \"user:*\" -output users.archive",
+		},
+		{
+			"Stream an export to another host:",
+			"redismigrate export -source redis://src:6379/0 -output - | ssh backup 'cat > keys.archive'",
+		},
+		{
+			"Write user keys to an RDB file to seed a dev instance:",
+			"redismigrate export -source redis://src:6379/0 -pattern \"user:*\" -format rdb -output dump.rdb",
+		},
+		{
+			"Export readable fixtures to NDJSON:",
+			"redismigrate export -source redis://src:6379/0 -pattern \"fixture:*\" -format ndjson -output fixtures.ndjson",
+		},
+		{
+			"Import an archive, keeping existing keys:",
+			"redismigrate -source file://users.archive -dest redis://dst:6379/0 -conflict skip",
+		},
+		{
+			"Restore user keys from a backup RDB file:",
+			"redismigrate -source \"file://dump.rdb?db=0\" -dest redis://dst:6379/0 -pattern \"user:*\"",
+		},
+		{
+			"Resume an interrupted migration:",
+			"redismigrate -source redis://src:6379/0 -dest redis://dst:6379/0 -resume migration.checkpoint",
+		},
+		{
+			"Copy keys above 64MB in chunks:",
+			"redismigrate -source redis://src:6379/0 -dest redis://dst:6379/0 -big-key-threshold 64MB",
+		},
 		{
 			"High throughput with custom batch size:",
 			"redismigrate -source redis://src:6379/0 -dest redis://dst:6379/0 -batch-size 500 -concurrency 8",
@@ -191,6 +348,54 @@ func FormatSummary(metrics *stats.Metrics, mode, pattern, conflict, sourceURL, d
 	content.WriteString(Styles.Config.Render(destURL))
 	content.WriteString("\n")
 
+	content.WriteString(formatCounts(metrics, conflict))
+	content.WriteString("\n")
+
+	if metrics.IsFollowing() {
+		content.WriteString("Followed changes: ")
+		content.WriteString(Styles.InfoStatus.Render(FormatCount(metrics.GetFollowEvents())))
+		content.WriteString(" events applied\n")
+	}
+
+	if metrics.IsInterrupted() {
+		content.WriteString("Status: ")
+		content.WriteString(Styles.ErrorStatus.Render("interrupted"))
+		content.WriteString(" | Remaining: ")
+		content.WriteString(Styles.InfoStatus.Render(FormatCount(metrics.GetRemainingKeys())))
+		content.WriteString("\n")
+	}
+
+	content.WriteString("Rate: ")
+	content.WriteString(Styles.InfoStatus.Render(FormatRate(metrics.GetProcessingRate())))
+	content.WriteString(" | Elapsed: ")
+	content.WriteString(Styles.InfoStatus.Render(FormatDuration(metrics.GetElapsed())))
+
+	return content.String()
+}
+
+// FormatDestinations formats the outcomes of a migration per destination,
+// following its summary when keys were restored to several destinations.
+func FormatDestinations(destinations []migrate.Destination, conflict string) string {
+	var content strings.Builder
+
+	for i, dest := range destinations {
+		if i > 0 {
+			content.WriteString("\n")
+		}
+		content.WriteString("Destination: ")
+		content.WriteString(Styles.Config.Render(dest.Name))
+		content.WriteString("\n")
+		content.WriteString(formatCounts(dest.Metrics, conflict))
+	}
+
+	return content.String()
+}
+
+// formatCounts formats the key counts of a summary, without a trailing
+// newline.
+func formatCounts(metrics *stats.Metrics, conflict string) string {
+	var content strings.Builder
+
 	content.WriteString("Total: ")
 	content.WriteString(Styles.InfoStatus.Render(FormatCount(metrics.GetTotalKeys())))
 	content.WriteString(" | Processed: ")
@@ -201,6 +406,9 @@ func FormatSummary(metrics *stats.Metrics, mode, pattern, conflict, sourceURL, d
 	content.WriteString(Styles.ErrorStatus.Render(FormatCount(metrics.GetFailedKeys())))
 
 	switch conflict {
+	case "error":
+		content.WriteString(" | Conflicts: ")
+		content.WriteString(Styles.ErrorStatus.Render(FormatCount(metrics.GetConflictedKeys())))
 	case "skip":
 		content.WriteString(" | Skipped: ")
 		content.WriteString(Styles.InfoStatus.Render(FormatCount(metrics.GetSkippedKeys())))
@@ -209,11 +417,170 @@ func FormatSummary(metrics *stats.Metrics, mode, pattern, conflict, sourceURL, d
 		content.WriteString(Styles.InfoStatus.Render(FormatCount(metrics.GetOverwrittenKeys())))
 	}
 
+	// Only shown once a key needed the native fallback or chunking, which is
+	// rare.
+	if native := metrics.GetNativeKeys(); native > 0 {
+		content.WriteString(" | Native: ")
+		content.WriteString(Styles.InfoStatus.Render(FormatCount(native)))
+	}
+	if bigKeys := metrics.GetBigKeys(); bigKeys > 0 {
+		content.WriteString(" | Big keys: ")
+		content.WriteString(Styles.InfoStatus.Render(FormatCount(bigKeys)))
+	}
+
+	// Only shown if keys expired in flight or were dropped by a TTL policy.
+	if expired := metrics.GetExpiredKeys(); expired > 0 {
+		content.WriteString(" | Expired: ")
+		content.WriteString(Styles.InfoStatus.Render(FormatCount(expired)))
+	}
+	if dropped := metrics.GetDroppedKeys(); dropped > 0 {
+		content.WriteString(" | Dropped: ")
+		content.WriteString(Styles.InfoStatus.Render(FormatCount(dropped)))
+	}
+
+	return content.String()
+}
+
+// FormatPlan formats the result of a dry run combined with the configuration.
+func FormatPlan(plan migrate.Plan, mode, pattern, conflict, sourceURL, destURL string) string {
+	var content strings.Builder
+
+	content.WriteString(Styles.Header.Render("Dry run: nothing has been written or deleted"))
 	content.WriteString("\n")
-	content.WriteString("Rate: ")
-	content.WriteString(Styles.InfoStatus.Render(FormatRate(metrics.GetProcessingRate())))
-	content.WriteString(" | Elapsed: ")
-	content.WriteString(Styles.InfoStatus.Render(FormatDuration(metrics.GetElapsed())))
+	content.WriteString("Mode: ")
+	content.WriteString(Styles.Flag.Render(mode))
+	content.WriteString(" | Pattern: ")
+	content.WriteString(Styles.Flag.Render(pattern))
+	content.WriteString(" | Conflict: ")
+	content.WriteString(Styles.Flag.Render(conflict))
+	content.WriteString("\n")
+	content.WriteString("Source: ")
+	content.WriteString(Styles.Config.Render(sourceURL))
+	content.WriteString("\n")
+	content.WriteString("Destination: ")
+	content.WriteString(Styles.Config.Render(destURL))
+	content.WriteString("\n")
+
+	content.WriteString("Keys: ")
+	content.WriteString(Styles.InfoStatus.Render(FormatCount(plan.Keys)))
+	content.WriteString(" | Size: ")
+	content.WriteString(Styles.InfoStatus.Render(FormatBytes(plan.Bytes)))
+	content.WriteString("\n")
+
+	if len(plan.Types) > 0 {
+		content.WriteString("Types: ")
+		for i, typ := range slices.Sorted(maps.Keys(plan.Types)) {
+			if i > 0 {
+				content.WriteString(" | ")
+			}
+			content.WriteString(typ)
+			content.WriteString(": ")
+			content.WriteString(Styles.InfoStatus.Render(FormatCount(plan.Types[typ])))
+		}
+		content.WriteString("\n")
+	}
+
+	var consequence string
+	switch conflict {
+	case "error":
+		consequence = "would abort the migration"
+	case "skip":
+		consequence = "would be skipped"
+	case "overwrite":
+		consequence = "would be overwritten"
+	}
+
+	content.WriteString("Conflicts: ")
+	if plan.Conflicts > 0 {
+		content.WriteString(Styles.ErrorStatus.Render(FormatCount(plan.Conflicts)))
+		content.WriteString(" " + consequence)
+	} else {
+		content.WriteString(Styles.SuccessStatus.Render(FormatCount(plan.Conflicts)))
+	}
+	content.WriteString("\n")
+	content.WriteString(formatSamples(plan.ConflictSamples, plan.Conflicts))
+
+	if mode == "move" {
+		content.WriteString("Deletes from source: ")
+		content.WriteString(Styles.InfoStatus.Render(FormatCount(plan.Deletes)))
+		content.WriteString("\n")
+		content.WriteString(formatSamples(plan.DeleteSamples, plan.Deletes))
+	}
+
+	return content.String()
+}
+
+// formatSamples lists sample keys of a plan, noting how many are not shown.
+func formatSamples(samples []string, total int64) string {
+	var content strings.Builder
+
+	for _, key := range samples {
+		content.WriteString("  ")
+		content.WriteString(Styles.QuotedString.Render(key))
+		content.WriteString("\n")
+	}
+
+	if more := total - int64(len(samples)); more > 0 {
+		content.WriteString("  ")
+		content.WriteString(Styles.Comment.Render(fmt.Sprintf("... and %d more", more)))
+		content.WriteString("\n")
+	}
+
+	return content.String()
+}
+
+// FormatVerifyReport formats the result of a verification.
+func FormatVerifyReport(report migrate.VerifyReport, pattern, sourceURL, destURL string) string {
+	var content strings.Builder
+
+	discrepancies := report.Missing + report.Extra + report.ValueMismatches + report.TTLMismatches
+
+	content.WriteString("Verification: ")
+	if report.OK() {
+		content.WriteString(Styles.SuccessStatus.Render("passed"))
+	} else {
+		content.WriteString(Styles.ErrorStatus.Render(fmt.Sprintf("failed with %d discrepancies", discrepancies)))
+	}
+	content.WriteString(" | Pattern: ")
+	content.WriteString(Styles.Flag.Render(pattern))
+	content.WriteString("\n")
+	content.WriteString("Source: ")
+	content.WriteString(Styles.Config.Render(sourceURL))
+	content.WriteString("\n")
+	content.WriteString("Destination: ")
+	content.WriteString(Styles.Config.Render(destURL))
+	content.WriteString("\n")
+
+	content.WriteString("Checked: ")
+	content.WriteString(Styles.InfoStatus.Render(FormatCount(report.Checked)))
+	content.WriteString(" | Matched: ")
+	content.WriteString(Styles.SuccessStatus.Render(FormatCount(report.Matched)))
+	content.WriteString(" | Missing: ")
+	content.WriteString(Styles.ErrorStatus.Render(FormatCount(report.Missing)))
+	content.WriteString(" | Extra: ")
+	content.WriteString(Styles.ErrorStatus.Render(FormatCount(report.Extra)))
+	content.WriteString(" | Value mismatches: ")
+	content.WriteString(Styles.ErrorStatus.Render(FormatCount(report.ValueMismatches)))
+	content.WriteString(" | TTL mismatches: ")
+	content.WriteString(Styles.ErrorStatus.Render(FormatCount(report.TTLMismatches)))
+	content.WriteString("\n")
+
+	for _, discrepancy := range report.Samples {
+		content.WriteString("  ")
+		content.WriteString(Styles.QuotedString.Render(discrepancy.Key))
+		content.WriteString(" ")
+		content.WriteString(Styles.Comment.Render(discrepancy.Kind.String()))
+		if discrepancy.Detail != "" {
+			content.WriteString(Styles.Comment.Render(": " + discrepancy.Detail))
+		}
+		content.WriteString("\n")
+	}
+
+	if more := discrepancies - int64(len(report.Samples)); more > 0 {
+		content.WriteString("  ")
+		content.WriteString(Styles.Comment.Render(fmt.Sprintf("... and %d more", more)))
+		content.WriteString("\n")
+	}
 
 	return content.String()
 }
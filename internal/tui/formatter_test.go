@@ -9,6 +9,7 @@ import (
 	"testing/synctest"
 	"time"
 
+	"github.com/pucke-dev/go-redismigrate/internal/migrate"
 	"github.com/pucke-dev/go-redismigrate/internal/stats"
 )
 
@@ -144,7 +145,7 @@ func TestFormatSummary(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			synctest.Run(func() {
+			synctest.Test(t, func(t *testing.T) {
 				// Create metrics with known values for consistent testing
 				testMetrics := stats.NewMetrics()
 				testMetrics.SetTotal(1000)
@@ -153,6 +154,7 @@ func TestFormatSummary(t *testing.T) {
 				testMetrics.AddFailed(25)
 				testMetrics.AddSkipped(15)
 				testMetrics.AddOverwritten(10)
+				testMetrics.AddConflicted(1)
 				
 				// Advance time by 5 minutes for consistent elapsed time
 				time.Sleep(5 * time.Minute)
@@ -164,6 +166,198 @@ func TestFormatSummary(t *testing.T) {
 	}
 }
 
+func TestFormatSummary_Interrupted(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		testMetrics := stats.NewMetrics()
+		testMetrics.SetTotal(1000)
+		testMetrics.AddProcessed(400)
+		testMetrics.AddSuccess(400)
+		testMetrics.MarkInterrupted()
+
+		time.Sleep(2 * time.Minute)
+
+		got := FormatSummary(testMetrics, "move", "user:*", "skip", "redis://localhost:6379/0", "redis://localhost:6379/1")
+		assertGolden(t, "format_summary_interrupted", got)
+	})
+}
+
+func TestFormatSummary_Native(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		testMetrics := stats.NewMetrics()
+		testMetrics.SetTotal(1000)
+		testMetrics.AddProcessed(1000)
+		testMetrics.AddSuccess(1000)
+		testMetrics.AddNative(120)
+
+		time.Sleep(2 * time.Minute)
+
+		got := FormatSummary(testMetrics, "copy", "*", "error", "redis://redis7:6379/0", "redis://redis6:6379/0")
+		assertGolden(t, "format_summary_native", got)
+	})
+}
+
+func TestFormatSummary_BigKeys(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		testMetrics := stats.NewMetrics()
+		testMetrics.SetTotal(1000)
+		testMetrics.AddProcessed(1000)
+		testMetrics.AddSuccess(998)
+		testMetrics.AddSkipped(2)
+		testMetrics.AddBigKeys(3)
+
+		time.Sleep(2 * time.Minute)
+
+		got := FormatSummary(testMetrics, "copy", "*", "skip", "redis://localhost:6379/0", "redis://localhost:6379/1")
+		assertGolden(t, "format_summary_big_keys", got)
+	})
+}
+
+func TestFormatSummary_TTLPolicy(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		testMetrics := stats.NewMetrics()
+		testMetrics.SetTotal(1000)
+		testMetrics.AddProcessed(1000)
+		testMetrics.AddSuccess(950)
+		testMetrics.AddExpired(4)
+		testMetrics.AddDropped(46)
+
+		time.Sleep(2 * time.Minute)
+
+		got := FormatSummary(testMetrics, "move", "cache:*", "error", "redis://localhost:6379/0", "redis://localhost:6379/1")
+		assertGolden(t, "format_summary_ttl_policy", got)
+	})
+}
+
+func TestFormatSummary_Follow(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		testMetrics := stats.NewMetrics()
+		testMetrics.SetTotal(1000)
+		testMetrics.AddProcessed(1000)
+		testMetrics.AddSuccess(1000)
+		testMetrics.MarkFollowing()
+		testMetrics.AddFollowEvents(4200)
+
+		time.Sleep(2 * time.Hour)
+
+		got := FormatSummary(testMetrics, "copy", "*", "error", "redis://localhost:6379/0", "redis://localhost:6379/1")
+		assertGolden(t, "format_summary_follow", got)
+	})
+}
+
+func TestFormatDestinations(t *testing.T) {
+	primary := stats.NewMetrics()
+	primary.SetTotal(1000)
+	primary.AddProcessed(1000)
+	primary.AddSuccess(1000)
+
+	replica := stats.NewMetrics()
+	replica.SetTotal(1000)
+	replica.AddProcessed(1000)
+	replica.AddSuccess(980)
+	replica.AddSkipped(20)
+
+	got := FormatDestinations([]migrate.Destination{
+		{Name: "redis://eu.example.com:6379/0", Metrics: primary},
+		{Name: "redis://us.example.com:6379/0", Metrics: replica},
+	}, "skip")
+	assertGolden(t, "format_destinations", got)
+}
+
+func TestFormatPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		conflict string
+		plan     migrate.Plan
+	}{
+		{
+			"move_mode_skip_conflict",
+			"move",
+			"skip",
+			migrate.Plan{
+				Keys:            1500,
+				Bytes:           3 * 1024 * 1024,
+				Types:           map[string]int64{"string": 1000, "hash": 400, "zset": 100},
+				Conflicts:       12,
+				ConflictSamples: []string{"user:1", "user:2"},
+				Deletes:         1488,
+				DeleteSamples:   []string{"user:10", "user:11"},
+			},
+		},
+		{
+			"copy_mode_no_conflicts",
+			"copy",
+			"error",
+			migrate.Plan{
+				Keys:  3,
+				Bytes: 200,
+				Types: map[string]int64{"string": 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatPlan(tt.plan, tt.mode, "user:*", tt.conflict, "redis://localhost:6379/0", "redis://localhost:6379/1")
+			assertGolden(t, "format_plan_"+tt.name, got)
+		})
+	}
+}
+
+func TestFormatVerifyReport(t *testing.T) {
+	tests := []struct {
+		name   string
+		report migrate.VerifyReport
+	}{
+		{"passed", migrate.VerifyReport{Checked: 1000, Matched: 1000}},
+		{
+			"failed",
+			migrate.VerifyReport{
+				Checked:         1000,
+				Matched:         990,
+				Missing:         4,
+				Extra:           2,
+				ValueMismatches: 5,
+				TTLMismatches:   1,
+				Samples: []migrate.Discrepancy{
+					{Key: "user:1", Kind: migrate.DiscrepancyMissing},
+					{Key: "user:2", Kind: migrate.DiscrepancyTTL, Detail: "source 1h0m0s, destination no expiry"},
+					{Key: "user:3", Kind: migrate.DiscrepancyExtra},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatVerifyReport(tt.report, "user:*", "redis://localhost:6379/0", "redis://localhost:6379/1")
+			assertGolden(t, "format_verify_report_"+tt.name, got)
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		bytes int64
+		want  string
+	}{
+		{"zero", 0, "0 B"},
+		{"bytes", 1023, "1023 B"},
+		{"kibibytes", 1536, "1.5 KiB"},
+		{"mebibytes", 3 * 1024 * 1024, "3.0 MiB"},
+		{"gibibytes", 5 * 1024 * 1024 * 1024, "5.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatBytes(tt.bytes); got != tt.want {
+				t.Errorf("FormatBytes(%d) = %q, want %q", tt.bytes, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestFormatCount(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -207,6 +401,27 @@ func TestFormatRate(t *testing.T) {
 	}
 }
 
+func TestFormatLag(t *testing.T) {
+	tests := []struct {
+		name string
+		lag  time.Duration
+		want string
+	}{
+		{"zero", 0, "0s"},
+		{"milliseconds", 120*time.Millisecond + 400*time.Microsecond, "120ms"},
+		{"seconds", 2500 * time.Millisecond, "2.5s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatLag(tt.lag)
+			if got != tt.want {
+				t.Errorf("FormatLag(%v) = %q, want %q", tt.lag, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestFormatDuration(t *testing.T) {
 	tests := []struct {
 		name     string
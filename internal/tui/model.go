@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"context"
 	"time"
 
 	"github.com/charmbracelet/bubbles/progress"
@@ -12,15 +13,30 @@ import (
 
 type (
 	Model struct {
-		// migrator handles the migration logic.
-		migrator *migrate.Migrator
+		// config is the configuration of the migration.
+		config migrate.Config
 
 		// metrics provides migration progress and statistics.
 		metrics *stats.Metrics
 
+		// destinations are shown with their own progress when keys are
+		// restored to several of them.
+		destinations []migrate.Destination
+
+		// verifying is set once the migration has been completed and the
+		// destination is being verified.
+		verifying bool
+
 		// progressBar is the progress bar model used to display migration progress.
 		progressBar progress.Model
 
+		// cancel stops the migration gracefully.
+		cancel context.CancelFunc
+
+		// stopping is set once the user asked to quit while the migration is
+		// still finishing its in-flight batches.
+		stopping bool
+
 		// view is the view model that renders the UI.
 		view   *View
 		err    error
@@ -31,19 +47,35 @@ type (
 	TickMsg  time.Time
 	DoneMsg  struct{}
 	ErrorMsg error
+
+	// VerifyMsg switches to the verification phase, tracking its progress
+	// in Metrics.
+	VerifyMsg struct {
+		Metrics *stats.Metrics
+	}
 )
 
-func NewModel(migrator *migrate.Migrator, metrics *stats.Metrics) Model {
+// NewModel creates the TUI model. cancel is called when the user quits while
+// the migration is running, and should stop the migration gracefully.
+func NewModel(config migrate.Config, metrics *stats.Metrics, cancel context.CancelFunc) Model {
 	prog := progress.New(progress.WithDefaultGradient())
 
 	return Model{
-		migrator:    migrator,
+		config:      config,
 		metrics:     metrics,
 		progressBar: prog,
+		cancel:      cancel,
 		view:        NewView(),
 	}
 }
 
+// WithDestinations shows the progress of every destination of a fan-out
+// migration, see [migrate.NewFanOutMigrator].
+func (m Model) WithDestinations(destinations []migrate.Destination) Model {
+	m.destinations = destinations
+	return m
+}
+
 func (m Model) Init() tea.Cmd {
 	// Trigger periodic updates.
 	return TickCmd()
@@ -60,7 +92,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "q":
-			return m, tea.Quit
+			// The first request stops the migration and waits for in-flight
+			// batches to finish; a second one quits immediately.
+			if m.err != nil || m.stopping {
+				return m, tea.Quit
+			}
+			m.stopping = true
+			m.cancel()
+			return m, nil
 		}
 
 	case tea.QuitMsg:
@@ -72,6 +111,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case DoneMsg:
 		return m, tea.Quit
 
+	case VerifyMsg:
+		m.verifying = true
+		m.metrics = msg.Metrics
+		return m, nil
+
 	case ErrorMsg:
 		m.err = msg
 		return m, nil
@@ -86,9 +130,12 @@ func (m Model) View() string {
 	}
 
 	return m.view.RenderMigrationProgress(ViewData{
-		Config:      m.migrator.GetConfig(),
-		Metrics:     m.metrics,
-		ProgressBar: m.progressBar,
+		Config:       m.config,
+		Metrics:      m.metrics,
+		Destinations: m.destinations,
+		ProgressBar:  m.progressBar,
+		Stopping:     m.stopping,
+		Verifying:    m.verifying,
 	})
 }
 
@@ -15,6 +15,17 @@ type (
 		Config      migrate.Config
 		Metrics     *stats.Metrics
 		ProgressBar progress.Model
+
+		// Destinations are shown with their own progress if there are
+		// several of them.
+		Destinations []migrate.Destination
+
+		// Stopping is set while the migration finishes its in-flight batches
+		// after the user asked to quit.
+		Stopping bool
+
+		// Verifying is set while the destination is compared with the source.
+		Verifying bool
 	}
 
 	View struct{}
@@ -33,12 +44,22 @@ func (v *View) RenderMigrationProgress(data ViewData) string {
 	var content strings.Builder
 
 	content.WriteString(v.renderHeader())
-	content.WriteString(v.renderConfiguration(data.Config))
-	content.WriteString(v.renderStatus(data.Metrics))
+	content.WriteString(v.renderConfiguration(data.Config, data.Destinations))
+	content.WriteString(v.renderStatus(data.Metrics, data.Stopping, data.Verifying))
 	content.WriteString(v.renderProgress(data.Metrics, data.ProgressBar))
-	content.WriteString(v.renderStatistics(data.Metrics, data.Config.Conflict.String()))
+	if data.Verifying {
+		content.WriteString(v.renderVerifyStatistics(data.Metrics))
+	} else {
+		content.WriteString(v.renderStatistics(data.Metrics, data.Config.Conflict.String()))
+		if len(data.Destinations) > 1 {
+			content.WriteString(v.renderDestinations(data.Destinations, data.ProgressBar, data.Config.Conflict.String()))
+		}
+	}
+	if data.Metrics.IsFollowing() {
+		content.WriteString(v.renderFollowStatistics(data.Metrics))
+	}
 	content.WriteString(v.renderPerformance(data.Metrics))
-	content.WriteString(v.renderHelp())
+	content.WriteString(v.renderHelp(data.Stopping, data.Metrics.IsFollowing()))
 
 	return content.String()
 }
@@ -52,7 +73,7 @@ func (v *View) renderHeader() string {
 	return content.String()
 }
 
-func (v *View) renderConfiguration(config migrate.Config) string {
+func (v *View) renderConfiguration(config migrate.Config, destinations []migrate.Destination) string {
 	var content strings.Builder
 
 	content.WriteString("Mode: ")
@@ -61,25 +82,43 @@ func (v *View) renderConfiguration(config migrate.Config) string {
 	content.WriteString(Styles.Flag.Render(config.Pattern))
 	content.WriteString(" | Conflict: ")
 	content.WriteString(Styles.Flag.Render(config.Conflict.String()))
+	if config.DryRun {
+		content.WriteString(" | ")
+		content.WriteString(Styles.Flag.Render("dry run"))
+	}
 	content.WriteString("\n")
 
 	content.WriteString("Source: ")
 	content.WriteString(Styles.Config.Render(config.SourceURL))
-	content.WriteString(" | Destination: ")
-	content.WriteString(Styles.Config.Render(config.DestURL))
+	if len(destinations) > 1 {
+		content.WriteString(" | Destinations: ")
+		content.WriteString(Styles.Config.Render(FormatCount(int64(len(destinations)))))
+	} else {
+		content.WriteString(" | Destination: ")
+		content.WriteString(Styles.Config.Render(config.DestURL))
+	}
 	content.WriteString("\n\n")
 
 	return content.String()
 }
 
-func (v *View) renderStatus(metrics *stats.Metrics) string {
+func (v *View) renderStatus(metrics *stats.Metrics, stopping, verifying bool) string {
 	total := metrics.GetTotalKeys()
 	processed := metrics.GetProcessedKeys()
 	
 	switch {
+	case stopping:
+		return Styles.Header.Render("Status: Stopping, finishing in-flight batches...") + "\n"
+
+	case metrics.IsFollowing():
+		return Styles.Header.Render("Status: Following changes...") + "\n"
+
 	case total == 0:
 		return Styles.Header.Render("Status: No keys to process") + "\n"
 
+	case verifying:
+		return Styles.Header.Render("Status: Verifying keys...") + "\n"
+
 	case processed < total:
 		return Styles.Header.Render("Status: Processing keys...") + "\n"
 
@@ -112,6 +151,9 @@ func (v *View) renderStatistics(metrics *stats.Metrics, conflictMode string) str
 	content.WriteString(Styles.ErrorStatus.Render(FormatCount(metrics.GetFailedKeys())))
 
 	switch conflictMode {
+	case "error":
+		content.WriteString(" | Conflicts: ")
+		content.WriteString(Styles.ErrorStatus.Render(FormatCount(metrics.GetConflictedKeys())))
 	case "skip":
 		content.WriteString(" | Skipped: ")
 		content.WriteString(Styles.InfoStatus.Render(FormatCount(metrics.GetSkippedKeys())))
@@ -120,6 +162,73 @@ func (v *View) renderStatistics(metrics *stats.Metrics, conflictMode string) str
 		content.WriteString(Styles.InfoStatus.Render(FormatCount(metrics.GetOverwrittenKeys())))
 	}
 
+	// Only shown once a key needed the native fallback or chunking, which is
+	// rare.
+	if native := metrics.GetNativeKeys(); native > 0 {
+		content.WriteString(" | Native: ")
+		content.WriteString(Styles.InfoStatus.Render(FormatCount(native)))
+	}
+	if bigKeys := metrics.GetBigKeys(); bigKeys > 0 {
+		content.WriteString(" | Big keys: ")
+		content.WriteString(Styles.InfoStatus.Render(FormatCount(bigKeys)))
+	}
+
+	// Only shown if keys expired in flight or were dropped by a TTL policy.
+	if expired := metrics.GetExpiredKeys(); expired > 0 {
+		content.WriteString(" | Expired: ")
+		content.WriteString(Styles.InfoStatus.Render(FormatCount(expired)))
+	}
+	if dropped := metrics.GetDroppedKeys(); dropped > 0 {
+		content.WriteString(" | Dropped: ")
+		content.WriteString(Styles.InfoStatus.Render(FormatCount(dropped)))
+	}
+
+	content.WriteString("\n")
+
+	return content.String()
+}
+
+// renderDestinations renders the progress and statistics of every destination
+// of a fan-out migration.
+func (v *View) renderDestinations(destinations []migrate.Destination, progressBar progress.Model, conflictMode string) string {
+	var content strings.Builder
+
+	for _, dest := range destinations {
+		content.WriteString("\n")
+		content.WriteString(Styles.Config.Render(dest.Name))
+		content.WriteString("\n")
+		content.WriteString(v.renderProgress(dest.Metrics, progressBar))
+		content.WriteString(v.renderStatistics(dest.Metrics, conflictMode))
+	}
+
+	return content.String()
+}
+
+func (v *View) renderVerifyStatistics(metrics *stats.Metrics) string {
+	var content strings.Builder
+
+	content.WriteString(Styles.Header.Render("Total: "))
+	content.WriteString(Styles.InfoStatus.Render(FormatCount(metrics.GetTotalKeys())))
+	content.WriteString(" | Checked: ")
+	content.WriteString(Styles.InfoStatus.Render(FormatCount(metrics.GetProcessedKeys())))
+	content.WriteString(" | Matched: ")
+	content.WriteString(Styles.SuccessStatus.Render(FormatCount(metrics.GetSuccessfulKeys())))
+	content.WriteString(" | Mismatched: ")
+	content.WriteString(Styles.ErrorStatus.Render(FormatCount(metrics.GetFailedKeys())))
+	content.WriteString("\n")
+
+	return content.String()
+}
+
+func (v *View) renderFollowStatistics(metrics *stats.Metrics) string {
+	var content strings.Builder
+
+	content.WriteString(Styles.Header.Render("Events applied: "))
+	content.WriteString(Styles.SuccessStatus.Render(FormatCount(metrics.GetFollowEvents())))
+	content.WriteString(" | Pending keys: ")
+	content.WriteString(Styles.InfoStatus.Render(FormatCount(metrics.GetPendingKeys())))
+	content.WriteString(" | Lag: ")
+	content.WriteString(Styles.InfoStatus.Render(FormatLag(metrics.GetFollowLag())))
 	content.WriteString("\n")
 
 	return content.String()
@@ -142,11 +251,18 @@ func (v *View) renderPerformance(metrics *stats.Metrics) string {
 	return content.String()
 }
 
-func (v *View) renderHelp() string {
+func (v *View) renderHelp(stopping, following bool) string {
 	var content strings.Builder
 
 	content.WriteString("\n")
-	content.WriteString(Styles.Help.Render("Press q or Ctrl+C to quit"))
+	switch {
+	case stopping:
+		content.WriteString(Styles.Help.Render("Press q or Ctrl+C again to quit immediately (in-flight batches may be left incomplete)"))
+	case following:
+		content.WriteString(Styles.Help.Render("Press q or Ctrl+C to stop following changes"))
+	default:
+		content.WriteString(Styles.Help.Render("Press q or Ctrl+C to stop the migration"))
+	}
 
 	return content.String()
 }